@@ -0,0 +1,322 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+const attachmentUsage = `Usage: addae attachment <command> [arguments]
+
+Commands:
+  ls      (--task id | --log id)             List the attachments on a task or a log
+  add     <file> (--task id | --log id) [--name n] [--mime type]
+                                             Attach a file, or stdin when <file> is -
+  extract <id> [--output path] [--force]     Write an attachment to a file, or stdout with -o -
+  rm      <id>                               Delete an attachment
+
+ls and add accept --format table|json|csv|markdown. Attachments are stored
+in the database, so backups of it include them. Files larger than 5 MB are
+refused; set $ADDAE_MAX_ATTACHMENT_SIZE, as in 512KB or 20MB, to change the
+limit. extract writes to the attachment's name in the current directory by
+default and checks the content against its SHA-256 hash.`
+
+// maxAttachmentSizeEnv overrides the largest attachment accepted.
+const maxAttachmentSizeEnv = "ADDAE_MAX_ATTACHMENT_SIZE"
+
+// maxAttachmentSize returns the attachment size cap set in the environment,
+// or 0 when it is not set.
+func maxAttachmentSize() (int64, error) {
+	v := os.Getenv(maxAttachmentSizeEnv)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := service.ParseSize(v)
+	if err != nil {
+		return 0, fmt.Errorf("$%s: %w", maxAttachmentSizeEnv, err)
+	}
+	return n, nil
+}
+
+// applyMaxAttachmentSize sets the service's size cap from the environment.
+// Only the commands that take attachments call it, so a bad value does not
+// break the others.
+func (a *App) applyMaxAttachmentSize() error {
+	n, err := maxAttachmentSize()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		a.svc.SetMaxAttachmentSize(n)
+	}
+	return nil
+}
+
+func (a *App) runAttachment(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, attachmentUsage)
+		return usagef("missing attachment command")
+	}
+
+	switch args[0] {
+	case "ls", "list":
+		return a.attachmentList(args[1:])
+	case "add":
+		return a.attachmentAdd(args[1:])
+	case "extract":
+		return a.attachmentExtract(args[1:])
+	case "rm", "remove":
+		return a.attachmentRemove(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stdout, attachmentUsage)
+		return nil
+	default:
+		fmt.Fprintln(a.stderr, attachmentUsage)
+		return usagef("unknown attachment command %q", args[0])
+	}
+}
+
+func (a *App) attachmentList(args []string) error {
+	fs := a.newFlagSet("attachment ls")
+	taskRef := fs.String("task", "", "ID of the task whose attachments to list")
+	logRef := fs.String("log", "", "ID of the log whose attachments to list")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+	if (*taskRef == "") == (*logRef == "") {
+		return usagef("expected one of --task or --log")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	var attachments []service.Attachment
+	if *taskRef != "" {
+		task, err := a.resolveTask(*taskRef)
+		if err != nil {
+			return err
+		}
+		attachments, err = a.svc.ListTaskAttachments(task.ID)
+		if err != nil {
+			return err
+		}
+	} else {
+		l, err := a.resolveLog(*logRef)
+		if err != nil {
+			return err
+		}
+		attachments, err = a.svc.ListLogAttachments(l.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return a.printAttachments(*format, attachments)
+}
+
+func (a *App) attachmentAdd(args []string) error {
+	fs := a.newFlagSet("attachment add")
+	taskRef := fs.String("task", "", "ID of the task to attach the file to")
+	logRef := fs.String("log", "", "ID of the log to attach the file to")
+	name := fs.String("name", "", "name to store the file under")
+	mimeType := fs.String("mime", "", "MIME type, guessed from the name or content when empty")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one file")
+	}
+	if (*taskRef == "") == (*logRef == "") {
+		return usagef("expected one of --task or --log")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+	path := rest[0]
+	if path == "-" && strings.TrimSpace(*name) == "" {
+		return usagef("--name is required when reading from stdin")
+	}
+	if err := a.applyMaxAttachmentSize(); err != nil {
+		return err
+	}
+
+	att := service.Attachment{Name: *name, MIMEType: *mimeType}
+	if att.Name == "" {
+		att.Name = filepath.Base(path)
+	}
+	var owner string
+	if *taskRef != "" {
+		task, err := a.resolveTask(*taskRef)
+		if err != nil {
+			return err
+		}
+		att.TaskID = &task.ID
+		owner = fmt.Sprintf("task %d (%s)", task.ID, task.Title)
+	} else {
+		l, err := a.resolveLog(*logRef)
+		if err != nil {
+			return err
+		}
+		att.LogID = &l.ID
+		owner = fmt.Sprintf("log %d (%s)", l.ID, l.Title)
+	}
+
+	data, err := a.readAttachment(path)
+	if err != nil {
+		return err
+	}
+	if err := a.svc.AddAttachment(&att, data); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		return a.printAttachments(*format, []service.Attachment{att})
+	}
+	fmt.Fprintf(a.stdout, "Attached %s (%s) to %s as attachment %d\n",
+		att.Name, service.FormatSize(att.Size), owner, att.ID)
+	return nil
+}
+
+// readAttachment reads the file at path, or stdin for -. It stops one byte
+// past the size cap, which is enough for the service to refuse the file
+// without reading all of a large one.
+func (a *App) readAttachment(path string) ([]byte, error) {
+	r := a.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(io.LimitReader(r, a.svc.MaxAttachmentSize()+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+func (a *App) attachmentExtract(args []string) error {
+	fs := a.newFlagSet("attachment extract")
+	output := fs.String("output", "", "file to write, or - for stdout")
+	fs.StringVar(output, "o", "", "shorthand for --output")
+	force := fs.Bool("force", false, "overwrite an existing file")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one attachment ID")
+	}
+
+	att, err := a.resolveAttachment(rest[0])
+	if err != nil {
+		return err
+	}
+	data, err := a.svc.ReadAttachment(att.ID)
+	if err != nil {
+		return err
+	}
+	if *output == "-" {
+		_, err := a.stdout.Write(data)
+		return err
+	}
+
+	path := *output
+	if path == "" {
+		path = att.Name
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists; pass --force to overwrite it or --output to pick another path", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Extracted attachment %d to %s (%s)\n", att.ID, path, service.FormatSize(att.Size))
+	return nil
+}
+
+func (a *App) attachmentRemove(args []string) error {
+	fs := a.newFlagSet("attachment rm")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one attachment ID")
+	}
+
+	att, err := a.resolveAttachment(rest[0])
+	if err != nil {
+		return err
+	}
+	if err := a.svc.DeleteAttachment(att.ID); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Deleted attachment %d: %s\n", att.ID, att.Name)
+	return nil
+}
+
+// resolveAttachment looks up an attachment by its numeric ID.
+func (a *App) resolveAttachment(ref string) (*service.Attachment, error) {
+	id, err := strconv.Atoi(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, usagef("invalid attachment ID %q", ref)
+	}
+	return a.svc.GetAttachment(id)
+}
+
+// printAttachments writes attachments in the given format.
+func (a *App) printAttachments(format string, attachments []service.Attachment) error {
+	if format == formatJSON {
+		if attachments == nil {
+			attachments = []service.Attachment{}
+		}
+		return a.writeJSON(attachments)
+	}
+
+	var t table
+	if format == formatTable {
+		t.header = []string{"id", "name", "type", "size", "added"}
+		for _, att := range attachments {
+			t.rows = append(t.rows, []string{
+				strconv.Itoa(att.ID), att.Name, att.MIMEType, service.FormatSize(att.Size),
+				formatTime(format, &att.DateCreated),
+			})
+		}
+		return a.writeTable(format, t)
+	}
+
+	t.header = []string{"id", "task_id", "log_id", "name", "mime_type", "size", "sha256", "created_at"}
+	for _, att := range attachments {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(att.ID), formatID(att.TaskID), formatID(att.LogID), att.Name, att.MIMEType,
+			strconv.FormatInt(att.Size, 10), att.SHA256, formatTime(format, &att.DateCreated),
+		})
+	}
+	return a.writeTable(format, t)
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+func TestAttachmentCommands(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Addae")
+	app.run(t, 0, "task", "add", "Addae", "Fix login")
+	app.stdin = strings.NewReader("Outage notes")
+	app.run(t, 0, "log", "Addae", "-t", "Outage")
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(src, []byte("port: 8080\n"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	out := app.run(t, 0, "attachment", "add", src, "--task", "1")
+	if !strings.Contains(out, "Attached config.yaml (11 B) to task 1 (Fix login) as attachment 1") {
+		t.Errorf("unexpected add output: %q", out)
+	}
+	app.stdin = strings.NewReader("panic: nil map")
+	out = app.run(t, 0, "attachment", "add", "-", "--log", "1", "--name", "trace.txt", "--format", "json")
+	var added []service.Attachment
+	if err := json.Unmarshal([]byte(out), &added); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(added) != 1 || added[0].LogID == nil || added[0].Size != 14 || len(added[0].SHA256) != 64 {
+		t.Errorf("unexpected attachment: %+v", added)
+	}
+	app.run(t, 2, "attachment", "add", src)
+	app.run(t, 2, "attachment", "add", "-", "--task", "1")
+	app.run(t, 1, "attachment", "add", src, "--task", "99")
+	app.run(t, 1, "attachment", "add", filepath.Join(dir, "missing"), "--task", "1")
+
+	app.svc.SetMaxAttachmentSize(10)
+	app.run(t, 1, "attachment", "add", src, "--task", "1")
+	if !strings.Contains(app.stderr.String(), "over the 10 B limit") {
+		t.Errorf("expected the size cap in the error, got %q", app.stderr.String())
+	}
+	app.svc.SetMaxAttachmentSize(service.DefaultMaxAttachmentSize)
+
+	out = app.run(t, 0, "attachment", "ls", "--task", "1")
+	for _, want := range []string{"config.yaml", "11 B"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the attachment list, got %q", want, out)
+		}
+	}
+	out = app.run(t, 0, "attachment", "ls", "--log", "1", "--format", "csv")
+	if !strings.Contains(out, "task_id,log_id,name,mime_type,size,sha256") || !strings.Contains(out, "trace.txt") {
+		t.Errorf("expected the log's attachment as CSV, got %q", out)
+	}
+	app.run(t, 2, "attachment", "ls")
+
+	dest := filepath.Join(dir, "restored.yaml")
+	out = app.run(t, 0, "attachment", "extract", "1", "--output", dest)
+	if !strings.Contains(out, "Extracted attachment 1 to "+dest) {
+		t.Errorf("unexpected extract output: %q", out)
+	}
+	if data, err := os.ReadFile(dest); err != nil || string(data) != "port: 8080\n" {
+		t.Errorf("expected the original content, got %q, %v", data, err)
+	}
+	app.run(t, 1, "attachment", "extract", "1", "--output", dest)
+	app.run(t, 0, "attachment", "extract", "1", "--output", dest, "--force")
+	if out := app.run(t, 0, "attachment", "extract", "2", "-o", "-"); out != "panic: nil map" {
+		t.Errorf("expected the content on stdout, got %q", out)
+	}
+
+	out = app.run(t, 0, "attachment", "rm", "1")
+	if !strings.Contains(out, "Deleted attachment 1: config.yaml") {
+		t.Errorf("unexpected rm output: %q", out)
+	}
+	app.run(t, 1, "attachment", "extract", "1")
+	app.run(t, 2, "attachment", "rm", "one")
+}
+
+func TestMaxAttachmentSizeFromEnv(t *testing.T) {
+	t.Setenv(maxAttachmentSizeEnv, "")
+	if n, err := maxAttachmentSize(); n != 0 || err != nil {
+		t.Errorf("expected no cap when unset, got %d, %v", n, err)
+	}
+	t.Setenv(maxAttachmentSizeEnv, "512KB")
+	if n, err := maxAttachmentSize(); n != 512<<10 || err != nil {
+		t.Errorf("expected 512KB, got %d, %v", n, err)
+	}
+	t.Setenv(maxAttachmentSizeEnv, "lots")
+	if _, err := maxAttachmentSize(); err == nil || !strings.Contains(err.Error(), maxAttachmentSizeEnv) {
+		t.Errorf("expected an error naming the variable, got %v", err)
+	}
+
+	// Only the commands that take attachments read the variable.
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Addae")
+	app.run(t, 0, "project", "list")
+	app.run(t, 0, "task", "add", "Addae", "Fix login")
+	app.stdin = strings.NewReader("notes")
+	app.run(t, 1, "attachment", "add", "-", "--name", "notes.txt", "--task", "1")
+
+	t.Setenv(maxAttachmentSizeEnv, "4B")
+	app.stdin = strings.NewReader("notes")
+	app.run(t, 1, "attachment", "add", "-", "--name", "notes.txt", "--task", "1")
+	app.stdin = strings.NewReader("note")
+	app.run(t, 0, "attachment", "add", "-", "--name", "notes.txt", "--task", "1")
+}
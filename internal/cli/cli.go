@@ -0,0 +1,349 @@
+// Package cli implements the non-interactive addae subcommands.
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/quamejnr/addae/internal/db"
+	"github.com/quamejnr/addae/internal/service"
+	"github.com/quamejnr/addae/internal/ui"
+	"github.com/quamejnr/addae/internal/workspace"
+)
+
+// Options selects the database an App works on.
+type Options struct {
+	// DBPath is an explicit database file. It overrides $ADDAE_DB and Workspace.
+	DBPath string
+	// Workspace is the named workspace to open instead of the persisted default.
+	Workspace string
+	// Migrations are applied whenever a database is opened.
+	Migrations fs.FS
+}
+
+// App holds the dependencies shared by every subcommand.
+type App struct {
+	opts   Options
+	store  *workspace.Store
+	db     *sql.DB
+	svc    *service.Service
+	dbName string // workspace name, or file path when --db or $ADDAE_DB is used
+	dbPath string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// NewApp creates an App wired to the process's standard streams.
+func NewApp(opts Options) (*App, error) {
+	store, err := workspace.DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+	return &App{
+		opts:   opts,
+		store:  store,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}, nil
+}
+
+// command describes a top-level subcommand.
+type command struct {
+	summary string
+	run     func(a *App, args []string) error
+	// standalone commands run without opening the database.
+	standalone bool
+	// unmigrated commands open the database without applying migrations.
+	unmigrated bool
+	// hidden commands are left out of the usage message.
+	hidden bool
+}
+
+var commands = map[string]command{
+	"project":    {summary: "Manage projects", run: (*App).runProject},
+	"task":       {summary: "Capture, list and complete tasks", run: (*App).runTask},
+	"log":        {summary: "Write a development log from $EDITOR or stdin", run: (*App).runLog},
+	"tag":        {summary: "List, add, remove and rename tags", run: (*App).runTag},
+	"timer":      {summary: "Time tasks and report the time spent on projects", run: (*App).runTimer},
+	"milestone":  {summary: "Plan milestones inside a project", run: (*App).runMilestone},
+	"state":      {summary: "List and configure task workflow states", run: (*App).runState},
+	"link":       {summary: "Link projects and tasks to URLs and files", run: (*App).runLink},
+	"attachment": {summary: "Attach small files to tasks and logs", run: (*App).runAttachment},
+	"workspace":  {summary: "Manage named workspaces", run: (*App).runWorkspace, standalone: true},
+	"migrate":    {summary: "Show, apply or roll back schema migrations", run: (*App).runMigrate, unmigrated: true},
+	"open":       {summary: "Start the interactive UI on a project, tab, task or log", run: (*App).runOpen},
+	"serve":      {summary: "Serve projects, tasks and logs as a local JSON API", run: (*App).runServe},
+	"completion": {summary: "Print a bash, zsh or fish completion script", run: (*App).runCompletion, standalone: true},
+	"doctor":     {summary: "Check the database for problems and repair them", run: (*App).runDoctor, unmigrated: true},
+}
+
+// usageError signals that a command was invoked incorrectly.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// Run executes the subcommand named by args[0], or starts the TUI when args is
+// empty, and returns the process exit code: 0 on success, 1 when the command
+// fails and 2 when it is used incorrectly.
+func (a *App) Run(args []string) int {
+	defer a.close()
+
+	if len(args) == 0 {
+		if err := a.open(); err != nil {
+			fmt.Fprintf(a.stderr, "addae: %v\n", err)
+			return 1
+		}
+		if err := a.runTUI(nil); err != nil {
+			fmt.Fprintf(a.stderr, "addae: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		a.printUsage()
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(a.stderr, "addae: unknown command %q\n\n", args[0])
+		a.printUsage()
+		return 2
+	}
+
+	if !cmd.standalone {
+		if err := a.openDB(!cmd.unmigrated); err != nil {
+			fmt.Fprintf(a.stderr, "addae %s: %v\n", args[0], err)
+			return 1
+		}
+	}
+
+	err := cmd.run(a, args[1:])
+	var usageErr usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(a.stderr, "addae %s: %v\n", args[0], err)
+		return 2
+	default:
+		fmt.Fprintf(a.stderr, "addae %s: %v\n", args[0], err)
+		return 1
+	}
+}
+
+// open connects to the selected database and applies pending migrations.
+func (a *App) open() error {
+	return a.openDB(true)
+}
+
+// openDB connects to the selected database, applying pending migrations when
+// migrate is set and no rollback has pinned the database at an older version.
+func (a *App) openDB(migrate bool) error {
+	if a.svc != nil {
+		return nil
+	}
+
+	path, name, err := a.resolveDB()
+	if err != nil {
+		return err
+	}
+
+	database, err := db.InitDB(path)
+	if err != nil {
+		return err
+	}
+	if migrate && a.opts.Migrations != nil {
+		if err := db.RunMigrationsFromFS(database, a.opts.Migrations); err != nil {
+			database.Close()
+			return err
+		}
+		version, pinned, err := db.PinnedVersion(context.Background(), database)
+		if err != nil {
+			database.Close()
+			return err
+		}
+		if pinned {
+			fmt.Fprintf(a.stderr, "addae: staying at migration %d after a rollback, apply the rest with: addae migrate up\n", version)
+		}
+	}
+
+	a.db = database
+	a.svc = service.NewService(database)
+	a.dbName = name
+	a.dbPath = path
+	return nil
+}
+
+func (a *App) close() {
+	if a.db != nil {
+		a.db.Close()
+		a.db = nil
+		a.svc = nil
+	}
+}
+
+// resolveDB picks the database file from --db, then $ADDAE_DB, then
+// --workspace and finally the persisted default workspace.
+func (a *App) resolveDB() (path, name string, err error) {
+	if a.opts.DBPath != "" {
+		return a.opts.DBPath, a.opts.DBPath, nil
+	}
+	if env := os.Getenv("ADDAE_DB"); env != "" {
+		return env, env, nil
+	}
+
+	name = a.opts.Workspace
+	if name == "" {
+		if name, err = a.store.Current(); err != nil {
+			return "", "", err
+		}
+	}
+	if err := workspace.ValidateName(name); err != nil {
+		return "", "", err
+	}
+	if name != workspace.DefaultName && !a.store.Exists(name) {
+		return "", "", fmt.Errorf("workspace %q does not exist, create it with: addae workspace create %s", name, name)
+	}
+
+	path = a.store.Path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create application directory: %w", err)
+	}
+	return path, name, nil
+}
+
+// runTUI starts the interactive interface on the open database, opening
+// target first when it is not nil.
+func (a *App) runTUI(target *ui.OpenTarget) error {
+	model, err := ui.NewModel(a.svc)
+	if err != nil {
+		return err
+	}
+	model.SetWorkspace(a.dbName)
+	if target != nil {
+		if err := model.Open(*target); err != nil {
+			return err
+		}
+	}
+
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	_, err = p.Run()
+	return err
+}
+
+func (a *App) printUsage() {
+	names := make([]string, 0, len(commands))
+	for name, cmd := range commands {
+		if !cmd.hidden {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(a.stderr, "Usage: addae [--db path | --workspace name] [command] [arguments]")
+	fmt.Fprintln(a.stderr, "\nRun without a command to start the interactive UI.")
+	fmt.Fprintln(a.stderr, "\nCommands:")
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+// newFlagSet returns a FlagSet that reports errors instead of exiting.
+func (a *App) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("addae "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	return fs
+}
+
+// parseArgs parses fs against args, allowing flags to appear before, between
+// or after positional arguments. It returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError{msg: err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// flagsSet returns the names of the flags that were explicitly set on fs.
+func flagsSet(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// resolveProject finds a project by numeric ID or by a unique,
+// case-insensitive name prefix. An exact name match always wins.
+func (a *App) resolveProject(ref string) (*service.Project, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, usagef("project reference is required")
+	}
+
+	if id, err := strconv.Atoi(ref); err == nil {
+		if p, err := a.svc.GetProject(id); err == nil {
+			return p, nil
+		}
+	}
+
+	projects, err := a.svc.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	needle := strings.ToLower(ref)
+	var matches []service.Project
+	for _, p := range projects {
+		name := strings.ToLower(p.Name)
+		if name == needle {
+			return &p, nil
+		}
+		if strings.HasPrefix(name, needle) {
+			matches = append(matches, p)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no project matches %q", ref)
+	case 1:
+		return &matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, p := range matches {
+			names[i] = fmt.Sprintf("%s (%d)", p.Name, p.ID)
+		}
+		return nil, fmt.Errorf("%q is ambiguous, it matches: %s", ref, strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+
+	adb "github.com/quamejnr/addae/internal/db"
+	"github.com/quamejnr/addae/internal/service"
+	"github.com/quamejnr/addae/internal/workspace"
+)
+
+// testApp bundles an App with the buffers it writes to.
+type testApp struct {
+	*App
+	db     *sql.DB
+	stdout *bytes.Buffer
+	stderr *bytes.Buffer
+}
+
+func setupTestApp(t *testing.T) *testApp {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	// Every new connection to :memory: is a fresh database, so pin the pool to one.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := adb.RunMigrations(db, "../db/migrations"); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	app := &App{
+		store:  workspace.NewStore(t.TempDir()),
+		svc:    service.NewService(db),
+		stdin:  strings.NewReader(""),
+		stdout: stdout,
+		stderr: stderr,
+	}
+	return &testApp{App: app, db: db, stdout: stdout, stderr: stderr}
+}
+
+// run executes args and fails the test if the exit code is not want.
+func (ta *testApp) run(t *testing.T, want int, args ...string) string {
+	t.Helper()
+	ta.stdout.Reset()
+	ta.stderr.Reset()
+	if code := ta.Run(args); code != want {
+		t.Fatalf("addae %s: expected exit code %d, got %d\nstdout: %s\nstderr: %s",
+			strings.Join(args, " "), want, code, ta.stdout, ta.stderr)
+	}
+	return ta.stdout.String()
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 2, "bogus")
+	if !strings.Contains(app.stderr.String(), `unknown command "bogus"`) {
+		t.Errorf("expected unknown command error, got %q", app.stderr.String())
+	}
+}
+
+func TestProjectAddAndList(t *testing.T) {
+	app := setupTestApp(t)
+
+	out := app.run(t, 0, "project", "add", "Website", "--summary", "Company site", "--status", "in progress")
+	if !strings.Contains(out, "Created project 1: Website") {
+		t.Errorf("unexpected add output: %q", out)
+	}
+	app.run(t, 0, "project", "add", "--name", "Mobile App")
+
+	out = app.run(t, 0, "project", "list")
+	if !strings.Contains(out, "Website") || !strings.Contains(out, "Mobile App") {
+		t.Errorf("expected both projects in list, got %q", out)
+	}
+
+	out = app.run(t, 0, "project", "list", "--status", "todo")
+	if strings.Contains(out, "Website") || !strings.Contains(out, "Mobile App") {
+		t.Errorf("expected only todo projects, got %q", out)
+	}
+}
+
+func TestProjectAddValidation(t *testing.T) {
+	app := setupTestApp(t)
+
+	app.run(t, 2, "project", "add")
+	app.run(t, 2, "project", "add", "Website", "--status", "done")
+	app.run(t, 2, "project", "add", strings.Repeat("x", 101))
+	app.run(t, 0, "project", "add", strings.Repeat("é", 60), "--summary", strings.Repeat("é", 200))
+}
+
+func TestProjectShowByPrefix(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website", "--desc", "Long description")
+	app.run(t, 0, "project", "add", "Webhooks")
+
+	out := app.run(t, 0, "project", "show", "websi")
+	if !strings.Contains(out, "Website") || !strings.Contains(out, "Long description") {
+		t.Errorf("unexpected show output: %q", out)
+	}
+
+	app.run(t, 1, "project", "show", "web")
+	if !strings.Contains(app.stderr.String(), "ambiguous") {
+		t.Errorf("expected ambiguous error, got %q", app.stderr.String())
+	}
+
+	app.run(t, 1, "project", "show", "nothing")
+}
+
+func TestProjectShowByID(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+
+	out := app.run(t, 0, "project", "show", "1")
+	if !strings.Contains(out, "Website") {
+		t.Errorf("unexpected show output: %q", out)
+	}
+}
+
+func TestProjectUpdate(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website", "--summary", "Old summary")
+
+	app.run(t, 2, "project", "update", "Website")
+	app.run(t, 0, "project", "update", "Website", "--status", "completed", "--name", "Site")
+
+	p, err := app.svc.GetProject(1)
+	if err != nil {
+		t.Fatalf("GetProject failed: %v", err)
+	}
+	if p.Name != "Site" || p.Status != "completed" {
+		t.Errorf("expected updated name and status, got %q and %q", p.Name, p.Status)
+	}
+	if p.Summary != "Old summary" {
+		t.Errorf("expected summary to be untouched, got %q", p.Summary)
+	}
+}
+
+func TestProjectRemove(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+
+	app.run(t, 0, "project", "rm", "website")
+	projects, err := app.svc.ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	if len(projects) != 0 {
+		t.Errorf("expected 0 projects, got %d", len(projects))
+	}
+
+	app.run(t, 1, "project", "rm", "website")
+}
+
+func TestProjectParent(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Client A")
+	app.run(t, 0, "project", "add", "Website", "--parent", "Client A")
+	app.run(t, 0, "project", "add", "API", "--parent", "2")
+	app.run(t, 1, "project", "add", "Docs", "--parent", "Nope")
+	app.run(t, 0, "task", "add", "API", "Endpoints")
+	app.run(t, 0, "task", "done", "1")
+
+	out := app.run(t, 0, "project", "ls")
+	for _, want := range []string{"  Website", "    API", "1/1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the project tree, got %q", want, out)
+		}
+	}
+	out = app.run(t, 0, "project", "show", "Client A")
+	for _, want := range []string{"Progress:", "1/1 tasks done", "Website"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the project, got %q", want, out)
+		}
+	}
+
+	app.run(t, 1, "project", "update", "Client A", "--parent", "API")
+	app.run(t, 0, "project", "update", "API", "--parent", "")
+	p, err := app.svc.GetProject(3)
+	if err != nil {
+		t.Fatalf("GetProject failed: %v", err)
+	}
+	if p.ParentID != nil {
+		t.Errorf("expected API to be a top-level project, got %d", *p.ParentID)
+	}
+}
@@ -0,0 +1,549 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+const completionUsage = `Usage: addae completion bash|zsh|fish
+
+Prints a completion script. Project names, task IDs and workspace names are
+completed from the database as you type. To install:
+
+  bash:  echo 'source <(addae completion bash)' >> ~/.bashrc
+  zsh:   echo 'source <(addae completion zsh)' >> ~/.zshrc
+  fish:  addae completion fish > ~/.config/fish/completions/addae.fish`
+
+// completionScripts call the hidden __complete command with the words typed
+// so far, the last one being the word under the cursor. It prints one
+// candidate per line as value<TAB>description.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for addae
+_addae() {
+    local IFS=$'\n' line
+    COMPREPLY=()
+    for line in $(addae __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null); do
+        COMPREPLY+=("$(printf '%q' "${line%%$'\t'*}")")
+    done
+}
+complete -F _addae addae
+`,
+	"zsh": `#compdef addae
+# zsh completion for addae
+_addae() {
+    local -a candidates
+    local line
+    for line in "${(@f)$(addae __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+        [[ -z $line ]] && continue
+        candidates+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+    done
+    _describe 'addae' candidates
+}
+compdef _addae addae
+`,
+	"fish": `# fish completion for addae
+function __addae_complete
+    set -l tokens (commandline -opc)
+    set -l cur (commandline -ct)
+    addae __complete $tokens[2..-1] "$cur" 2>/dev/null
+end
+complete -c addae -f -a '(__addae_complete)'
+`,
+}
+
+func (a *App) runCompletion(args []string) error {
+	if len(args) != 1 {
+		fmt.Fprintln(a.stderr, completionUsage)
+		return usagef("expected exactly one shell")
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprintln(a.stdout, completionUsage)
+		return nil
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return usagef("unsupported shell %q, expected bash, zsh or fish", args[0])
+	}
+	fmt.Fprint(a.stdout, script)
+	return nil
+}
+
+// candidate is a single completion with an optional description.
+type candidate struct {
+	value string
+	desc  string
+}
+
+// subcommands lists the subcommands offered for each command.
+var subcommands = map[string][]string{
+	"project":    {"list", "add", "show", "update", "rm"},
+	"task":       {"add", "ls", "done", "undone", "state", "due", "priority", "repeat", "parent", "milestone", "move", "block", "unblock", "rm"},
+	"log":        {"ls", "link", "unlink"},
+	"tag":        {"ls", "add", "rm", "rename"},
+	"timer":      {"start", "stop", "status", "report"},
+	"milestone":  {"ls", "add", "show", "update", "rm"},
+	"state":      {"ls", "add", "rm"},
+	"link":       {"ls", "add", "rm"},
+	"attachment": {"ls", "add", "extract", "rm"},
+	"workspace":  {"list", "create", "use", "rm"},
+	"migrate":    {"status", "up", "down", "down-to", "redo"},
+	"completion": {"bash", "zsh", "fish"},
+}
+
+// commandFlags lists the flags of each command, keyed by "command" or
+// "command subcommand". Boolean flags are listed in boolFlags.
+var commandFlags = map[string][]string{
+	"project list":       {"--status", "--tag", "--format"},
+	"project add":        {"--summary", "--desc", "--status", "--parent", "--format"},
+	"project show":       {"--format"},
+	"project update":     {"--name", "--summary", "--desc", "--status", "--parent", "--format"},
+	"project rm":         {"--format"},
+	"task add":           {"--desc", "--due", "--priority", "--parent", "--repeat", "--format"},
+	"task ls":            {"--all", "--done", "--tag", "--milestone", "--state", "--format"},
+	"task done":          {"--format"},
+	"task undone":        {"--format"},
+	"task state":         {"--format"},
+	"task due":           {"--format"},
+	"task priority":      {"--format"},
+	"task repeat":        {"--format"},
+	"task parent":        {"--format"},
+	"task milestone":     {"--format"},
+	"task move":          {"--format"},
+	"task block":         {"--format"},
+	"task unblock":       {"--format"},
+	"task rm":            {"--format"},
+	"log":                {"--title", "--task", "--format"},
+	"log ls":             {"--task", "--format"},
+	"log link":           {"--format"},
+	"log unlink":         {"--format"},
+	"tag ls":             {"--format"},
+	"tag add":            {"--project", "--task"},
+	"tag rm":             {"--project", "--task"},
+	"timer status":       {"--format"},
+	"timer report":       {"--format"},
+	"milestone ls":       {"--format"},
+	"milestone add":      {"--target", "--desc", "--format"},
+	"milestone show":     {"--format"},
+	"milestone update":   {"--name", "--target", "--desc", "--format"},
+	"milestone rm":       {"--format"},
+	"state ls":           {"--format"},
+	"link ls":            {"--project", "--task", "--format"},
+	"link add":           {"--project", "--task", "--label", "--format"},
+	"attachment ls":      {"--task", "--log", "--format"},
+	"attachment add":     {"--task", "--log", "--name", "--mime", "--format"},
+	"attachment extract": {"--output", "--force"},
+	"open":               {"--tab", "--task", "--log"},
+	"serve":              {"--addr"},
+	"doctor":             {"--fix", "--backup", "--format"},
+	"workspace list":     {"--format"},
+	"workspace create":   {"--use"},
+	"workspace rm":       {"--force"},
+	"migrate status":     {"--format"},
+	"migrate up":         {"--dry-run"},
+	"migrate down":       {"--dry-run"},
+	"migrate down-to":    {"--dry-run"},
+	"migrate redo":       {"--dry-run"},
+}
+
+// boolFlags take no value.
+var boolFlags = []string{"all", "done", "fix", "use", "force", "dry-run"}
+
+// __complete lists commands itself, so it is registered here to avoid an
+// initialization cycle.
+func init() {
+	commands["__complete"] = command{run: (*App).runComplete, standalone: true, hidden: true}
+}
+
+// runComplete prints the completions for the words typed after "addae". It is
+// used by the completion scripts and prints nothing when it cannot help.
+func (a *App) runComplete(args []string) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	for _, c := range a.complete(args) {
+		fmt.Fprintf(a.stdout, "%s\t%s\n", c.value, c.desc)
+	}
+	return nil
+}
+
+// complete returns the candidates for the last word in words.
+func (a *App) complete(words []string) []candidate {
+	cur := words[len(words)-1]
+	words = words[:len(words)-1]
+
+	// Leading global flags select the database to complete from.
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(words[0], "-"), "=")
+		if !hasValue {
+			if len(words) == 1 {
+				if name == "workspace" {
+					return a.completeWorkspaces(cur)
+				}
+				return nil
+			}
+			value = words[1]
+			words = words[1:]
+		}
+		switch name {
+		case "db":
+			a.opts.DBPath = value
+		case "workspace":
+			a.opts.Workspace = value
+		}
+		words = words[1:]
+	}
+
+	if len(words) == 0 {
+		if strings.HasPrefix(cur, "-") {
+			return filterValues(cur, []string{"--db", "--workspace", "--version"})
+		}
+		var names []string
+		for name, cmd := range commands {
+			if !cmd.hidden {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		var out []candidate
+		for _, name := range names {
+			if strings.HasPrefix(name, cur) {
+				out = append(out, candidate{value: name, desc: commands[name].summary})
+			}
+		}
+		return out
+	}
+
+	cmd, rest := words[0], words[1:]
+	key := cmd
+	if subs, ok := subcommands[cmd]; ok && len(rest) > 0 && slices.Contains(subs, rest[0]) {
+		key, rest = cmd+" "+rest[0], rest[1:]
+	}
+
+	// Complete the value of the flag before the cursor.
+	if n := len(rest); n > 0 && strings.HasPrefix(rest[n-1], "-") && !strings.Contains(rest[n-1], "=") {
+		if flag := strings.TrimLeft(rest[n-1], "-"); !slices.Contains(boolFlags, flag) {
+			if (cmd == "tag" || cmd == "link" || cmd == "attachment" || key == "log ls") && flag == "task" {
+				return a.completeTasks(cur, 0, nil)
+			}
+			if cmd == "project" && flag == "parent" {
+				return a.completeProjects(cur)
+			}
+			return a.completeFlagValue(flag, cur, positionals(rest[:n-1]))
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		return filterValues(cur, commandFlags[key])
+	}
+
+	args := positionals(rest)
+	if key == cmd {
+		if subs, ok := subcommands[cmd]; ok && len(args) == 0 && cmd != "log" {
+			return filterValues(cur, subs)
+		}
+	}
+
+	switch key {
+	case "project show", "project update", "project rm", "task add", "task ls", "log ls", "timer report",
+		"milestone ls", "milestone add", "open":
+		if len(args) == 0 {
+			return a.completeProjects(cur)
+		}
+	case "log":
+		if len(args) == 0 {
+			return append(filterValues(cur, subcommands["log"]), a.completeProjects(cur)...)
+		}
+	case "log link", "log unlink":
+		if len(args) > 0 {
+			return a.completeTasks(cur, 0, nil)
+		}
+	case "task done":
+		if len(args) == 0 {
+			return a.completeTasks(cur, 0, func(t service.TaskRef) bool { return t.CompletedAt == nil })
+		}
+	case "task undone":
+		if len(args) == 0 {
+			return a.completeTasks(cur, 0, func(t service.TaskRef) bool { return t.CompletedAt != nil })
+		}
+	case "timer start":
+		if len(args) == 0 {
+			return a.completeTasks(cur, 0, func(t service.TaskRef) bool { return t.CompletedAt == nil })
+		}
+	case "task due", "task rm":
+		if len(args) == 0 {
+			return a.completeTasks(cur, 0, nil)
+		}
+	case "task parent":
+		if len(args) < 2 {
+			return a.completeTasks(cur, 0, nil)
+		}
+	case "task state":
+		switch len(args) {
+		case 0:
+			return a.completeTasks(cur, 0, nil)
+		case 1:
+			return a.completeStates(cur)
+		}
+	case "state rm":
+		if len(args) == 0 {
+			return a.completeStates(cur)
+		}
+	case "task move":
+		switch len(args) {
+		case 0:
+			return a.completeTasks(cur, 0, nil)
+		case 1:
+			return filterValues(cur, []string{"top", "bottom"})
+		}
+	case "task milestone":
+		switch len(args) {
+		case 0:
+			return a.completeTasks(cur, 0, nil)
+		case 1:
+			if a.openForCompletion() != nil {
+				return nil
+			}
+			task, err := a.resolveTask(args[0])
+			if err != nil {
+				return nil
+			}
+			return a.completeMilestones(cur, task.ProjectID)
+		}
+	case "milestone show", "milestone update", "milestone rm":
+		if len(args) == 0 {
+			return a.completeMilestones(cur, 0)
+		}
+	case "task block", "task unblock":
+		return a.completeTasks(cur, 0, nil)
+	case "task repeat":
+		switch len(args) {
+		case 0:
+			return a.completeTasks(cur, 0, nil)
+		case 1:
+			return filterValues(cur, service.RecurrenceWords)
+		}
+	case "task priority":
+		switch len(args) {
+		case 0:
+			return a.completeTasks(cur, 0, func(t service.TaskRef) bool { return t.CompletedAt == nil })
+		case 1:
+			return filterValues(cur, service.PriorityNames)
+		}
+	case "tag rm", "tag rename":
+		if len(args) == 0 {
+			return a.completeTags(cur)
+		}
+	case "workspace use", "workspace rm":
+		if len(args) == 0 {
+			return a.completeWorkspaces(cur)
+		}
+	}
+	return nil
+}
+
+// positionals drops flags and their values from args.
+func positionals(args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			out = append(out, arg)
+			continue
+		}
+		if !strings.Contains(arg, "=") && !slices.Contains(boolFlags, strings.TrimLeft(arg, "-")) {
+			i++
+		}
+	}
+	return out
+}
+
+func (a *App) completeFlagValue(flag, cur string, args []string) []candidate {
+	switch flag {
+	case "format":
+		return filterValues(cur, outputFormats)
+	case "status":
+		return filterValues(cur, projectStatuses)
+	case "priority":
+		return filterValues(cur, service.PriorityNames)
+	case "repeat":
+		return filterValues(cur, service.RecurrenceWords)
+	case "tab":
+		return filterValues(cur, openTabs)
+	case "workspace":
+		return a.completeWorkspaces(cur)
+	case "tag":
+		return a.completeTags(cur)
+	case "project":
+		return a.completeProjects(cur)
+	case "parent":
+		if len(args) == 0 || a.openForCompletion() != nil {
+			return nil
+		}
+		p, err := a.resolveProject(args[0])
+		if err != nil {
+			return nil
+		}
+		return a.completeTasks(cur, p.ID, nil)
+	case "state":
+		return a.completeStates(cur)
+	case "milestone":
+		if len(args) == 0 || a.openForCompletion() != nil {
+			return nil
+		}
+		p, err := a.resolveProject(args[0])
+		if err != nil {
+			return nil
+		}
+		return a.completeMilestones(cur, p.ID)
+	case "task", "log":
+		if len(args) == 0 || a.openForCompletion() != nil {
+			return nil
+		}
+		p, err := a.resolveProject(args[0])
+		if err != nil {
+			return nil
+		}
+		if flag == "task" {
+			return a.completeTasks(cur, p.ID, nil)
+		}
+		return a.completeLogs(cur, p.ID)
+	}
+	return nil
+}
+
+func filterValues(cur string, values []string) []candidate {
+	var out []candidate
+	for _, v := range values {
+		if strings.HasPrefix(v, cur) {
+			out = append(out, candidate{value: v})
+		}
+	}
+	return out
+}
+
+// openForCompletion opens the database without applying migrations, which
+// keeps completion fast.
+func (a *App) openForCompletion() error {
+	return a.openDB(false)
+}
+
+func (a *App) completeProjects(cur string) []candidate {
+	if a.openForCompletion() != nil {
+		return nil
+	}
+	projects, err := a.svc.ListProjects()
+	if err != nil {
+		return nil
+	}
+
+	needle := strings.ToLower(cur)
+	var out []candidate
+	for _, p := range projects {
+		if strings.HasPrefix(strings.ToLower(p.Name), needle) {
+			out = append(out, candidate{value: p.Name, desc: p.Status})
+		}
+	}
+	return out
+}
+
+// completeTasks offers the IDs of tasks in projectID, or in every project
+// when it is 0, that match keep.
+func (a *App) completeTasks(cur string, projectID int, keep func(service.TaskRef) bool) []candidate {
+	if a.openForCompletion() != nil {
+		return nil
+	}
+	tasks, err := a.svc.ListTaskRefs(projectID)
+	if err != nil {
+		return nil
+	}
+
+	var out []candidate
+	for _, t := range tasks {
+		id := strconv.Itoa(t.ID)
+		if strings.HasPrefix(id, cur) && (keep == nil || keep(t)) {
+			out = append(out, candidate{value: id, desc: fmt.Sprintf("%s (%s)", t.Title, t.ProjectName)})
+		}
+	}
+	return out
+}
+
+// completeMilestones offers the milestones of a project, or of every project
+// when projectID is 0.
+func (a *App) completeMilestones(cur string, projectID int) []candidate {
+	if a.openForCompletion() != nil {
+		return nil
+	}
+	milestones, err := a.svc.ListMilestoneRefs(projectID)
+	if err != nil {
+		return nil
+	}
+
+	var out []candidate
+	for _, m := range milestones {
+		if id := strconv.Itoa(m.ID); strings.HasPrefix(id, cur) {
+			out = append(out, candidate{value: id, desc: fmt.Sprintf("%s (%s)", m.Name, m.ProjectName)})
+		}
+	}
+	return out
+}
+
+func (a *App) completeStates(cur string) []candidate {
+	if a.openForCompletion() != nil {
+		return nil
+	}
+	states, err := a.svc.TaskStates()
+	if err != nil {
+		return nil
+	}
+	return filterValues(cur, states)
+}
+
+func (a *App) completeLogs(cur string, projectID int) []candidate {
+	logs, err := a.svc.ListProjectLogs(projectID)
+	if err != nil {
+		return nil
+	}
+	var out []candidate
+	for _, l := range logs {
+		if id := strconv.Itoa(l.ID); strings.HasPrefix(id, cur) {
+			out = append(out, candidate{value: id, desc: l.Title})
+		}
+	}
+	return out
+}
+
+func (a *App) completeTags(cur string) []candidate {
+	if a.openForCompletion() != nil {
+		return nil
+	}
+	tags, err := a.svc.ListTags()
+	if err != nil {
+		return nil
+	}
+	needle := strings.ToLower(cur)
+	var out []candidate
+	for _, t := range tags {
+		if strings.HasPrefix(strings.ToLower(t.Name), needle) {
+			out = append(out, candidate{value: t.Name, desc: fmt.Sprintf("%d projects, %d tasks", t.Projects, t.Tasks)})
+		}
+	}
+	return out
+}
+
+func (a *App) completeWorkspaces(cur string) []candidate {
+	workspaces, err := a.store.List()
+	if err != nil {
+		return nil
+	}
+	var out []candidate
+	for _, w := range workspaces {
+		if strings.HasPrefix(w.Name, cur) {
+			out = append(out, candidate{value: w.Name})
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompletionScripts(t *testing.T) {
+	app := setupTestApp(t)
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		out := app.run(t, 0, "completion", shell)
+		if !strings.Contains(out, "addae __complete") {
+			t.Errorf("expected %s script to call __complete, got %q", shell, out)
+		}
+	}
+	app.run(t, 2, "completion", "powershell")
+	app.run(t, 2, "completion")
+}
+
+func TestComplete(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website Relaunch")
+	app.run(t, 0, "project", "add", "Mobile")
+	app.run(t, 0, "task", "add", "Website", "Write copy")
+	app.run(t, 0, "task", "add", "Mobile", "Ship beta")
+	app.run(t, 0, "task", "done", "2")
+	app.run(t, 0, "tag", "add", "acme", "--project", "Mobile")
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"pro"}, []string{"project"}},
+		{[]string{"project", ""}, []string{"list", "add", "show", "update", "rm"}},
+		{[]string{"project", "show", "w"}, []string{"Website Relaunch"}},
+		{[]string{"project", "list", "--status", "in"}, []string{"in progress"}},
+		{[]string{"project", "list", "--f"}, []string{"--format"}},
+		{[]string{"task", "ls", "--all", ""}, []string{"Website Relaunch", "Mobile"}},
+		{[]string{"task", "done", ""}, []string{"1"}},
+		{[]string{"task", "undone", ""}, []string{"2"}},
+		{[]string{"task", "rm", ""}, []string{"1", "2"}},
+		{[]string{"log", "M"}, []string{"Mobile"}},
+		{[]string{"log", "ls", ""}, []string{"Website Relaunch", "Mobile"}},
+		{[]string{"open", "Mobile", "--task", ""}, []string{"2"}},
+		{[]string{"open", "Mobile", "--tab", ""}, []string{"details", "tasks", "logs"}},
+		{[]string{"task", "ls", "--format", "j"}, []string{"json"}},
+		{[]string{"completion", ""}, []string{"bash", "zsh", "fish"}},
+		{[]string{"workspace", "use", ""}, []string{"default"}},
+		{[]string{"task", "add", "Mobile", ""}, nil},
+		{[]string{"tag", "rm", "a"}, []string{"acme"}},
+		{[]string{"tag", "add", "acme", "--project", "M"}, []string{"Mobile"}},
+		{[]string{"tag", "add", "acme", "--task", ""}, []string{"1", "2"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range app.complete(tt.words) {
+			got = append(got, c.value)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("complete(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteOutput(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "task", "add", "Website", "Write copy")
+
+	out := app.run(t, 0, "__complete", "task", "done", "")
+	if out != "1\tWrite copy (Website)\n" {
+		t.Errorf("unexpected completion output: %q", out)
+	}
+
+	app.run(t, 0, "help")
+	if strings.Contains(app.stderr.String(), "__complete") {
+		t.Errorf("expected __complete to be hidden from usage")
+	}
+}
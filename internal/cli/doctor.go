@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quamejnr/addae/internal/db"
+)
+
+const doctorUsage = `Usage: addae doctor [--fix [--backup path]] [--format f]
+
+Checks the database for corruption, broken references, unenforced foreign keys,
+pending migrations and tasks whose completion was lost by the completed_at
+migration. Exits with 1 when a check fails.
+
+--fix backs the database up, then applies pending migrations, moves tasks
+and logs that belong to no project into a project named Recovered, and clears
+parent tasks, milestones and parent projects that no longer exist. The backup
+goes next to the database unless --backup is given. Anything else it reports
+needs manual action, including tasks the completed_at migration may have
+reopened: nothing records which of them were completed.`
+
+func (a *App) runDoctor(args []string) error {
+	fs := a.newFlagSet("doctor")
+	fs.Usage = func() { fmt.Fprintln(a.stderr, doctorUsage) }
+	fix := fs.Bool("fix", false, "repair what can be repaired safely after taking a backup")
+	backup := fs.String("backup", "", "where --fix writes the backup")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+	if *backup != "" && !*fix {
+		return usagef("--backup requires --fix")
+	}
+	if a.db == nil {
+		return errors.New("no database open")
+	}
+
+	ctx := context.Background()
+	checks, err := db.Diagnose(ctx, a.db, a.opts.Migrations)
+	if err != nil {
+		return err
+	}
+
+	if *fix && needsRepair(checks) {
+		path := *backup
+		if path == "" {
+			path = fmt.Sprintf("%s.%s.bak", a.dbPath, time.Now().Format("20060102-150405"))
+		}
+		if err := db.Backup(ctx, a.db, path); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stderr, "Backed up database to %s\n", path)
+
+		done, err := db.Repair(ctx, a.db, a.opts.Migrations)
+		for _, d := range done {
+			fmt.Fprintf(a.stderr, "Fixed: %s\n", d)
+		}
+		if err != nil {
+			return fmt.Errorf("repair failed, the backup is at %s: %w", path, err)
+		}
+
+		if checks, err = db.Diagnose(ctx, a.db, a.opts.Migrations); err != nil {
+			return err
+		}
+	}
+	if *fix {
+		for _, c := range checks {
+			if c.Severity != db.SeverityOK {
+				fmt.Fprintf(a.stderr, "Needs manual action: %s: %s\n", c.Name, c.Message)
+			}
+		}
+	}
+
+	if err := a.printChecks(*format, checks); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, c := range checks {
+		if c.Severity == db.SeverityFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+	return nil
+}
+
+func needsRepair(checks []db.Check) bool {
+	for _, c := range checks {
+		if c.Fixable {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) printChecks(format string, checks []db.Check) error {
+	if format == formatJSON {
+		return a.writeJSON(checks)
+	}
+
+	t := table{header: []string{"severity", "check", "message"}}
+	for _, c := range checks {
+		severity := string(c.Severity)
+		if format == formatTable {
+			severity = strings.ToUpper(severity)
+		}
+		msg := c.Message
+		if c.Fixable && format == formatTable {
+			msg += " (fix with --fix)"
+		}
+		t.rows = append(t.rows, []string{severity, c.Name, msg})
+	}
+	return a.writeTable(format, t)
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// execRaw runs statements against path on a connection without foreign keys,
+// the way databases ended up before InitDB enforced them.
+func execRaw(t *testing.T, path string, stmts ...string) {
+	t.Helper()
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer raw.Close()
+	for _, stmt := range stmts {
+		if _, err := raw.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+}
+
+func TestDoctorHealthy(t *testing.T) {
+	app := setupWorkspaceApp(t, Options{DBPath: filepath.Join(t.TempDir(), "addae.db")})
+	app.run(t, 0, "project", "add", "Website")
+
+	out := app.run(t, 0, "doctor")
+	for _, check := range []string{"integrity", "foreign-keys", "foreign-key-check", "orphans", "completed-at", "migrations"} {
+		if !strings.Contains(out, check) {
+			t.Errorf("expected %s check in output: %q", check, out)
+		}
+	}
+	if strings.Contains(out, "FAIL") || strings.Contains(out, "WARN") {
+		t.Errorf("expected a healthy database, got %q", out)
+	}
+}
+
+func TestDoctorFixesOrphans(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "addae.db")
+	app := setupWorkspaceApp(t, Options{DBPath: path})
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "task", "add", "Website", "Keep me")
+
+	execRaw(t, path,
+		`INSERT INTO tasks (project_id, title) VALUES (999, 'Lost task')`,
+		`INSERT INTO logs (project_id, title) VALUES (NULL, 'Lost log')`)
+
+	app.run(t, 1, "doctor")
+	if !strings.Contains(app.stdout.String(), "1 tasks and 1 logs belong to no project") {
+		t.Errorf("expected orphans to be reported, got %q", app.stdout.String())
+	}
+
+	backup := filepath.Join(dir, "before-fix.db")
+	app.run(t, 0, "doctor", "--fix", "--backup", backup)
+	if !strings.Contains(app.stderr.String(), "moved 2 orphaned tasks and logs") {
+		t.Errorf("expected orphans to be recovered, got %q", app.stderr.String())
+	}
+	if _, err := os.Stat(backup); err != nil {
+		t.Errorf("expected backup to be written: %v", err)
+	}
+
+	out := app.run(t, 0, "task", "ls", "Recovered")
+	if !strings.Contains(out, "Lost task") {
+		t.Errorf("expected orphaned task in Recovered, got %q", out)
+	}
+	out = app.run(t, 0, "log", "ls", "Recovered")
+	if !strings.Contains(out, "Lost log") {
+		t.Errorf("expected orphaned log in Recovered, got %q", out)
+	}
+
+	app.run(t, 2, "doctor", "--backup", backup)
+}
+
+func TestDoctorCompletedAtMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addae.db")
+	app := setupWorkspaceApp(t, Options{DBPath: path})
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "migrate", "down-to", "20250204221253")
+
+	execRaw(t, path,
+		`INSERT INTO tasks (project_id, title, status) VALUES (1, 'Shipped', 'completed')`,
+		`INSERT INTO tasks (project_id, title, status) VALUES (1, 'Still open', 'todo')`)
+
+	out := app.run(t, 0, "doctor")
+	if !strings.Contains(out, "migrations pending") {
+		t.Errorf("expected pending migration to be reported, got %q", out)
+	}
+
+	// The shipped migration reopens completed tasks, and nothing records
+	// which they were.
+	app.run(t, 0, "migrate", "up")
+	out = app.run(t, 0, "task", "ls", "Website")
+	if !strings.Contains(out, "Shipped") {
+		t.Errorf("expected the migration to leave Shipped open, got %q", out)
+	}
+
+	out = app.run(t, 0, "doctor")
+	if !strings.Contains(out, "INFO") || !strings.Contains(out, "2 tasks created before") {
+		t.Errorf("expected open tasks predating the migration to be reported, got %q", out)
+	}
+
+	// --fix cannot repair them, and says so.
+	app.run(t, 0, "doctor", "--fix")
+	if !strings.Contains(app.stderr.String(), "Needs manual action: completed-at") {
+		t.Errorf("expected the completed-at check left for manual action, got %q", app.stderr.String())
+	}
+}
+
+func TestDoctorUnfixableForeignKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addae.db")
+	app := setupWorkspaceApp(t, Options{DBPath: path})
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "task", "add", "Website", "Design")
+
+	execRaw(t, path, `INSERT INTO task_dependencies (task_id, blocked_by_task_id) VALUES (1, 999)`)
+
+	out := app.run(t, 1, "doctor")
+	if !strings.Contains(out, "1 in task_dependencies") || strings.Contains(out, "fix with --fix") {
+		t.Errorf("expected a violation --fix cannot repair, got %q", out)
+	}
+}
+
+func TestDoctorClearsDanglingReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addae.db")
+	app := setupWorkspaceApp(t, Options{DBPath: path})
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "task", "add", "Website", "Design")
+	app.run(t, 0, "task", "add", "Website", "Build")
+
+	execRaw(t, path,
+		`UPDATE tasks SET parent_task_id = 999 WHERE id = 1`,
+		`UPDATE tasks SET milestone_id = 999`,
+		`UPDATE projects SET parent_id = 999`)
+
+	out := app.run(t, 1, "doctor")
+	if !strings.Contains(out, "1 in tasks.parent_task_id, 2 in tasks.milestone_id, 1 in projects.parent_id") {
+		t.Errorf("expected dangling references to be reported, got %q", out)
+	}
+
+	app.run(t, 0, "doctor", "--fix")
+	if !strings.Contains(app.stderr.String(), "cleared 4 references") {
+		t.Errorf("expected the references to be cleared, got %q", app.stderr.String())
+	}
+	out = app.run(t, 0, "doctor")
+	if !strings.Contains(out, "every parent task, milestone and parent project exists") {
+		t.Errorf("expected no dangling references after --fix, got %q", out)
+	}
+}
@@ -0,0 +1,335 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+// Output formats accepted by --format.
+const (
+	formatTable    = "table"
+	formatJSON     = "json"
+	formatCSV      = "csv"
+	formatMarkdown = "markdown"
+)
+
+var outputFormats = []string{formatTable, formatJSON, formatCSV, formatMarkdown}
+
+// formatFlag registers the --format flag on fs.
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", formatTable, "output format: table, json, csv or markdown")
+}
+
+func validateFormat(format string) error {
+	for _, f := range outputFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return usagef("invalid format %q, expected one of: %s", format, strings.Join(outputFormats, ", "))
+}
+
+// table is a format-independent set of rows for table, csv and markdown output.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (a *App) writeJSON(v any) error {
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (a *App) writeTable(format string, t table) error {
+	switch format {
+	case formatCSV:
+		w := csv.NewWriter(a.stdout)
+		if err := w.Write(t.header); err != nil {
+			return err
+		}
+		if err := w.WriteAll(t.rows); err != nil {
+			return err
+		}
+		return w.Error()
+	case formatMarkdown:
+		fmt.Fprintf(a.stdout, "| %s |\n", strings.Join(escapeMarkdown(t.header), " | "))
+		fmt.Fprintf(a.stdout, "|%s\n", strings.Repeat(" --- |", len(t.header)))
+		for _, row := range t.rows {
+			fmt.Fprintf(a.stdout, "| %s |\n", strings.Join(escapeMarkdown(row), " | "))
+		}
+		return nil
+	default:
+		w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(t.header, "\t")))
+		for _, row := range t.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+// escapeMarkdown makes cells safe to place inside a markdown table row.
+func escapeMarkdown(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		c = strings.ReplaceAll(c, "|", `\|`)
+		c = strings.ReplaceAll(c, "\r\n", "<br>")
+		escaped[i] = strings.ReplaceAll(c, "\n", "<br>")
+	}
+	return escaped
+}
+
+// formatTime renders t for humans in table output and as RFC 3339 elsewhere.
+func formatTime(format string, t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	if format == formatTable {
+		return t.Local().Format("2006-01-02 15:04")
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// formatDue prints a due date as a plain date.
+func formatDue(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Local().Format(service.DueDateLayout)
+}
+
+// formatID prints an optional reference to another record.
+func formatID(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.Itoa(*id)
+}
+
+// formatIDs prints references to other records as a comma separated list.
+func formatIDs(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, ",")
+}
+
+// formatPriority leaves the priority column blank for tasks without one, so
+// the ones that matter stand out.
+func formatPriority(p service.Priority) string {
+	if p == service.PriorityNone {
+		return ""
+	}
+	return p.String()
+}
+
+// printProjects writes projects in the given format. The table format keeps
+// to a few columns that fit a terminal; the others carry every field.
+func (a *App) printProjects(format string, projects []service.Project) error {
+	if format == formatJSON {
+		if projects == nil {
+			projects = []service.Project{}
+		}
+		return a.writeJSON(projects)
+	}
+
+	var t table
+	if format == formatTable {
+		t.header = []string{"id", "name", "status", "progress", "tags", "summary"}
+		for _, row := range projectTree(projects) {
+			p := row.project
+			t.rows = append(t.rows, []string{
+				strconv.Itoa(p.ID), strings.Repeat("  ", row.depth) + p.Name, p.Status,
+				fmt.Sprintf("%d/%d", p.Done, p.Tasks), strings.Join(p.Tags, ","), firstLine(p.Summary),
+			})
+		}
+		return a.writeTable(format, t)
+	}
+
+	t.header = []string{
+		"id", "name", "summary", "desc", "status", "created_at", "updated_at", "tags",
+		"parent_id", "tasks", "done",
+	}
+	for _, p := range projects {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(p.ID), p.Name, p.Summary, p.Desc, p.Status,
+			formatTime(format, &p.DateCreated), formatTime(format, &p.DateUpdated),
+			strings.Join(p.Tags, ","),
+			formatID(p.ParentID), strconv.Itoa(p.Tasks), strconv.Itoa(p.Done),
+		})
+	}
+	return a.writeTable(format, t)
+}
+
+// projectRow is a project placed in the project tree.
+type projectRow struct {
+	project service.Project
+	depth   int
+}
+
+// projectTree lists each project followed by its subprojects. Projects whose
+// parent is not in projects are listed at the top level.
+func projectTree(projects []service.Project) []projectRow {
+	listed := make(map[int]bool, len(projects))
+	for _, p := range projects {
+		listed[p.ID] = true
+	}
+	children := make(map[int][]service.Project)
+	var roots []service.Project
+	for _, p := range projects {
+		if p.ParentID != nil && listed[*p.ParentID] {
+			children[*p.ParentID] = append(children[*p.ParentID], p)
+		} else {
+			roots = append(roots, p)
+		}
+	}
+
+	var rows []projectRow
+	var walk func(projects []service.Project, depth int)
+	walk = func(projects []service.Project, depth int) {
+		for _, p := range projects {
+			rows = append(rows, projectRow{p, depth})
+			walk(children[p.ID], depth+1)
+		}
+	}
+	walk(roots, 0)
+	return rows
+}
+
+// printProject writes a single project, as a detail view in table format.
+func (a *App) printProject(format string, p service.Project) error {
+	switch format {
+	case formatJSON:
+		return a.writeJSON(p)
+	case formatTable:
+		var parent *service.Project
+		if p.ParentID != nil {
+			var err error
+			if parent, err = a.svc.GetProject(*p.ParentID); err != nil {
+				return err
+			}
+		}
+		w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "ID:\t%d\n", p.ID)
+		fmt.Fprintf(w, "Name:\t%s\n", p.Name)
+		fmt.Fprintf(w, "Status:\t%s\n", p.Status)
+		if parent != nil {
+			fmt.Fprintf(w, "Parent:\t%s (%d)\n", parent.Name, parent.ID)
+		}
+		fmt.Fprintf(w, "Progress:\t%d/%d tasks done\n", p.Done, p.Tasks)
+		fmt.Fprintf(w, "Summary:\t%s\n", p.Summary)
+		if len(p.Tags) > 0 {
+			fmt.Fprintf(w, "Tags:\t%s\n", strings.Join(p.Tags, ", "))
+		}
+		fmt.Fprintf(w, "Created:\t%s\n", formatTime(format, &p.DateCreated))
+		fmt.Fprintf(w, "Updated:\t%s\n", formatTime(format, &p.DateUpdated))
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if p.Desc != "" {
+			fmt.Fprintf(a.stdout, "\n%s\n", p.Desc)
+		}
+		return nil
+	default:
+		return a.printProjects(format, []service.Project{p})
+	}
+}
+
+// printTasks writes tasks in the given format.
+func (a *App) printTasks(format string, tasks []service.Task) error {
+	if format == formatJSON {
+		if tasks == nil {
+			tasks = []service.Task{}
+		}
+		return a.writeJSON(tasks)
+	}
+
+	var t table
+	if format == formatTable {
+		t.header = []string{"id", "done", "state", "priority", "title", "blocked by", "tags", "due", "repeat", "completed"}
+		for _, task := range tasks {
+			mark := "[ ]"
+			if task.CompletedAt != nil {
+				mark = "[x]"
+			}
+			t.rows = append(t.rows, []string{
+				strconv.Itoa(task.ID), mark, task.State, formatPriority(task.Priority), task.Title, formatIDs(task.BlockedBy),
+				strings.Join(task.Tags, ","),
+				formatDue(task.DueAt), task.Recurrence.String(), formatTime(format, task.CompletedAt),
+			})
+		}
+		return a.writeTable(format, t)
+	}
+
+	t.header = []string{
+		"id", "project_id", "title", "desc", "completed_at", "created_at", "updated_at", "tags", "due_at", "priority",
+		"parent_task_id", "blocked_by", "recurrence", "milestone_id", "position", "state",
+	}
+	for _, task := range tasks {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(task.ID), strconv.Itoa(task.ProjectID), task.Title, task.Desc,
+			formatTime(format, task.CompletedAt),
+			formatTime(format, &task.DateCreated), formatTime(format, &task.DateUpdated),
+			strings.Join(task.Tags, ","), formatTime(format, task.DueAt), task.Priority.String(),
+			formatID(task.ParentTaskID), formatIDs(task.BlockedBy), task.Recurrence.String(),
+			formatID(task.MilestoneID), strconv.Itoa(task.Position), task.State,
+		})
+	}
+	return a.writeTable(format, t)
+}
+
+// printTask writes a single task.
+func (a *App) printTask(format string, task service.Task) error {
+	if format == formatJSON {
+		return a.writeJSON(task)
+	}
+	return a.printTasks(format, []service.Task{task})
+}
+
+// printLogs writes logs in the given format.
+func (a *App) printLogs(format string, logs []service.Log) error {
+	if format == formatJSON {
+		if logs == nil {
+			logs = []service.Log{}
+		}
+		return a.writeJSON(logs)
+	}
+
+	var t table
+	if format == formatTable {
+		t.header = []string{"id", "title", "tasks", "created"}
+		for _, l := range logs {
+			t.rows = append(t.rows, []string{
+				strconv.Itoa(l.ID), l.Title, formatIDs(l.TaskIDs), formatTime(format, &l.DateCreated),
+			})
+		}
+		return a.writeTable(format, t)
+	}
+
+	t.header = []string{"id", "project_id", "title", "desc", "task_ids", "created_at", "updated_at"}
+	for _, l := range logs {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(l.ID), strconv.Itoa(l.ProjectID), l.Title, l.Desc, formatIDs(l.TaskIDs),
+			formatTime(format, &l.DateCreated), formatTime(format, &l.DateUpdated),
+		})
+	}
+	return a.writeTable(format, t)
+}
+
+// printLog writes a single log.
+func (a *App) printLog(format string, l service.Log) error {
+	if format == formatJSON {
+		return a.writeJSON(l)
+	}
+	return a.printLogs(format, []service.Log{l})
+}
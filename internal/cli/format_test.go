@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProjectListJSON(t *testing.T) {
+	app := setupTestApp(t)
+
+	out := app.run(t, 0, "project", "list", "--format", "json")
+	if strings.TrimSpace(out) != "[]" {
+		t.Errorf("expected empty JSON array, got %q", out)
+	}
+
+	app.run(t, 0, "project", "add", "Website", "--summary", "Company site")
+	out = app.run(t, 0, "project", "list", "--format", "json")
+
+	var projects []map[string]any
+	if err := json.Unmarshal([]byte(out), &projects); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if len(projects) != 1 {
+		t.Fatalf("expected 1 project, got %d", len(projects))
+	}
+	for _, field := range []string{"id", "name", "summary", "desc", "status", "created_at", "updated_at"} {
+		if _, ok := projects[0][field]; !ok {
+			t.Errorf("expected field %q in %v", field, projects[0])
+		}
+	}
+	if _, err := time.Parse(time.RFC3339, projects[0]["created_at"].(string)); err != nil {
+		t.Errorf("expected RFC3339 created_at: %v", err)
+	}
+}
+
+func TestTaskJSONCompletedAt(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+
+	out := app.run(t, 0, "task", "add", "Website", "Write copy", "--format", "json")
+	var task map[string]any
+	if err := json.Unmarshal([]byte(out), &task); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if v, ok := task["completed_at"]; !ok || v != nil {
+		t.Errorf("expected completed_at to be null for an open task, got %v", v)
+	}
+	if task["title"] != "Write copy" || task["project_id"] != float64(1) {
+		t.Errorf("unexpected task: %v", task)
+	}
+
+	out = app.run(t, 0, "task", "done", "1", "--format", "json")
+	if err := json.Unmarshal([]byte(out), &task); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	completedAt, ok := task["completed_at"].(string)
+	if !ok {
+		t.Fatalf("expected completed_at to be set, got %v", task["completed_at"])
+	}
+	if _, err := time.Parse(time.RFC3339, completedAt); err != nil {
+		t.Errorf("expected RFC3339 completed_at: %v", err)
+	}
+}
+
+func TestTaskListCSV(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "task", "add", "Website", "Write, then edit", "--desc", "two\nlines")
+
+	out := app.run(t, 0, "task", "ls", "Website", "--format", "csv")
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV %q: %v", out, err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header and 1 row, got %d records", len(records))
+	}
+	if records[0][0] != "id" || records[0][4] != "completed_at" {
+		t.Errorf("unexpected header: %v", records[0])
+	}
+	if records[1][2] != "Write, then edit" || records[1][3] != "two\nlines" {
+		t.Errorf("unexpected row: %v", records[1])
+	}
+}
+
+func TestLogListMarkdown(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.stdin = strings.NewReader("a | b\nsecond line")
+	app.run(t, 0, "log", "Website", "-t", "Pipes")
+
+	out := app.run(t, 0, "log", "ls", "Website", "--format", "markdown")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header, separator and 1 row, got %q", out)
+	}
+	if !strings.HasPrefix(lines[0], "| id | project_id | title |") {
+		t.Errorf("unexpected header: %q", lines[0])
+	}
+	if !strings.Contains(lines[2], `a \| b<br>second line`) {
+		t.Errorf("expected escaped cell, got %q", lines[2])
+	}
+}
+
+func TestInvalidFormat(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 2, "project", "list", "--format", "xml")
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+const linkUsage = `Usage: addae link <command> [arguments]
+
+Commands:
+  ls     (--project p | --task id)           List the links on a project and its tasks, or on a task
+  add    <target> (--project p | --task id) [--label l]
+                                             Link a project or a task to a URL or a file
+  rm     <id>                                Delete a link
+
+ls and add accept --format table|json|csv|markdown. <target> is a URL with a
+scheme, like https://, or a file path, which is stored as an absolute path.
+The label defaults to the target.
+
+<p> is a project ID or a unique prefix of its name.`
+
+func (a *App) runLink(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, linkUsage)
+		return usagef("missing link command")
+	}
+
+	switch args[0] {
+	case "ls", "list":
+		return a.linkList(args[1:])
+	case "add":
+		return a.linkAdd(args[1:])
+	case "rm", "remove":
+		return a.linkRemove(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stdout, linkUsage)
+		return nil
+	default:
+		fmt.Fprintln(a.stderr, linkUsage)
+		return usagef("unknown link command %q", args[0])
+	}
+}
+
+func (a *App) linkList(args []string) error {
+	fs := a.newFlagSet("link ls")
+	projectRef := fs.String("project", "", "project whose links to list")
+	taskRef := fs.String("task", "", "ID of the task whose links to list")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+	if (*projectRef == "") == (*taskRef == "") {
+		return usagef("expected one of --project or --task")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	var links []service.Link
+	if *projectRef != "" {
+		p, err := a.resolveProject(*projectRef)
+		if err != nil {
+			return err
+		}
+		links, err = a.svc.ListProjectLinks(p.ID)
+		if err != nil {
+			return err
+		}
+	} else {
+		task, err := a.resolveTask(*taskRef)
+		if err != nil {
+			return err
+		}
+		links, err = a.svc.ListTaskLinks(task.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return a.printLinks(*format, links)
+}
+
+func (a *App) linkAdd(args []string) error {
+	fs := a.newFlagSet("link add")
+	projectRef := fs.String("project", "", "project to link")
+	taskRef := fs.String("task", "", "ID of the task to link")
+	label := fs.String("label", "", "label shown instead of the target")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one URL or file path")
+	}
+	if (*projectRef == "") == (*taskRef == "") {
+		return usagef("expected one of --project or --task")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+	if strings.TrimSpace(rest[0]) == "" {
+		return usagef("a URL or file path is required")
+	}
+
+	l := service.Link{Label: *label, Target: rest[0]}
+	var owner string
+	if *projectRef != "" {
+		p, err := a.resolveProject(*projectRef)
+		if err != nil {
+			return err
+		}
+		l.ProjectID = &p.ID
+		owner = fmt.Sprintf("project %d (%s)", p.ID, p.Name)
+	} else {
+		task, err := a.resolveTask(*taskRef)
+		if err != nil {
+			return err
+		}
+		l.TaskID = &task.ID
+		owner = fmt.Sprintf("task %d (%s)", task.ID, task.Title)
+	}
+
+	if err := a.svc.AddLink(&l); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		return a.printLinks(*format, []service.Link{l})
+	}
+	fmt.Fprintf(a.stdout, "Added link %d to %s: %s\n", l.ID, owner, l.Label)
+	return nil
+}
+
+func (a *App) linkRemove(args []string) error {
+	fs := a.newFlagSet("link rm")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one link ID")
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(rest[0]))
+	if err != nil {
+		return usagef("invalid link ID %q", rest[0])
+	}
+
+	if err := a.svc.DeleteLink(id); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Deleted link %d\n", id)
+	return nil
+}
+
+// formatLinkOwner names what a link is on, as in "task 3".
+func formatLinkOwner(l service.Link) string {
+	if l.TaskID != nil {
+		return "task " + strconv.Itoa(*l.TaskID)
+	}
+	return "project"
+}
+
+// printLinks writes links in the given format.
+func (a *App) printLinks(format string, links []service.Link) error {
+	if format == formatJSON {
+		if links == nil {
+			links = []service.Link{}
+		}
+		return a.writeJSON(links)
+	}
+
+	var t table
+	if format == formatTable {
+		t.header = []string{"id", "on", "label", "target"}
+		for _, l := range links {
+			target := l.Target
+			if target == l.Label {
+				target = ""
+			}
+			t.rows = append(t.rows, []string{strconv.Itoa(l.ID), formatLinkOwner(l), l.Label, target})
+		}
+		return a.writeTable(format, t)
+	}
+
+	t.header = []string{"id", "project_id", "task_id", "label", "target", "created_at"}
+	for _, l := range links {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(l.ID), formatID(l.ProjectID), formatID(l.TaskID), l.Label, l.Target,
+			formatTime(format, &l.DateCreated),
+		})
+	}
+	return a.writeTable(format, t)
+}
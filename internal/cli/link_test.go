@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+func TestLinkCommands(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Addae")
+	app.run(t, 0, "task", "add", "Addae", "Schema")
+
+	out := app.run(t, 0, "link", "ls", "--project", "Addae", "--format", "json")
+	if strings.TrimSpace(out) != "[]" {
+		t.Errorf("expected an empty JSON list, got %q", out)
+	}
+
+	out = app.run(t, 0, "link", "add", "https://example.com/design", "--project", "Addae", "--label", "Design doc")
+	if !strings.Contains(out, "Added link 1 to project 1 (Addae): Design doc") {
+		t.Errorf("unexpected add output: %q", out)
+	}
+	out = app.run(t, 0, "link", "add", "/tmp/schema.sql", "--task", "1", "--format", "json")
+	var added []service.Link
+	if err := json.Unmarshal([]byte(out), &added); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(added) != 1 || added[0].TaskID == nil || added[0].Label != "/tmp/schema.sql" || added[0].DateCreated.IsZero() {
+		t.Errorf("unexpected link: %+v", added)
+	}
+	app.run(t, 2, "link", "add", "https://example.com")
+	app.run(t, 2, "link", "add", "https://example.com", "--project", "Addae", "--task", "1")
+	app.run(t, 2, "link", "add", " ", "--project", "Addae")
+	app.run(t, 1, "link", "add", "https://example.com", "--task", "99")
+
+	out = app.run(t, 0, "link", "ls", "--project", "Addae")
+	for _, want := range []string{"Design doc", "https://example.com/design", "task 1", "/tmp/schema.sql"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the link list, got %q", want, out)
+		}
+	}
+	out = app.run(t, 0, "link", "ls", "--task", "1", "--format", "csv")
+	if !strings.Contains(out, "id,project_id,task_id,label,target,created_at") || strings.Contains(out, "Design doc") {
+		t.Errorf("expected only the task's link as CSV, got %q", out)
+	}
+
+	out = app.run(t, 0, "link", "rm", "1")
+	if !strings.Contains(out, "Deleted link 1") {
+		t.Errorf("unexpected rm output: %q", out)
+	}
+	app.run(t, 1, "link", "rm", "1")
+	app.run(t, 2, "link", "rm", "one")
+}
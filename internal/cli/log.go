@@ -0,0 +1,303 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+const logUsage = `Usage: addae log <project> [-t title] [--task ids]
+       addae log ls (<project> | --task id)
+       addae log link <log-id> <task-id>...
+       addae log unlink <log-id> <task-id>...
+
+Writes a development log for a project. When stdin is piped the log body is
+read from it, otherwise $EDITOR is opened on a temporary markdown file.
+Without a title, the first line of the body is used. "log ls" lists the
+logs of a project, or the logs that mention a task.
+
+--task links the new log to the tasks it is about, as comma separated IDs of
+tasks in the project. "log link" and "log unlink" change the tasks of an
+existing log.
+
+Every form accepts --format table|json|csv|markdown.
+
+<project> is a project ID or a unique prefix of its name.`
+
+// defaultEditor is used when $EDITOR is not set.
+const defaultEditor = "vi"
+
+func (a *App) runLog(args []string) error {
+	if len(args) > 1 {
+		switch args[0] {
+		case "ls", "list":
+			return a.logList(args[1:])
+		case "link":
+			return a.logSetTasks(args[1:], true)
+		case "unlink":
+			return a.logSetTasks(args[1:], false)
+		}
+	}
+
+	fs := a.newFlagSet("log")
+	fs.Usage = func() { fmt.Fprintln(a.stderr, logUsage) }
+	var title string
+	fs.StringVar(&title, "t", "", "log title")
+	fs.StringVar(&title, "title", "", "log title")
+	taskRefs := fs.String("task", "", "comma separated IDs of the tasks the log is about")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		fmt.Fprintln(a.stderr, logUsage)
+		return usagef("expected exactly one project")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) > 100 {
+		return usagef("log title must be at most 100 characters")
+	}
+
+	p, err := a.resolveProject(rest[0])
+	if err != nil {
+		return err
+	}
+	var taskIDs []int
+	for _, ref := range strings.Split(*taskRefs, ",") {
+		if strings.TrimSpace(ref) == "" {
+			continue
+		}
+		task, err := a.resolveTask(ref)
+		if err != nil {
+			return err
+		}
+		if task.ProjectID != p.ID {
+			return fmt.Errorf("task %d is not in %s", task.ID, p.Name)
+		}
+		taskIDs = append(taskIDs, task.ID)
+	}
+
+	var body string
+	if stdinIsPiped(a.stdin) {
+		data, err := io.ReadAll(a.stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		body = string(data)
+	} else {
+		body, err = a.editLog()
+		if err != nil {
+			return err
+		}
+	}
+
+	body = strings.TrimRight(body, " \t\r\n")
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("log is empty, nothing saved")
+	}
+
+	if title == "" {
+		title = titleFromBody(body)
+	}
+
+	id, err := a.svc.CreateLog(p.ID, title, body)
+	if err != nil {
+		return err
+	}
+	if len(taskIDs) > 0 {
+		if err := a.svc.SetLogTasks(id, taskIDs); err != nil {
+			return err
+		}
+	}
+	if *format != formatTable {
+		log, err := a.svc.GetLog(id)
+		if err != nil {
+			return err
+		}
+		return a.printLog(*format, *log)
+	}
+	fmt.Fprintf(a.stdout, "Added log %d to %s: %s\n", id, p.Name, title)
+	return nil
+}
+
+func (a *App) logList(args []string) error {
+	fs := a.newFlagSet("log ls")
+	taskRef := fs.String("task", "", "ID of a task whose logs to list")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 1 || (len(rest) == 1) == (*taskRef != "") {
+		return usagef("expected exactly one project, or --task")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	var logs []service.Log
+	if *taskRef != "" {
+		task, err := a.resolveTask(*taskRef)
+		if err != nil {
+			return err
+		}
+		logs, err = a.svc.ListTaskLogs(task.ID)
+		if err != nil {
+			return err
+		}
+	} else {
+		p, err := a.resolveProject(rest[0])
+		if err != nil {
+			return err
+		}
+		logs, err = a.svc.ListProjectLogs(p.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return a.printLogs(*format, logs)
+}
+
+func (a *App) logSetTasks(args []string, link bool) error {
+	command := "log unlink"
+	if link {
+		command = "log link"
+	}
+	fs := a.newFlagSet(command)
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 2 {
+		return usagef("expected a log ID and the IDs of the tasks it is about")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	l, err := a.resolveLog(rest[0])
+	if err != nil {
+		return err
+	}
+	var tasks []*service.Task
+	for _, ref := range rest[1:] {
+		task, err := a.resolveTask(ref)
+		if err != nil {
+			return err
+		}
+		tasks = append(tasks, task)
+	}
+
+	ids := slices.Clone(l.TaskIDs)
+	for _, task := range tasks {
+		switch {
+		case link && !slices.Contains(ids, task.ID):
+			ids = append(ids, task.ID)
+		case !link && !slices.Contains(ids, task.ID):
+			return fmt.Errorf("log %d is not linked to task %d", l.ID, task.ID)
+		case !link:
+			ids = slices.DeleteFunc(ids, func(id int) bool { return id == task.ID })
+		}
+	}
+	if err := a.svc.SetLogTasks(l.ID, ids); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetLog(l.ID)
+		if err != nil {
+			return err
+		}
+		return a.printLog(*format, *updated)
+	}
+
+	for _, task := range tasks {
+		if link {
+			fmt.Fprintf(a.stdout, "Log %d (%s) is linked to task %d: %s\n", l.ID, l.Title, task.ID, task.Title)
+		} else {
+			fmt.Fprintf(a.stdout, "Log %d (%s) is no longer linked to task %d: %s\n", l.ID, l.Title, task.ID, task.Title)
+		}
+	}
+	return nil
+}
+
+// editLog opens $EDITOR on a temporary markdown file and returns what was saved.
+func (a *App) editLog() (string, error) {
+	f, err := os.CreateTemp("", "addae-log-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{defaultEditor}
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read log file: %w", err)
+	}
+	return string(data), nil
+}
+
+// stdinIsPiped reports whether r carries piped or redirected input rather than
+// an interactive terminal. Readers that are not files are always treated as piped.
+func stdinIsPiped(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return true
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+// titleFromBody derives a log title from the first non-empty line of body,
+// dropping any markdown heading markers. It falls back to the current date.
+func titleFromBody(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "# "))
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > 100 {
+			line = string(runes[:97]) + "..."
+		}
+		return line
+	}
+	return "Log " + time.Now().Format("2006-01-02 15:04")
+}
+
+// resolveLog looks up a log by its numeric ID.
+func (a *App) resolveLog(ref string) (*service.Log, error) {
+	id, err := strconv.Atoi(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, usagef("invalid log ID %q", ref)
+	}
+	return a.svc.GetLog(id)
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogFromStdin(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+
+	app.stdin = strings.NewReader("ok  \tgithub.com/x\t0.1s\nFAIL github.com/y\n")
+	out := app.run(t, 0, "log", "web", "-t", "test run")
+	if !strings.Contains(out, "Added log 1 to Website: test run") {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	logs, err := app.svc.ListProjectLogs(1)
+	if err != nil {
+		t.Fatalf("ListProjectLogs failed: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log, got %d", len(logs))
+	}
+	if logs[0].Title != "test run" {
+		t.Errorf("expected title 'test run', got %q", logs[0].Title)
+	}
+	if logs[0].Desc != "ok  \tgithub.com/x\t0.1s\nFAIL github.com/y" {
+		t.Errorf("unexpected body: %q", logs[0].Desc)
+	}
+}
+
+func TestLogTitleFromBody(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+
+	app.stdin = strings.NewReader("\n## Deployed v2\n\nAll good.\n")
+	app.run(t, 0, "log", "Website")
+
+	logs, err := app.svc.ListProjectLogs(1)
+	if err != nil {
+		t.Fatalf("ListProjectLogs failed: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Title != "Deployed v2" {
+		t.Errorf("expected title derived from body, got %+v", logs)
+	}
+}
+
+func TestLogEmptyBody(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+
+	app.stdin = strings.NewReader("  \n\n")
+	app.run(t, 1, "log", "Website", "-t", "nothing")
+	app.run(t, 2, "log")
+}
+
+func TestLogFromEditor(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+
+	// A fake editor that writes a fixed body into the file it is given.
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	script := "#!/bin/sh\nprintf '# From the editor\\n\\nDetails.\\n' > \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake editor: %v", err)
+	}
+	t.Setenv("EDITOR", editor)
+
+	tty, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", os.DevNull, err)
+	}
+	defer tty.Close()
+	app.stdin = tty
+
+	app.run(t, 0, "log", "Website")
+
+	logs, err := app.svc.ListProjectLogs(1)
+	if err != nil {
+		t.Fatalf("ListProjectLogs failed: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Title != "From the editor" {
+		t.Fatalf("expected log from editor, got %+v", logs)
+	}
+	if logs[0].Desc != "# From the editor\n\nDetails." {
+		t.Errorf("unexpected body: %q", logs[0].Desc)
+	}
+}
+
+func TestLogTasks(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "project", "add", "Mobile")
+	app.run(t, 0, "task", "add", "Website", "Design")
+	app.run(t, 0, "task", "add", "Website", "Build")
+	app.run(t, 0, "task", "add", "Mobile", "Ship beta")
+
+	app.stdin = strings.NewReader("Worked on the design.\n")
+	app.run(t, 0, "log", "Website", "-t", "Monday", "--task", "1")
+	app.stdin = strings.NewReader("Other project.\n")
+	app.run(t, 1, "log", "Website", "-t", "Nope", "--task", "3")
+	if logs, _ := app.svc.ListProjectLogs(1); len(logs) != 1 {
+		t.Fatalf("expected a rejected task to save no log, got %+v", logs)
+	}
+
+	out := app.run(t, 0, "log", "link", "1", "2")
+	if !strings.Contains(out, "Log 1 (Monday) is linked to task 2: Build") {
+		t.Errorf("unexpected output: %q", out)
+	}
+	out = app.run(t, 0, "log", "ls", "Website", "--format", "csv")
+	if !strings.Contains(out, `"1,2"`) {
+		t.Errorf("expected the log's task IDs in the csv, got %q", out)
+	}
+	out = app.run(t, 0, "log", "ls", "--task", "2")
+	if !strings.Contains(out, "Monday") {
+		t.Errorf("expected Monday among Build's logs, got %q", out)
+	}
+
+	app.run(t, 1, "log", "link", "1", "3")
+	app.run(t, 0, "log", "unlink", "1", "1")
+	app.run(t, 1, "log", "unlink", "1", "1")
+	if out := app.run(t, 0, "log", "ls", "--task", "1"); strings.Contains(out, "Monday") {
+		t.Errorf("expected Monday unlinked from Design, got %q", out)
+	}
+	app.run(t, 2, "log", "ls", "Website", "--task", "1")
+}
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/quamejnr/addae/internal/db"
+)
+
+const migrateUsage = `Usage: addae migrate <command> [arguments]
+
+Commands:
+  status                    List applied and pending migrations
+  up                        Apply every pending migration
+  down                      Roll back the most recent migration
+  down-to <version>         Roll back every migration newer than version
+  redo                      Roll back the most recent migration and apply it again
+
+up, down, down-to and redo accept --dry-run to print the SQL they would run.
+status accepts --format table|json|csv|markdown.
+
+Other commands apply pending migrations whenever they open the database,
+except after down or down-to: the database then stays at the version rolled
+back to, with a warning, until migrate up applies the rest.`
+
+// migrationStatus is the printable state of a single migration.
+type migrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+func (a *App) runMigrate(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, migrateUsage)
+		return usagef("missing migrate command")
+	}
+
+	switch args[0] {
+	case "status":
+		return a.migrateStatus(args[1:])
+	case "up", "down", "down-to", "redo":
+		return a.migrateRun(args[0], args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stdout, migrateUsage)
+		return nil
+	default:
+		fmt.Fprintln(a.stderr, migrateUsage)
+		return usagef("unknown migrate command %q", args[0])
+	}
+}
+
+func (a *App) migrator() (*db.Migrator, error) {
+	if a.opts.Migrations == nil || a.db == nil {
+		return nil, errors.New("no migrations available")
+	}
+	return db.NewMigrator(a.db, a.opts.Migrations)
+}
+
+func (a *App) migrateStatus(args []string) error {
+	fs := a.newFlagSet("migrate status")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	m, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	migrations := make([]migrationStatus, len(statuses))
+	pending := 0
+	for i, s := range statuses {
+		migrations[i] = migrationStatus{
+			Version: s.Source.Version,
+			Name:    path.Base(s.Source.Path),
+			State:   string(s.State),
+		}
+		if s.State == goose.StateApplied {
+			appliedAt := s.AppliedAt
+			migrations[i].AppliedAt = &appliedAt
+		} else {
+			pending++
+		}
+	}
+
+	if *format == formatJSON {
+		return a.writeJSON(migrations)
+	}
+
+	t := table{header: []string{"version", "state", "applied_at", "name"}}
+	for _, s := range migrations {
+		t.rows = append(t.rows, []string{
+			strconv.FormatInt(s.Version, 10), s.State, formatTime(*format, s.AppliedAt), s.Name,
+		})
+	}
+	if err := a.writeTable(*format, t); err != nil {
+		return err
+	}
+
+	if *format == formatTable {
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "\nDatabase version %d, %d pending\n", version, pending)
+	}
+	return nil
+}
+
+func (a *App) migrateRun(command string, args []string) error {
+	fs := a.newFlagSet("migrate " + command)
+	dryRun := fs.Bool("dry-run", false, "print the SQL that would run without running it")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var version int64
+	if command == "down-to" {
+		if len(rest) != 1 {
+			return usagef("expected exactly one version")
+		}
+		version, err = strconv.ParseInt(rest[0], 10, 64)
+		if err != nil || version < 0 {
+			return usagef("invalid version %q", rest[0])
+		}
+	} else if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+
+	m, err := a.migrator()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	if *dryRun {
+		steps, err := m.Plan(ctx, command, version)
+		if err != nil {
+			return err
+		}
+		if len(steps) == 0 {
+			fmt.Fprintln(a.stdout, "Nothing to do")
+			return nil
+		}
+		for i, step := range steps {
+			stmts, err := m.SQL(step)
+			if err != nil {
+				return err
+			}
+			direction := "down"
+			if step.Up {
+				direction = "up"
+			}
+			if i > 0 {
+				fmt.Fprintln(a.stdout)
+			}
+			fmt.Fprintf(a.stdout, "-- %s %s\n%s\n", direction, path.Base(step.Path), stmts)
+		}
+		return nil
+	}
+
+	var results []*goose.MigrationResult
+	switch command {
+	case "up":
+		results, err = m.Up(ctx)
+	case "down":
+		var res *goose.MigrationResult
+		if res, err = m.Down(ctx); res != nil {
+			results = append(results, res)
+		}
+	case "down-to":
+		results, err = m.DownTo(ctx, version)
+	case "redo":
+		results, err = m.Redo(ctx)
+	}
+
+	for _, res := range results {
+		fmt.Fprintln(a.stdout, res)
+	}
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(a.stdout, "Nothing to do")
+	}
+	return nil
+}
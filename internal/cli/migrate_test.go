@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// baseMigrations holds the first two migrations only, so the rollback steps
+// below do not shift as migrations are added.
+func baseMigrations(t *testing.T) fs.FS {
+	t.Helper()
+	fsys := fstest.MapFS{}
+	for _, name := range []string{
+		"20250204221253_init_schema.sql",
+		"20250720174907_add_completed_at_column.up.sql",
+	} {
+		data, err := os.ReadFile(filepath.Join("../db/migrations", name))
+		if err != nil {
+			t.Fatalf("failed to read migration: %v", err)
+		}
+		fsys[name] = &fstest.MapFile{Data: data}
+	}
+	return fsys
+}
+
+func TestMigrateStatusAndRollback(t *testing.T) {
+	app := setupWorkspaceApp(t, Options{DBPath: t.TempDir() + "/addae.db", Migrations: baseMigrations(t)})
+	app.run(t, 0, "migrate", "up")
+
+	out := app.run(t, 0, "migrate", "status")
+	if strings.Contains(out, "pending ") || !strings.Contains(out, "0 pending") {
+		t.Errorf("expected every migration to be applied, got %q", out)
+	}
+
+	out = app.run(t, 0, "migrate", "down", "--dry-run")
+	if !strings.Contains(out, "-- down 20250720174907_add_completed_at_column.up.sql") ||
+		!strings.Contains(out, "ALTER TABLE tasks DROP COLUMN completed_at;") {
+		t.Errorf("unexpected dry run output: %q", out)
+	}
+	if strings.Contains(out, "-- +goose") {
+		t.Errorf("expected goose annotations to be stripped, got %q", out)
+	}
+
+	out = app.run(t, 0, "migrate", "status", "--format", "json")
+	var statuses []migrationStatus
+	if err := json.Unmarshal([]byte(out), &statuses); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(statuses) != 2 || statuses[1].State != "applied" {
+		t.Fatalf("expected the dry run to leave migrations applied, got %+v", statuses)
+	}
+
+	out = app.run(t, 0, "migrate", "down")
+	if !strings.Contains(out, "down 20250720174907_add_completed_at_column.up.sql") {
+		t.Errorf("unexpected down output: %q", out)
+	}
+
+	out = app.run(t, 0, "migrate", "status")
+	if !strings.Contains(out, "Database version 20250204221253, 1 pending") {
+		t.Errorf("unexpected status after down: %q", out)
+	}
+
+	out = app.run(t, 0, "migrate", "up", "--dry-run")
+	if !strings.Contains(out, "-- up 20250720174907_add_completed_at_column.up.sql") {
+		t.Errorf("unexpected dry run output: %q", out)
+	}
+
+	app.run(t, 0, "migrate", "up")
+	out = app.run(t, 0, "migrate", "redo")
+	if strings.Count(out, "20250720174907") != 2 {
+		t.Errorf("expected redo to roll back and reapply, got %q", out)
+	}
+
+	app.run(t, 0, "migrate", "down-to", "20250204221253")
+	out = app.run(t, 0, "migrate", "up")
+	if !strings.Contains(out, "up 20250720174907") {
+		t.Errorf("unexpected up output: %q", out)
+	}
+
+	out = app.run(t, 0, "migrate", "up")
+	if !strings.Contains(out, "Nothing to do") {
+		t.Errorf("expected nothing to do, got %q", out)
+	}
+
+	app.run(t, 2, "migrate", "down-to")
+	app.run(t, 2, "migrate", "down-to", "latest")
+	app.run(t, 2, "migrate", "sideways")
+}
+
+func TestRollbackSurvivesOtherCommands(t *testing.T) {
+	app := setupWorkspaceApp(t, Options{DBPath: t.TempDir() + "/addae.db"})
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "migrate", "down")
+
+	app.run(t, 0, "task", "ls", "Website")
+	if !strings.Contains(app.stderr.String(), "apply the rest with: addae migrate up") {
+		t.Errorf("expected a warning that migrations are held back, got %q", app.stderr.String())
+	}
+	out := app.run(t, 0, "migrate", "status")
+	if !strings.Contains(out, ", 1 pending") {
+		t.Errorf("expected task ls to leave the rolled back migration pending, got %q", out)
+	}
+	out = app.run(t, 0, "doctor")
+	if !strings.Contains(out, "pending since a rollback") {
+		t.Errorf("expected doctor to report the rollback, got %q", out)
+	}
+	app.run(t, 0, "doctor", "--fix")
+	if out := app.run(t, 0, "migrate", "status"); !strings.Contains(out, ", 1 pending") {
+		t.Errorf("expected doctor --fix to leave the rollback alone, got %q", out)
+	}
+
+	app.run(t, 0, "migrate", "up")
+	app.run(t, 0, "task", "ls", "Website")
+	if app.stderr.Len() != 0 {
+		t.Errorf("expected no warning after migrate up, got %q", app.stderr.String())
+	}
+	out = app.run(t, 0, "migrate", "status")
+	if !strings.Contains(out, ", 0 pending") {
+		t.Errorf("expected every migration to be applied, got %q", out)
+	}
+}
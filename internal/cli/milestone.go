@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+const milestoneUsage = `Usage: addae milestone <command> [arguments]
+
+Commands:
+  ls     <project>                           List a project's milestones with their progress
+  add    <project> <name> [--target date] [--desc d]
+                                             Add a milestone to a project
+  show   <id>                                Show a milestone and its tasks
+  update <id> [--name n] [--target date] [--desc d]
+                                             Update a milestone
+  rm     <id>                                Delete a milestone, keeping its tasks
+
+Every command accepts --format table|json|csv|markdown. Commands that change
+a milestone print the affected record in any format other than table.
+
+<project> is a project ID or a unique prefix of its name. Target dates are
+written like due dates; an empty --target clears it. Tasks are assigned to
+a milestone with "addae task milestone".`
+
+func (a *App) runMilestone(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, milestoneUsage)
+		return usagef("missing milestone command")
+	}
+
+	switch args[0] {
+	case "ls", "list":
+		return a.milestoneList(args[1:])
+	case "add", "create":
+		return a.milestoneAdd(args[1:])
+	case "show":
+		return a.milestoneShow(args[1:])
+	case "update":
+		return a.milestoneUpdate(args[1:])
+	case "rm", "delete":
+		return a.milestoneRemove(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stdout, milestoneUsage)
+		return nil
+	default:
+		fmt.Fprintln(a.stderr, milestoneUsage)
+		return usagef("unknown milestone command %q", args[0])
+	}
+}
+
+func (a *App) milestoneList(args []string) error {
+	fs := a.newFlagSet("milestone ls")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one project")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	p, err := a.resolveProject(rest[0])
+	if err != nil {
+		return err
+	}
+	milestones, err := a.svc.ListProjectMilestones(p.ID)
+	if err != nil {
+		return err
+	}
+	return a.printMilestones(*format, milestones)
+}
+
+func (a *App) milestoneAdd(args []string) error {
+	fs := a.newFlagSet("milestone add")
+	target := fs.String("target", "", "target date")
+	desc := fs.String("desc", "", "description")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 2 {
+		return usagef("expected a project and a milestone name")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	m := service.Milestone{Name: strings.TrimSpace(rest[1]), Desc: *desc}
+	if err := validateMilestoneName(m.Name); err != nil {
+		return err
+	}
+	if m.TargetDate, err = service.ParseDueDate(*target, time.Now()); err != nil {
+		return usagef("%v", err)
+	}
+
+	p, err := a.resolveProject(rest[0])
+	if err != nil {
+		return err
+	}
+	m.ProjectID = p.ID
+	if err := a.svc.CreateMilestone(&m); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		created, err := a.svc.GetMilestone(m.ID)
+		if err != nil {
+			return err
+		}
+		return a.printMilestone(*format, *created)
+	}
+	fmt.Fprintf(a.stdout, "Added milestone %d to %s: %s\n", m.ID, p.Name, m.Name)
+	return nil
+}
+
+func (a *App) milestoneShow(args []string) error {
+	fs := a.newFlagSet("milestone show")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one milestone ID")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	m, err := a.resolveMilestone(rest[0])
+	if err != nil {
+		return err
+	}
+	if *format != formatTable {
+		return a.printMilestone(*format, *m)
+	}
+	if err := a.printMilestone(*format, *m); err != nil {
+		return err
+	}
+
+	tasks, err := a.svc.ListProjectTasks(m.ProjectID)
+	if err != nil {
+		return err
+	}
+	var assigned []service.Task
+	for _, t := range tasks {
+		if t.MilestoneID != nil && *t.MilestoneID == m.ID {
+			assigned = append(assigned, t)
+		}
+	}
+	if len(assigned) == 0 {
+		return nil
+	}
+	fmt.Fprintln(a.stdout)
+	return a.printTasks(*format, assigned)
+}
+
+func (a *App) milestoneUpdate(args []string) error {
+	fs := a.newFlagSet("milestone update")
+	name := fs.String("name", "", "new name")
+	target := fs.String("target", "", "new target date, or empty to clear it")
+	desc := fs.String("desc", "", "new description")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one milestone ID")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	set := flagsSet(fs)
+	if !set["name"] && !set["target"] && !set["desc"] {
+		return usagef("nothing to update; pass --name, --target or --desc")
+	}
+
+	m, err := a.resolveMilestone(rest[0])
+	if err != nil {
+		return err
+	}
+	if set["name"] {
+		m.Name = strings.TrimSpace(*name)
+		if err := validateMilestoneName(m.Name); err != nil {
+			return err
+		}
+	}
+	if set["target"] {
+		if m.TargetDate, err = service.ParseDueDate(*target, time.Now()); err != nil {
+			return usagef("%v", err)
+		}
+	}
+	if set["desc"] {
+		m.Desc = *desc
+	}
+
+	if err := a.svc.UpdateMilestone(m); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetMilestone(m.ID)
+		if err != nil {
+			return err
+		}
+		return a.printMilestone(*format, *updated)
+	}
+	fmt.Fprintf(a.stdout, "Updated milestone %d: %s\n", m.ID, m.Name)
+	return nil
+}
+
+func (a *App) milestoneRemove(args []string) error {
+	fs := a.newFlagSet("milestone rm")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one milestone ID")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	m, err := a.resolveMilestone(rest[0])
+	if err != nil {
+		return err
+	}
+	if err := a.svc.DeleteMilestone(m.ID); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		return a.printMilestone(*format, *m)
+	}
+	fmt.Fprintf(a.stdout, "Deleted milestone %d: %s\n", m.ID, m.Name)
+	return nil
+}
+
+// resolveMilestone looks up a milestone by its numeric ID.
+func (a *App) resolveMilestone(ref string) (*service.Milestone, error) {
+	id, err := strconv.Atoi(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, usagef("invalid milestone ID %q", ref)
+	}
+	return a.svc.GetMilestone(id)
+}
+
+func validateMilestoneName(name string) error {
+	if name == "" {
+		return usagef("milestone name is required")
+	}
+	if utf8.RuneCountInString(name) > 100 {
+		return usagef("milestone name must be at most 100 characters")
+	}
+	return nil
+}
+
+// formatProgress prints how many of a milestone's tasks are done.
+func formatProgress(m service.Milestone) string {
+	return fmt.Sprintf("%d/%d", m.Done, m.Tasks)
+}
+
+// printMilestones writes milestones in the given format.
+func (a *App) printMilestones(format string, milestones []service.Milestone) error {
+	if format == formatJSON {
+		if milestones == nil {
+			milestones = []service.Milestone{}
+		}
+		return a.writeJSON(milestones)
+	}
+
+	var t table
+	if format == formatTable {
+		t.header = []string{"id", "name", "target", "progress", "desc"}
+		for _, m := range milestones {
+			t.rows = append(t.rows, []string{
+				strconv.Itoa(m.ID), m.Name, formatDue(m.TargetDate), formatProgress(m), firstLine(m.Desc),
+			})
+		}
+		return a.writeTable(format, t)
+	}
+
+	t.header = []string{"id", "project_id", "name", "target_date", "desc", "tasks", "done", "created_at", "updated_at"}
+	for _, m := range milestones {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(m.ID), strconv.Itoa(m.ProjectID), m.Name, formatTime(format, m.TargetDate), m.Desc,
+			strconv.Itoa(m.Tasks), strconv.Itoa(m.Done),
+			formatTime(format, &m.DateCreated), formatTime(format, &m.DateUpdated),
+		})
+	}
+	return a.writeTable(format, t)
+}
+
+// printMilestone writes a single milestone, as a detail view in table format.
+func (a *App) printMilestone(format string, m service.Milestone) error {
+	switch format {
+	case formatJSON:
+		return a.writeJSON(m)
+	case formatTable:
+		w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "ID:\t%d\n", m.ID)
+		fmt.Fprintf(w, "Name:\t%s\n", m.Name)
+		if m.TargetDate != nil {
+			fmt.Fprintf(w, "Target:\t%s\n", formatDue(m.TargetDate))
+		}
+		fmt.Fprintf(w, "Progress:\t%s tasks done\n", formatProgress(m))
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if m.Desc != "" {
+			fmt.Fprintf(a.stdout, "\n%s\n", m.Desc)
+		}
+		return nil
+	default:
+		return a.printMilestones(format, []service.Milestone{m})
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+func TestMilestoneCommands(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Addae")
+	app.run(t, 0, "project", "add", "Other")
+	app.run(t, 0, "task", "add", "Addae", "Schema")
+	app.run(t, 0, "task", "add", "Addae", "Docs")
+	app.run(t, 0, "task", "add", "Other", "Elsewhere")
+
+	out := app.run(t, 0, "milestone", "ls", "Addae", "--format", "json")
+	if strings.TrimSpace(out) != "[]" {
+		t.Errorf("expected an empty JSON list, got %q", out)
+	}
+
+	out = app.run(t, 0, "milestone", "add", "Addae", "v1.0", "--target", "2026-12-01", "--desc", "First release")
+	if !strings.Contains(out, "Added milestone 1 to Addae: v1.0") {
+		t.Errorf("unexpected add output: %q", out)
+	}
+	app.run(t, 2, "milestone", "add", "Addae", " ")
+	app.run(t, 2, "milestone", "add", "Addae", "v2", "--target", "someday")
+	app.run(t, 1, "milestone", "add", "Nope", "v2")
+
+	out = app.run(t, 0, "task", "milestone", "1", "1")
+	if !strings.Contains(out, "Task 1 is in milestone 1 (v1.0): Schema") {
+		t.Errorf("unexpected task milestone output: %q", out)
+	}
+	app.run(t, 0, "task", "milestone", "2", "1")
+	app.run(t, 0, "task", "done", "1")
+	app.run(t, 1, "task", "milestone", "3", "1")
+	app.run(t, 1, "task", "milestone", "1", "99")
+
+	out = app.run(t, 0, "milestone", "ls", "Addae")
+	for _, want := range []string{"v1.0", "2026-12-01", "1/2", "First release"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the milestone list, got %q", want, out)
+		}
+	}
+	out = app.run(t, 0, "milestone", "show", "1")
+	for _, want := range []string{"Progress:", "1/2 tasks done", "Schema", "Docs"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the milestone, got %q", want, out)
+		}
+	}
+
+	out = app.run(t, 0, "task", "ls", "Addae", "--all", "--milestone", "1", "--format", "json")
+	var tasks []service.Task
+	if err := json.Unmarshal([]byte(out), &tasks); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].MilestoneID == nil || *tasks[0].MilestoneID != 1 {
+		t.Errorf("expected both tasks of the milestone, got %+v", tasks)
+	}
+
+	out = app.run(t, 0, "milestone", "update", "1", "--name", "v1.1", "--target", "", "--format", "json")
+	var m service.Milestone
+	if err := json.Unmarshal([]byte(out), &m); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if m.Name != "v1.1" || m.TargetDate != nil || m.Desc != "First release" || m.Tasks != 2 || m.Done != 1 {
+		t.Errorf("unexpected milestone after update: %+v", m)
+	}
+	app.run(t, 2, "milestone", "update", "1")
+
+	out = app.run(t, 0, "task", "milestone", "2")
+	if !strings.Contains(out, "Task 2 is not in a milestone: Docs") {
+		t.Errorf("unexpected clear output: %q", out)
+	}
+
+	out = app.run(t, 0, "milestone", "rm", "1")
+	if !strings.Contains(out, "Deleted milestone 1: v1.1") {
+		t.Errorf("unexpected rm output: %q", out)
+	}
+	task, err := app.svc.GetTask(1)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.MilestoneID != nil {
+		t.Errorf("expected the task to leave the deleted milestone, got %d", *task.MilestoneID)
+	}
+	app.run(t, 1, "milestone", "show", "1")
+
+	app.run(t, 2, "milestone", "add", "Addae", strings.Repeat("x", 101))
+	app.run(t, 0, "milestone", "add", "Addae", strings.Repeat("é", 60))
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/quamejnr/addae/internal/ui"
+)
+
+const openUsage = `Usage: addae open <project> [--tab details|tasks|logs] [--task id | --log id]
+
+Starts the interactive UI with <project> selected. --task and --log put the
+cursor on that item and show it, on the tasks or logs tab.
+
+<project> is a project ID or a unique prefix of its name.`
+
+// openTabs are the tabs accepted by --tab.
+var openTabs = []string{"details", "tasks", "logs"}
+
+func (a *App) runOpen(args []string) error {
+	target, err := a.parseOpen(args)
+	if err != nil {
+		return err
+	}
+	return a.runTUI(target)
+}
+
+// parseOpen resolves the arguments of addae open into the place to start the
+// UI at, checking that the task or log belongs to the project.
+func (a *App) parseOpen(args []string) (*ui.OpenTarget, error) {
+	fs := a.newFlagSet("open")
+	fs.Usage = func() { fmt.Fprintln(a.stderr, openUsage) }
+	tab := fs.String("tab", "", "tab to open: details, tasks or logs")
+	taskID := fs.Int("task", 0, "task to put the cursor on")
+	logID := fs.Int("log", 0, "log to put the cursor on")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 1 {
+		return nil, usagef("expected exactly one project")
+	}
+	if *taskID != 0 && *logID != 0 {
+		return nil, usagef("--task and --log are mutually exclusive")
+	}
+
+	if *tab != "" {
+		if !slices.Contains(openTabs, *tab) {
+			return nil, usagef("invalid tab %q, expected one of: details, tasks, logs", *tab)
+		}
+		if (*taskID != 0 && *tab != "tasks") || (*logID != 0 && *tab != "logs") {
+			return nil, usagef("--tab %s does not show the requested item", *tab)
+		}
+	}
+
+	p, err := a.resolveProject(rest[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if *taskID != 0 {
+		task, err := a.svc.GetTask(*taskID)
+		if err != nil {
+			return nil, err
+		}
+		if task.ProjectID != p.ID {
+			return nil, fmt.Errorf("task %d is not in project %s", task.ID, p.Name)
+		}
+	}
+	if *logID != 0 {
+		l, err := a.svc.GetLog(*logID)
+		if err != nil {
+			return nil, err
+		}
+		if l.ProjectID != p.ID {
+			return nil, fmt.Errorf("log %d is not in project %s", l.ID, p.Name)
+		}
+	}
+
+	return &ui.OpenTarget{ProjectID: p.ID, Tab: *tab, TaskID: *taskID, LogID: *logID}, nil
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/quamejnr/addae/internal/ui"
+)
+
+func TestParseOpen(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "project", "add", "Mobile")
+	app.run(t, 0, "task", "add", "Website", "Write copy")
+	app.run(t, 0, "task", "add", "Mobile", "Ship beta")
+	app.stdin = strings.NewReader("notes")
+	app.run(t, 0, "log", "Website", "-t", "Kickoff")
+
+	target, err := app.parseOpen([]string{"web", "--tab", "logs"})
+	if err != nil {
+		t.Fatalf("parseOpen failed: %v", err)
+	}
+	if *target != (ui.OpenTarget{ProjectID: 1, Tab: "logs"}) {
+		t.Errorf("unexpected target: %+v", *target)
+	}
+
+	target, err = app.parseOpen([]string{"Website", "--task", "1"})
+	if err != nil {
+		t.Fatalf("parseOpen failed: %v", err)
+	}
+	if target.TaskID != 1 || target.ProjectID != 1 {
+		t.Errorf("unexpected target: %+v", *target)
+	}
+
+	var usageErr usageError
+	for _, args := range [][]string{
+		{},
+		{"Website", "--tab", "notes"},
+		{"Website", "--task", "1", "--log", "1"},
+		{"Website", "--tab", "logs", "--task", "1"},
+	} {
+		if _, err := app.parseOpen(args); !errors.As(err, &usageErr) {
+			t.Errorf("parseOpen(%q): expected usage error, got %v", args, err)
+		}
+	}
+
+	if _, err := app.parseOpen([]string{"Website", "--task", "2"}); err == nil {
+		t.Errorf("expected error opening a task from another project")
+	}
+	if _, err := app.parseOpen([]string{"Mobile", "--log", "1"}); err == nil {
+		t.Errorf("expected error opening a log from another project")
+	}
+}
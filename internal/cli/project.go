@@ -0,0 +1,321 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+// projectStatuses mirrors the CHECK constraint on projects.status.
+var projectStatuses = []string{"todo", "in progress", "completed", "archived"}
+
+const projectUsage = `Usage: addae project <command> [arguments]
+
+Commands:
+  list   [--status s] [--tag t]                List projects
+  add    <name> [--summary s] [--desc d] [--status s] [--parent p]
+                                               Create a project
+  show   <project>                             Show a project and its subprojects
+  update <project> [--name n] [--summary s] [--desc d] [--status s] [--parent p]
+                                               Update a project
+  rm     <project>                             Delete a project with its tasks and logs
+
+Every command accepts --format table|json|csv|markdown. Commands that change
+a project print the affected record in any format other than table.
+
+<project> is a project ID or a unique prefix of its name. --parent puts a
+project under another one, such as an area like "OSS"; an empty --parent
+makes it top-level. The table of list shows subprojects under their parents
+with the progress of their tasks, counting those of their subprojects.
+Deleting a project moves its subprojects up to its parent.`
+
+func (a *App) runProject(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, projectUsage)
+		return usagef("missing project command")
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		return a.projectList(args[1:])
+	case "add", "create":
+		return a.projectAdd(args[1:])
+	case "show":
+		return a.projectShow(args[1:])
+	case "update":
+		return a.projectUpdate(args[1:])
+	case "rm", "delete":
+		return a.projectRemove(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stdout, projectUsage)
+		return nil
+	default:
+		fmt.Fprintln(a.stderr, projectUsage)
+		return usagef("unknown project command %q", args[0])
+	}
+}
+
+func (a *App) projectList(args []string) error {
+	fs := a.newFlagSet("project list")
+	status := fs.String("status", "", "only list projects with this status")
+	tag := fs.String("tag", "", "only list projects with this tag or holding tasks with it")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+	if *status != "" {
+		if err := validateStatus(*status); err != nil {
+			return err
+		}
+	}
+
+	f := service.ProjectFilter{Tag: *tag}
+	if *status != "" {
+		f.Statuses = []string{*status}
+	}
+	projects, err := a.svc.FilterProjects(f)
+	if err != nil {
+		return err
+	}
+	return a.printProjects(*format, projects)
+}
+
+func (a *App) projectAdd(args []string) error {
+	fs := a.newFlagSet("project add")
+	name := fs.String("name", "", "project name")
+	summary := fs.String("summary", "", "one-line summary")
+	desc := fs.String("desc", "", "detailed description")
+	status := fs.String("status", "todo", "status: todo, in progress, completed or archived")
+	parent := fs.String("parent", "", "project to put the project under")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	switch {
+	case len(rest) == 1 && *name == "":
+		*name = rest[0]
+	case len(rest) > 0:
+		return usagef("unexpected argument %q", rest[len(rest)-1])
+	}
+
+	p := service.Project{
+		Name:    strings.TrimSpace(*name),
+		Summary: *summary,
+		Desc:    *desc,
+		Status:  *status,
+	}
+	if err := validateProject(p); err != nil {
+		return err
+	}
+	if *parent != "" {
+		parentProject, err := a.resolveProject(*parent)
+		if err != nil {
+			return err
+		}
+		p.ParentID = &parentProject.ID
+	}
+
+	if err := a.svc.CreateProject(&p); err != nil {
+		return err
+	}
+	if p.ParentID != nil {
+		if err := a.svc.SetProjectParent(p.ID, p.ParentID); err != nil {
+			return err
+		}
+	}
+	if *format != formatTable {
+		created, err := a.svc.GetProject(p.ID)
+		if err != nil {
+			return err
+		}
+		return a.printProject(*format, *created)
+	}
+	fmt.Fprintf(a.stdout, "Created project %d: %s\n", p.ID, p.Name)
+	return nil
+}
+
+func (a *App) projectShow(args []string) error {
+	fs := a.newFlagSet("project show")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one project")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	p, err := a.resolveProject(rest[0])
+	if err != nil {
+		return err
+	}
+	if *format != formatTable {
+		return a.printProject(*format, *p)
+	}
+	if err := a.printProject(*format, *p); err != nil {
+		return err
+	}
+
+	projects, err := a.svc.ListProjects()
+	if err != nil {
+		return err
+	}
+	var subprojects []service.Project
+	for _, sub := range projects {
+		if sub.ParentID != nil && *sub.ParentID == p.ID {
+			subprojects = append(subprojects, sub)
+		}
+	}
+	if len(subprojects) == 0 {
+		return nil
+	}
+	fmt.Fprintln(a.stdout)
+	return a.printProjects(*format, subprojects)
+}
+
+func (a *App) projectUpdate(args []string) error {
+	fs := a.newFlagSet("project update")
+	name := fs.String("name", "", "new project name")
+	summary := fs.String("summary", "", "new summary")
+	desc := fs.String("desc", "", "new description")
+	status := fs.String("status", "", "new status: todo, in progress, completed or archived")
+	parent := fs.String("parent", "", "project to put the project under, or empty to make it top-level")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one project")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	set := flagsSet(fs)
+	delete(set, "format")
+	if len(set) == 0 {
+		return usagef("nothing to update, pass at least one of --name, --summary, --desc, --status or --parent")
+	}
+
+	p, err := a.resolveProject(rest[0])
+	if err != nil {
+		return err
+	}
+
+	if set["name"] {
+		p.Name = strings.TrimSpace(*name)
+	}
+	if set["summary"] {
+		p.Summary = *summary
+	}
+	if set["desc"] {
+		p.Desc = *desc
+	}
+	if set["status"] {
+		p.Status = *status
+	}
+	if err := validateProject(*p); err != nil {
+		return err
+	}
+	var parentID *int
+	if set["parent"] && *parent != "" {
+		parentProject, err := a.resolveProject(*parent)
+		if err != nil {
+			return err
+		}
+		parentID = &parentProject.ID
+	}
+
+	if err := a.svc.UpdateProject(p); err != nil {
+		return err
+	}
+	if set["parent"] {
+		if err := a.svc.SetProjectParent(p.ID, parentID); err != nil {
+			return err
+		}
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetProject(p.ID)
+		if err != nil {
+			return err
+		}
+		return a.printProject(*format, *updated)
+	}
+	fmt.Fprintf(a.stdout, "Updated project %d: %s\n", p.ID, p.Name)
+	return nil
+}
+
+func (a *App) projectRemove(args []string) error {
+	fs := a.newFlagSet("project rm")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one project")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	p, err := a.resolveProject(rest[0])
+	if err != nil {
+		return err
+	}
+
+	if err := a.svc.DeleteProject(p.ID); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		return a.printProject(*format, *p)
+	}
+	fmt.Fprintf(a.stdout, "Deleted project %d: %s\n", p.ID, p.Name)
+	return nil
+}
+
+func validateProject(p service.Project) error {
+	if p.Name == "" {
+		return usagef("project name is required")
+	}
+	if utf8.RuneCountInString(p.Name) > 100 {
+		return usagef("project name must be at most 100 characters")
+	}
+	if utf8.RuneCountInString(p.Summary) > 255 {
+		return usagef("project summary must be at most 255 characters")
+	}
+	return validateStatus(p.Status)
+}
+
+func validateStatus(status string) error {
+	for _, s := range projectStatuses {
+		if status == s {
+			return nil
+		}
+	}
+	return usagef("invalid status %q, expected one of: %s", status, strings.Join(projectStatuses, ", "))
+}
+
+// firstLine returns the first line of s, for single-line table cells.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/quamejnr/addae/internal/server"
+)
+
+const serveUsage = `Usage: addae serve [--addr host:port]
+
+Serves a JSON API for projects, tasks and logs until interrupted:
+
+  GET    /api/projects[?status=s&tag=t]    POST /api/projects
+  GET    /api/projects/{id}                PATCH, DELETE /api/projects/{id}
+  GET    /api/projects/{id}/tasks[?completed=b&tag=t]
+  POST   /api/projects/{id}/tasks
+  GET    /api/projects/{id}/logs           POST /api/projects/{id}/logs
+  GET    /api/projects/{id}/time-entries
+  GET    /api/projects/{id}/milestones     POST /api/projects/{id}/milestones
+  GET    /api/projects/{id}/links          POST /api/projects/{id}/links
+  GET    /api/tasks[?project_id=n&completed=b&tag=t]
+  GET    /api/tasks/{id}                   PATCH, DELETE /api/tasks/{id}
+  GET    /api/tasks/{id}/states            POST /api/tasks/{id}/timer
+  GET    /api/tasks/{id}/logs
+  GET    /api/tasks/{id}/links             POST /api/tasks/{id}/links
+  GET    /api/tasks/{id}/attachments       POST /api/tasks/{id}/attachments?name=n
+  GET    /api/logs[?project_id=n]
+  GET    /api/logs/{id}                    PATCH, DELETE /api/logs/{id}
+  GET    /api/logs/{id}/attachments        POST /api/logs/{id}/attachments?name=n
+  GET    /api/milestones/{id}              PATCH, DELETE /api/milestones/{id}
+  DELETE /api/links/{id}
+  GET    /api/attachments/{id}             DELETE /api/attachments/{id}
+  GET    /api/attachments/{id}/content
+  GET    /api/tags                         GET /api/states
+  GET    /api/timer                        DELETE /api/timer
+
+The API has no authentication, so keep it on a loopback address. JSON
+bodies must be sent as application/json, and requests from web pages not
+served from loopback are refused, as are requests for a host other than
+localhost or a loopback IP unless --addr binds elsewhere.`
+
+func (a *App) runServe(args []string) error {
+	fs := a.newFlagSet("serve")
+	fs.Usage = func() { fmt.Fprintln(a.stderr, serveUsage) }
+	addr := fs.String("addr", "127.0.0.1:7777", "address to listen on")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+	if err := a.applyMaxAttachmentSize(); err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	if tcp, ok := ln.Addr().(*net.TCPAddr); ok && !tcp.IP.IsLoopback() {
+		fmt.Fprintf(a.stderr, "warning: %s is reachable from other machines and the API has no authentication\n", ln.Addr())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(a.stdout, "Serving %s on http://%s\n", a.dbName, ln.Addr())
+	if err := server.New(a.svc).Serve(ctx, ln); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.stdout, "Stopped")
+	return nil
+}
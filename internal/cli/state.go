@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+const stateUsage = `Usage: addae state <command> [arguments]
+
+Commands:
+  ls                                         List the workflow states in order
+  add    <name>                              Add a state, just before done
+  rm     <name>                              Remove a state no task is in
+
+ls accepts --format table|json|csv|markdown. Tasks start in the first state
+and are completed in done, which always comes last. Move a task between
+states with "addae task state".`
+
+func (a *App) runState(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, stateUsage)
+		return usagef("missing state command")
+	}
+
+	switch args[0] {
+	case "ls", "list":
+		return a.stateList(args[1:])
+	case "add":
+		return a.stateAdd(args[1:])
+	case "rm", "remove":
+		return a.stateRemove(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stdout, stateUsage)
+		return nil
+	default:
+		fmt.Fprintln(a.stderr, stateUsage)
+		return usagef("unknown state command %q", args[0])
+	}
+}
+
+func (a *App) stateList(args []string) error {
+	fs := a.newFlagSet("state ls")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	states, err := a.svc.TaskStates()
+	if err != nil {
+		return err
+	}
+	if *format == formatJSON {
+		return a.writeJSON(states)
+	}
+	t := table{header: []string{"state"}}
+	for _, s := range states {
+		t.rows = append(t.rows, []string{s})
+	}
+	return a.writeTable(*format, t)
+}
+
+func (a *App) stateAdd(args []string) error {
+	fs := a.newFlagSet("state add")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one state name")
+	}
+
+	name := strings.TrimSpace(rest[0])
+	if err := a.svc.AddTaskState(name); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Added state %q before %s\n", name, service.StateDone)
+	return nil
+}
+
+func (a *App) stateRemove(args []string) error {
+	fs := a.newFlagSet("state rm")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one state name")
+	}
+
+	if err := a.svc.RemoveTaskState(rest[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Removed state %q\n", rest[0])
+	return nil
+}
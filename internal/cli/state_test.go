@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+func TestStateCommands(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Addae")
+	app.run(t, 0, "task", "add", "Addae", "Schema")
+
+	out := app.run(t, 0, "state", "ls", "--format", "json")
+	var states []string
+	if err := json.Unmarshal([]byte(out), &states); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if strings.Join(states, ",") != "todo,in progress,blocked,in review,done" {
+		t.Errorf("unexpected default states: %v", states)
+	}
+
+	out = app.run(t, 0, "state", "add", "qa")
+	if !strings.Contains(out, `Added state "qa" before done`) {
+		t.Errorf("unexpected add output: %q", out)
+	}
+	app.run(t, 1, "state", "add", "qa")
+	app.run(t, 1, "state", "add", "done")
+
+	out = app.run(t, 0, "task", "state", "1", "qa")
+	if !strings.Contains(out, "Task 1 is qa: Schema") {
+		t.Errorf("unexpected task state output: %q", out)
+	}
+	app.run(t, 1, "task", "state", "1", "nope")
+	app.run(t, 1, "state", "rm", "qa")
+
+	out = app.run(t, 0, "task", "ls", "Addae", "--state", "qa")
+	if !strings.Contains(out, "Schema") {
+		t.Errorf("expected the task in the state filter, got %q", out)
+	}
+
+	out = app.run(t, 0, "task", "state", "1", "done", "--format", "json")
+	var task service.Task
+	if err := json.Unmarshal([]byte(out), &task); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if task.State != service.StateDone || task.CompletedAt == nil {
+		t.Errorf("expected the task to be completed in done, got %+v", task)
+	}
+
+	out = app.run(t, 0, "task", "state", "1", "--format", "json")
+	var history []service.StateChange
+	if err := json.Unmarshal([]byte(out), &history); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(history) != 3 || history[0].State != "todo" || history[1].State != "qa" || history[2].State != "done" {
+		t.Errorf("unexpected state history: %+v", history)
+	}
+
+	out = app.run(t, 0, "state", "rm", "qa")
+	if !strings.Contains(out, `Removed state "qa"`) {
+		t.Errorf("unexpected rm output: %q", out)
+	}
+	app.run(t, 1, "state", "rm", "qa")
+}
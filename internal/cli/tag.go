@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+const tagUsage = `Usage: addae tag <command> [arguments]
+
+Commands:
+  ls                                         List tags with how many projects and tasks use them
+  add    <tag> (--project p | --task id)     Tag a project or a task
+  rm     <tag> (--project p | --task id)     Remove a tag from a project or a task
+  rename <tag> <new-name>                    Rename a tag everywhere it is used
+
+ls accepts --format table|json|csv|markdown. Tags are single words and are
+matched regardless of case. A tag disappears once nothing uses it.
+
+<p> is a project ID or a unique prefix of its name.`
+
+func (a *App) runTag(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, tagUsage)
+		return usagef("missing tag command")
+	}
+
+	switch args[0] {
+	case "ls", "list":
+		return a.tagList(args[1:])
+	case "add":
+		return a.tagSet(args[1:], true)
+	case "rm", "remove":
+		return a.tagSet(args[1:], false)
+	case "rename":
+		return a.tagRename(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stdout, tagUsage)
+		return nil
+	default:
+		fmt.Fprintln(a.stderr, tagUsage)
+		return usagef("unknown tag command %q", args[0])
+	}
+}
+
+func (a *App) tagList(args []string) error {
+	fs := a.newFlagSet("tag ls")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	tags, err := a.svc.ListTags()
+	if err != nil {
+		return err
+	}
+	if *format == formatJSON {
+		if tags == nil {
+			tags = []service.Tag{}
+		}
+		return a.writeJSON(tags)
+	}
+
+	t := table{header: []string{"name", "projects", "tasks"}}
+	for _, tag := range tags {
+		t.rows = append(t.rows, []string{tag.Name, strconv.Itoa(tag.Projects), strconv.Itoa(tag.Tasks)})
+	}
+	return a.writeTable(*format, t)
+}
+
+// tagSet adds a tag to, or removes it from, the project or task named by
+// --project or --task.
+func (a *App) tagSet(args []string, add bool) error {
+	command := "tag rm"
+	if add {
+		command = "tag add"
+	}
+	fs := a.newFlagSet(command)
+	projectRef := fs.String("project", "", "project to tag")
+	taskRef := fs.String("task", "", "ID of the task to tag")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one tag")
+	}
+	if (*projectRef == "") == (*taskRef == "") {
+		return usagef("expected one of --project or --task")
+	}
+	tag, err := service.NormalizeTag(rest[0])
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	if *projectRef != "" {
+		p, err := a.resolveProject(*projectRef)
+		if err != nil {
+			return err
+		}
+		if add {
+			if err := a.svc.AddProjectTag(p.ID, tag); err != nil {
+				return err
+			}
+			fmt.Fprintf(a.stdout, "Tagged project %d (%s) with %s\n", p.ID, p.Name, tag)
+			return nil
+		}
+		if err := a.svc.RemoveProjectTag(p.ID, tag); err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				return fmt.Errorf("project %d is not tagged with %s", p.ID, tag)
+			}
+			return err
+		}
+		fmt.Fprintf(a.stdout, "Removed %s from project %d (%s)\n", tag, p.ID, p.Name)
+		return nil
+	}
+
+	task, err := a.resolveTask(*taskRef)
+	if err != nil {
+		return err
+	}
+	if add {
+		if err := a.svc.AddTaskTag(task.ID, tag); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "Tagged task %d (%s) with %s\n", task.ID, task.Title, tag)
+		return nil
+	}
+	if err := a.svc.RemoveTaskTag(task.ID, tag); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			return fmt.Errorf("task %d is not tagged with %s", task.ID, tag)
+		}
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Removed %s from task %d (%s)\n", tag, task.ID, task.Title)
+	return nil
+}
+
+func (a *App) tagRename(args []string) error {
+	fs := a.newFlagSet("tag rename")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 2 {
+		return usagef("expected a tag and its new name")
+	}
+	newName, err := service.NormalizeTag(rest[1])
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	if err := a.svc.RenameTag(rest[0], newName); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Renamed tag %s to %s\n", rest[0], newName)
+	return nil
+}
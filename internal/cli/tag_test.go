@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+func TestTagCommands(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "project", "add", "Garden")
+	app.run(t, 0, "task", "add", "Website", "Write copy")
+	app.run(t, 0, "task", "add", "Website", "Fix footer")
+
+	app.run(t, 0, "tag", "add", "acme", "--project", "Web")
+	app.run(t, 0, "tag", "add", "#copy", "--task", "1")
+	app.run(t, 2, "tag", "add", "two words", "--task", "1")
+	app.run(t, 2, "tag", "add", "acme")
+	app.run(t, 2, "tag", "add", "acme", "--project", "Web", "--task", "1")
+
+	out := app.run(t, 0, "project", "list", "--tag", "ACME")
+	if !strings.Contains(out, "Website") || strings.Contains(out, "Garden") {
+		t.Errorf("expected only the tagged project, got %q", out)
+	}
+	out = app.run(t, 0, "task", "ls", "Website", "--tag", "copy")
+	if !strings.Contains(out, "Write copy") || strings.Contains(out, "Fix footer") {
+		t.Errorf("expected only the tagged task, got %q", out)
+	}
+	out = app.run(t, 0, "project", "list", "--tag", "copy")
+	if !strings.Contains(out, "Website") || strings.Contains(out, "Garden") {
+		t.Errorf("expected the project holding the tagged task, got %q", out)
+	}
+	out = app.run(t, 0, "project", "show", "Website")
+	if !strings.Contains(out, "Tags:") || !strings.Contains(out, "acme") {
+		t.Errorf("expected tags in project details, got %q", out)
+	}
+
+	app.run(t, 0, "tag", "rename", "acme", "client-acme")
+	out = app.run(t, 0, "tag", "ls", "--format", "json")
+	var tags []service.Tag
+	if err := json.Unmarshal([]byte(out), &tags); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(tags) != 2 || tags[0].Name != "client-acme" || tags[0].Projects != 1 || tags[1].Tasks != 1 {
+		t.Errorf("unexpected tags: %+v", tags)
+	}
+
+	app.run(t, 0, "tag", "rm", "copy", "--task", "1")
+	app.run(t, 1, "tag", "rm", "copy", "--task", "1")
+	out = app.run(t, 0, "tag", "ls")
+	if strings.Contains(out, "copy\t") || strings.Contains(out, "copy ") {
+		t.Errorf("expected unused tag to be dropped, got %q", out)
+	}
+}
@@ -0,0 +1,713 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+const taskUsage = `Usage: addae task <command> [arguments]
+
+Commands:
+  add    <project> <title> [--desc d] [--due date] [--priority p] [--parent id] [--repeat rule]
+                                      Add a task to a project, or a subtask to a task
+  ls     <project> [--all | --done] [--tag t] [--milestone id] [--state s]
+                                      List pending tasks, or all or completed ones
+  done   <id>                           Mark a task as completed
+  undone <id>                           Mark a task as pending again
+  state  <id> [state]                   Move a task to a workflow state, or show its history
+  due    <id> [date]                    Set a task's due date, or clear it
+  priority <id> <level>                 Set a task's priority
+  repeat <id> [rule]                    Make a task repeat, or stop it repeating
+  parent <id> [parent-id]               Make a task a subtask, or a top-level task again
+  milestone <id> [milestone-id]         Assign a task to a milestone, or clear it
+  move   <id> <position|top|bottom>     Move a task in its project's manual order
+  block  <id> <blocker-id>...           Mark a task as blocked until other tasks are done
+  unblock <id> <blocker-id>...          Stop other tasks from blocking a task
+  rm     <id>                           Delete a task
+
+Every command accepts --format table|json|csv|markdown. Commands that change
+a task print the affected record in any format other than table.
+
+<project> is a project ID or a unique prefix of its name. Dates are written
+as YYYY-MM-DD, today, tomorrow, or a number of days or weeks ahead like 3d
+or 2w. Priorities are none, low, medium, high or urgent; pending tasks are
+listed by priority.
+
+Repeat rules are daily, weekly, weekly on some days like "weekly mon,thu",
+monthly, or a number of days after completion like "every 14d". Completing a
+repeating task adds its next occurrence.
+
+Workflow states are listed by "addae state ls". Moving a task to done
+completes it, and reopening a task moves it back to the first state.
+
+Tasks of the same priority are listed in a manual order. Positions count
+from 0 across the whole project, as in the position field of the json and
+csv output.`
+
+func (a *App) runTask(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, taskUsage)
+		return usagef("missing task command")
+	}
+
+	switch args[0] {
+	case "add":
+		return a.taskAdd(args[1:])
+	case "ls", "list":
+		return a.taskList(args[1:])
+	case "done":
+		return a.taskSetCompleted(args[1:], true)
+	case "undone":
+		return a.taskSetCompleted(args[1:], false)
+	case "state":
+		return a.taskState(args[1:])
+	case "due":
+		return a.taskSetDue(args[1:])
+	case "priority":
+		return a.taskSetPriority(args[1:])
+	case "repeat":
+		return a.taskSetRecurrence(args[1:])
+	case "parent":
+		return a.taskSetParent(args[1:])
+	case "milestone":
+		return a.taskSetMilestone(args[1:])
+	case "move":
+		return a.taskMove(args[1:])
+	case "block":
+		return a.taskSetBlockers(args[1:], true)
+	case "unblock":
+		return a.taskSetBlockers(args[1:], false)
+	case "rm", "delete":
+		return a.taskRemove(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stdout, taskUsage)
+		return nil
+	default:
+		fmt.Fprintln(a.stderr, taskUsage)
+		return usagef("unknown task command %q", args[0])
+	}
+}
+
+func (a *App) taskAdd(args []string) error {
+	fs := a.newFlagSet("task add")
+	desc := fs.String("desc", "", "task description")
+	due := fs.String("due", "", "due date")
+	priority := fs.String("priority", "none", "priority: none, low, medium, high or urgent")
+	parent := fs.String("parent", "", "ID of the task to add a subtask to")
+	repeat := fs.String("repeat", "", "repeat rule, like daily, weekly mon,thu, monthly or every 14d")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 2 {
+		return usagef("expected a project and a title")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	title := strings.TrimSpace(rest[1])
+	if err := validateTaskTitle(title); err != nil {
+		return err
+	}
+	dueAt, err := service.ParseDueDate(*due, time.Now())
+	if err != nil {
+		return usagef("%v", err)
+	}
+	level, err := service.ParsePriority(*priority)
+	if err != nil {
+		return usagef("%v", err)
+	}
+	recurrence, err := service.ParseRecurrence(*repeat)
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	p, err := a.resolveProject(rest[0])
+	if err != nil {
+		return err
+	}
+	var parentTask *service.Task
+	if *parent != "" {
+		if parentTask, err = a.resolveTask(*parent); err != nil {
+			return err
+		}
+		if parentTask.ProjectID != p.ID {
+			return fmt.Errorf("task %d is not in project %s", parentTask.ID, p.Name)
+		}
+	}
+
+	id, err := a.svc.CreateTask(p.ID, title, *desc, dueAt)
+	if err != nil {
+		return err
+	}
+	if level != service.PriorityNone {
+		if err := a.svc.SetTaskPriority(id, level); err != nil {
+			return err
+		}
+	}
+	if parentTask != nil {
+		if err := a.svc.SetTaskParent(id, &parentTask.ID); err != nil {
+			return err
+		}
+	}
+	if !recurrence.IsZero() {
+		if err := a.svc.SetTaskRecurrence(id, recurrence); err != nil {
+			return err
+		}
+	}
+	if *format != formatTable {
+		task, err := a.svc.GetTask(id)
+		if err != nil {
+			return err
+		}
+		return a.printTask(*format, *task)
+	}
+	fmt.Fprintf(a.stdout, "Added task %d to %s: %s\n", id, p.Name, title)
+	return nil
+}
+
+func (a *App) taskList(args []string) error {
+	fs := a.newFlagSet("task ls")
+	all := fs.Bool("all", false, "list pending and completed tasks")
+	done := fs.Bool("done", false, "list only completed tasks")
+	tag := fs.String("tag", "", "only list tasks with this tag")
+	milestone := fs.Int("milestone", 0, "only list tasks assigned to this milestone")
+	state := fs.String("state", "", "only list tasks in this workflow state")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one project")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+	if *all && *done {
+		return usagef("--all and --done are mutually exclusive")
+	}
+
+	p, err := a.resolveProject(rest[0])
+	if err != nil {
+		return err
+	}
+
+	tasks, err := a.svc.ListProjectTasks(p.ID)
+	if err != nil {
+		return err
+	}
+
+	var filtered []service.Task
+	for _, t := range tasks {
+		if (*all || (t.CompletedAt != nil) == *done) && (*tag == "" || service.HasTag(t.Tags, *tag)) &&
+			(*milestone == 0 || (t.MilestoneID != nil && *t.MilestoneID == *milestone)) &&
+			(*state == "" || t.State == *state) {
+			filtered = append(filtered, t)
+		}
+	}
+	return a.printTasks(*format, filtered)
+}
+
+// taskSetCompleted marks a task as completed or pending, setting completed_at
+// the same way the task list does when toggling a task.
+func (a *App) taskSetCompleted(args []string, completed bool) error {
+	name := "task undone"
+	if completed {
+		name = "task done"
+	}
+	fs := a.newFlagSet(name)
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one task ID")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+
+	// Completing a task twice keeps the time it was first completed.
+	var completedAt *time.Time
+	if completed {
+		completedAt = task.CompletedAt
+		if completedAt == nil {
+			now := time.Now()
+			completedAt = &now
+		}
+	}
+
+	if err := a.svc.UpdateTask(task.ID, task.Title, task.Desc, completedAt, task.DueAt); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetTask(task.ID)
+		if err != nil {
+			return err
+		}
+		return a.printTask(*format, *updated)
+	}
+
+	state := "pending"
+	if completed {
+		state = "completed"
+	}
+	fmt.Fprintf(a.stdout, "Marked task %d as %s: %s\n", task.ID, state, task.Title)
+	if completed && task.CompletedAt == nil && !task.Recurrence.IsZero() {
+		next := task.Recurrence.Next(task.DueAt, *completedAt)
+		fmt.Fprintf(a.stdout, "It repeats %s, next due %s\n", task.Recurrence.Describe(), next.Format(service.DueDateLayout))
+	}
+	return nil
+}
+
+// taskSetDue sets the due date of a task, or clears it when no date is given.
+func (a *App) taskSetDue(args []string) error {
+	fs := a.newFlagSet("task due")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 1 || len(rest) > 2 {
+		return usagef("expected a task ID and an optional date")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	var dueAt *time.Time
+	if len(rest) == 2 {
+		if dueAt, err = service.ParseDueDate(rest[1], time.Now()); err != nil {
+			return usagef("%v", err)
+		}
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+	if err := a.svc.UpdateTask(task.ID, task.Title, task.Desc, task.CompletedAt, dueAt); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetTask(task.ID)
+		if err != nil {
+			return err
+		}
+		return a.printTask(*format, *updated)
+	}
+
+	if dueAt == nil {
+		fmt.Fprintf(a.stdout, "Cleared the due date of task %d: %s\n", task.ID, task.Title)
+		return nil
+	}
+	fmt.Fprintf(a.stdout, "Task %d is due %s: %s\n", task.ID, formatDue(dueAt), task.Title)
+	return nil
+}
+
+func (a *App) taskSetPriority(args []string) error {
+	fs := a.newFlagSet("task priority")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 2 {
+		return usagef("expected a task ID and a priority")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+	level, err := service.ParsePriority(rest[1])
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+	if err := a.svc.SetTaskPriority(task.ID, level); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetTask(task.ID)
+		if err != nil {
+			return err
+		}
+		return a.printTask(*format, *updated)
+	}
+	fmt.Fprintf(a.stdout, "Set the priority of task %d to %s: %s\n", task.ID, level, task.Title)
+	return nil
+}
+
+// taskSetRecurrence sets the rule a task repeats by, or stops it repeating
+// when no rule is given.
+func (a *App) taskSetRecurrence(args []string) error {
+	fs := a.newFlagSet("task repeat")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 1 {
+		return usagef("expected a task ID and an optional repeat rule")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+	recurrence, err := service.ParseRecurrence(strings.Join(rest[1:], " "))
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+	if err := a.svc.SetTaskRecurrence(task.ID, recurrence); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetTask(task.ID)
+		if err != nil {
+			return err
+		}
+		return a.printTask(*format, *updated)
+	}
+	if recurrence.IsZero() {
+		fmt.Fprintf(a.stdout, "Task %d no longer repeats: %s\n", task.ID, task.Title)
+		return nil
+	}
+	fmt.Fprintf(a.stdout, "Task %d repeats %s: %s\n", task.ID, recurrence.Describe(), task.Title)
+	return nil
+}
+
+// taskSetParent makes a task a subtask of another, or a top-level task when
+// no parent is given.
+func (a *App) taskSetParent(args []string) error {
+	fs := a.newFlagSet("task parent")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 1 || len(rest) > 2 {
+		return usagef("expected a task ID and an optional parent task ID")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+	var parent *service.Task
+	if len(rest) == 2 {
+		if parent, err = a.resolveTask(rest[1]); err != nil {
+			return err
+		}
+	}
+
+	var parentID *int
+	if parent != nil {
+		parentID = &parent.ID
+	}
+	if err := a.svc.SetTaskParent(task.ID, parentID); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetTask(task.ID)
+		if err != nil {
+			return err
+		}
+		return a.printTask(*format, *updated)
+	}
+
+	if parent == nil {
+		fmt.Fprintf(a.stdout, "Task %d is a top-level task: %s\n", task.ID, task.Title)
+		return nil
+	}
+	fmt.Fprintf(a.stdout, "Task %d is a subtask of task %d (%s): %s\n", task.ID, parent.ID, parent.Title, task.Title)
+	return nil
+}
+
+func (a *App) taskSetMilestone(args []string) error {
+	fs := a.newFlagSet("task milestone")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 1 || len(rest) > 2 {
+		return usagef("expected a task ID and an optional milestone ID")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+	var milestone *service.Milestone
+	if len(rest) == 2 {
+		if milestone, err = a.resolveMilestone(rest[1]); err != nil {
+			return err
+		}
+	}
+
+	var milestoneID *int
+	if milestone != nil {
+		milestoneID = &milestone.ID
+	}
+	if err := a.svc.SetTaskMilestone(task.ID, milestoneID); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetTask(task.ID)
+		if err != nil {
+			return err
+		}
+		return a.printTask(*format, *updated)
+	}
+
+	if milestone == nil {
+		fmt.Fprintf(a.stdout, "Task %d is not in a milestone: %s\n", task.ID, task.Title)
+		return nil
+	}
+	fmt.Fprintf(a.stdout, "Task %d is in milestone %d (%s): %s\n", task.ID, milestone.ID, milestone.Name, task.Title)
+	return nil
+}
+
+// taskState moves a task to a workflow state, or prints the states it has
+// been through when no state is given.
+func (a *App) taskState(args []string) error {
+	fs := a.newFlagSet("task state")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 1 || len(rest) > 2 {
+		return usagef("expected a task ID and an optional state")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+	if len(rest) == 1 {
+		history, err := a.svc.TaskStateHistory(task.ID)
+		if err != nil {
+			return err
+		}
+		if *format == formatJSON {
+			if history == nil {
+				history = []service.StateChange{}
+			}
+			return a.writeJSON(history)
+		}
+		header := []string{"state", "entered_at"}
+		if *format == formatTable {
+			header = []string{"state", "entered"}
+		}
+		t := table{header: header}
+		for _, c := range history {
+			t.rows = append(t.rows, []string{c.State, formatTime(*format, &c.EnteredAt)})
+		}
+		return a.writeTable(*format, t)
+	}
+
+	state := strings.TrimSpace(rest[1])
+	if err := a.svc.SetTaskState(task.ID, state, time.Now()); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetTask(task.ID)
+		if err != nil {
+			return err
+		}
+		return a.printTask(*format, *updated)
+	}
+	fmt.Fprintf(a.stdout, "Task %d is %s: %s\n", task.ID, state, task.Title)
+	return nil
+}
+
+// taskMove moves a task to a position in its project's manual order.
+func (a *App) taskMove(args []string) error {
+	fs := a.newFlagSet("task move")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 2 {
+		return usagef("expected a task ID and a position")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	var position int
+	switch rest[1] {
+	case "top":
+		position = 0
+	case "bottom":
+		position = math.MaxInt
+	default:
+		if position, err = strconv.Atoi(rest[1]); err != nil || position < 0 {
+			return usagef("invalid position %q, expected a number from 0, top or bottom", rest[1])
+		}
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+	if err := a.svc.MoveTask(task.ID, position); err != nil {
+		return err
+	}
+	updated, err := a.svc.GetTask(task.ID)
+	if err != nil {
+		return err
+	}
+	if *format != formatTable {
+		return a.printTask(*format, *updated)
+	}
+	fmt.Fprintf(a.stdout, "Moved task %d to position %d: %s\n", task.ID, updated.Position, task.Title)
+	return nil
+}
+
+// taskSetBlockers adds tasks to, or removes them from, the tasks blocking a
+// task.
+func (a *App) taskSetBlockers(args []string, block bool) error {
+	command := "task unblock"
+	if block {
+		command = "task block"
+	}
+	fs := a.newFlagSet(command)
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 2 {
+		return usagef("expected a task ID and the IDs of the tasks blocking it")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+	var blockers []*service.Task
+	for _, ref := range rest[1:] {
+		blocker, err := a.resolveTask(ref)
+		if err != nil {
+			return err
+		}
+		blockers = append(blockers, blocker)
+	}
+
+	ids := slices.Clone(task.BlockedBy)
+	for _, blocker := range blockers {
+		switch {
+		case block && !slices.Contains(ids, blocker.ID):
+			ids = append(ids, blocker.ID)
+		case !block && !slices.Contains(ids, blocker.ID):
+			return fmt.Errorf("task %d is not blocked by task %d", task.ID, blocker.ID)
+		case !block:
+			ids = slices.DeleteFunc(ids, func(id int) bool { return id == blocker.ID })
+		}
+	}
+	if err := a.svc.SetTaskBlockers(task.ID, ids); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		updated, err := a.svc.GetTask(task.ID)
+		if err != nil {
+			return err
+		}
+		return a.printTask(*format, *updated)
+	}
+
+	for _, blocker := range blockers {
+		if block {
+			fmt.Fprintf(a.stdout, "Task %d is blocked by task %d (%s): %s\n", task.ID, blocker.ID, blocker.Title, task.Title)
+		} else {
+			fmt.Fprintf(a.stdout, "Task %d is no longer blocked by task %d (%s): %s\n", task.ID, blocker.ID, blocker.Title, task.Title)
+		}
+	}
+	return nil
+}
+
+func (a *App) taskRemove(args []string) error {
+	fs := a.newFlagSet("task rm")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one task ID")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+
+	if err := a.svc.DeleteTask(task.ID); err != nil {
+		return err
+	}
+	if *format != formatTable {
+		return a.printTask(*format, *task)
+	}
+	fmt.Fprintf(a.stdout, "Deleted task %d: %s\n", task.ID, task.Title)
+	return nil
+}
+
+// resolveTask looks up a task by its numeric ID.
+func (a *App) resolveTask(ref string) (*service.Task, error) {
+	id, err := strconv.Atoi(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, usagef("invalid task ID %q", ref)
+	}
+	return a.svc.GetTask(id)
+}
+
+func validateTaskTitle(title string) error {
+	if title == "" {
+		return usagef("task title is required")
+	}
+	if utf8.RuneCountInString(title) > 100 {
+		return usagef("task title must be at most 100 characters")
+	}
+	return nil
+}
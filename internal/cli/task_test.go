@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+func TestTaskAddAndList(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+
+	out := app.run(t, 0, "task", "add", "web", "Write copy", "--desc", "Landing page")
+	if !strings.Contains(out, "Added task 1 to Website: Write copy") {
+		t.Errorf("unexpected add output: %q", out)
+	}
+	app.run(t, 0, "task", "add", "--desc", "Header", "Website", "Fix nav")
+
+	tasks, err := app.svc.ListProjectTasks(1)
+	if err != nil {
+		t.Fatalf("ListProjectTasks failed: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(tasks))
+	}
+	if tasks[0].Desc != "Landing page" || tasks[1].Desc != "Header" {
+		t.Errorf("expected descriptions to be stored, got %q and %q", tasks[0].Desc, tasks[1].Desc)
+	}
+
+	out = app.run(t, 0, "task", "ls", "Website")
+	if !strings.Contains(out, "Write copy") || !strings.Contains(out, "Fix nav") {
+		t.Errorf("expected both tasks, got %q", out)
+	}
+
+	app.run(t, 2, "task", "add", "Website")
+	app.run(t, 2, "task", "add", "Website", strings.Repeat("x", 101))
+	app.run(t, 0, "task", "add", "Website", strings.Repeat("é", 60))
+	app.run(t, 2, "task", "ls", "Website", "--all", "--done")
+	app.run(t, 1, "task", "add", "Nowhere", "Title")
+}
+
+func TestTaskDoneAndUndone(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "task", "add", "Website", "Write copy")
+	app.run(t, 0, "task", "add", "Website", "Fix nav")
+
+	app.run(t, 0, "task", "done", "1")
+	task, err := app.svc.GetTask(1)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.CompletedAt == nil {
+		t.Fatalf("expected task to be completed")
+	}
+
+	// Completing it again keeps the original completion time.
+	monday := time.Date(2025, 3, 10, 9, 0, 0, 0, time.Local)
+	if err := app.svc.UpdateTask(1, task.Title, task.Desc, &monday, nil); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	app.run(t, 0, "task", "done", "1")
+	if task, _ := app.svc.GetTask(1); task.CompletedAt == nil || !task.CompletedAt.Equal(monday) {
+		t.Errorf("expected the completion time to stay %v, got %v", monday, task.CompletedAt)
+	}
+
+	out := app.run(t, 0, "task", "ls", "Website")
+	if strings.Contains(out, "Write copy") || !strings.Contains(out, "Fix nav") {
+		t.Errorf("expected only pending tasks, got %q", out)
+	}
+	out = app.run(t, 0, "task", "ls", "Website", "--done")
+	if !strings.Contains(out, "Write copy") || strings.Contains(out, "Fix nav") {
+		t.Errorf("expected only completed tasks, got %q", out)
+	}
+	out = app.run(t, 0, "task", "ls", "Website", "--all")
+	if !strings.Contains(out, "Write copy") || !strings.Contains(out, "Fix nav") {
+		t.Errorf("expected all tasks, got %q", out)
+	}
+
+	app.run(t, 0, "task", "undone", "1")
+	task, err = app.svc.GetTask(1)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.CompletedAt != nil {
+		t.Errorf("expected task to be pending again")
+	}
+
+	app.run(t, 2, "task", "done", "abc")
+	app.run(t, 1, "task", "done", "42")
+}
+
+func TestTaskRemove(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "task", "add", "Website", "Write copy")
+
+	app.run(t, 0, "task", "rm", "1")
+	if _, err := app.svc.GetTask(1); err == nil {
+		t.Errorf("expected task to be deleted")
+	}
+	app.run(t, 1, "task", "rm", "1")
+}
+
+func TestTaskDue(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "task", "add", "Website", "Write copy", "--due", "2025-03-14")
+
+	task, err := app.svc.GetTask(1)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.DueAt == nil || task.DueAt.Format("2006-01-02") != "2025-03-14" {
+		t.Fatalf("expected task to be due on 2025-03-14, got %v", task.DueAt)
+	}
+	out := app.run(t, 0, "task", "ls", "Website")
+	if !strings.Contains(out, "2025-03-14") {
+		t.Errorf("expected the due date in the list, got %q", out)
+	}
+
+	out = app.run(t, 0, "task", "due", "1", "2025-04-01")
+	if !strings.Contains(out, "Task 1 is due 2025-04-01") {
+		t.Errorf("unexpected due output: %q", out)
+	}
+	out = app.run(t, 0, "task", "due", "1")
+	if !strings.Contains(out, "Cleared the due date of task 1") {
+		t.Errorf("unexpected clear output: %q", out)
+	}
+	if task, _ := app.svc.GetTask(1); task.DueAt != nil {
+		t.Errorf("expected the due date to be cleared, got %v", task.DueAt)
+	}
+
+	app.run(t, 2, "task", "add", "Website", "Fix nav", "--due", "someday")
+	app.run(t, 2, "task", "due", "1", "someday")
+	app.run(t, 1, "task", "due", "42", "today")
+}
+
+func TestTaskPriority(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "task", "add", "Website", "Write copy")
+	app.run(t, 0, "task", "add", "Website", "Fix nav", "--priority", "high")
+
+	task, err := app.svc.GetTask(2)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.Priority != service.PriorityHigh {
+		t.Errorf("expected high priority, got %v", task.Priority)
+	}
+
+	out := app.run(t, 0, "task", "priority", "1", "urgent")
+	if !strings.Contains(out, "Set the priority of task 1 to urgent") {
+		t.Errorf("unexpected priority output: %q", out)
+	}
+	out = app.run(t, 0, "task", "ls", "Website")
+	if strings.Index(out, "Write copy") > strings.Index(out, "Fix nav") || !strings.Contains(out, "urgent") {
+		t.Errorf("expected the urgent task first, got %q", out)
+	}
+
+	app.run(t, 2, "task", "add", "Website", "Pick fonts", "--priority", "critical")
+	app.run(t, 2, "task", "priority", "1")
+	app.run(t, 2, "task", "priority", "1", "critical")
+	app.run(t, 1, "task", "priority", "42", "low")
+}
+
+func TestTaskParent(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "project", "add", "Mobile")
+	app.run(t, 0, "task", "add", "Website", "Launch")
+	app.run(t, 0, "task", "add", "Website", "Write copy", "--parent", "1")
+	app.run(t, 0, "task", "add", "Mobile", "Ship beta")
+
+	task, err := app.svc.GetTask(2)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.ParentTaskID == nil || *task.ParentTaskID != 1 {
+		t.Errorf("expected Write copy to be a subtask of Launch, got %v", task.ParentTaskID)
+	}
+
+	out := app.run(t, 0, "task", "parent", "2")
+	if !strings.Contains(out, "Task 2 is a top-level task") {
+		t.Errorf("unexpected parent output: %q", out)
+	}
+	out = app.run(t, 0, "task", "parent", "1", "2")
+	if !strings.Contains(out, "Task 1 is a subtask of task 2 (Write copy)") {
+		t.Errorf("unexpected parent output: %q", out)
+	}
+
+	app.run(t, 1, "task", "parent", "2", "1")
+	app.run(t, 1, "task", "parent", "3", "1")
+	app.run(t, 1, "task", "add", "Mobile", "Test", "--parent", "1")
+	app.run(t, 2, "task", "parent")
+}
+
+func TestTaskMove(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	for _, title := range []string{"Launch", "Write copy", "Fix nav"} {
+		app.run(t, 0, "task", "add", "Website", title)
+	}
+
+	out := app.run(t, 0, "task", "move", "3", "top")
+	if !strings.Contains(out, "Moved task 3 to position 0: Fix nav") {
+		t.Errorf("unexpected move output: %q", out)
+	}
+	out = app.run(t, 0, "task", "move", "1", "bottom")
+	if !strings.Contains(out, "Moved task 1 to position 2: Launch") {
+		t.Errorf("unexpected move output: %q", out)
+	}
+	app.run(t, 0, "task", "move", "2", "0")
+
+	out = app.run(t, 0, "task", "ls", "Website")
+	if i, j, k := strings.Index(out, "Write copy"), strings.Index(out, "Fix nav"), strings.Index(out, "Launch"); i > j || j > k {
+		t.Errorf("expected Write copy, Fix nav, Launch, got %q", out)
+	}
+
+	app.run(t, 2, "task", "move", "1", "-1")
+	app.run(t, 2, "task", "move", "1", "up")
+	app.run(t, 2, "task", "move", "1")
+	app.run(t, 1, "task", "move", "9", "top")
+}
+
+func TestTaskBlock(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Website")
+	app.run(t, 0, "project", "add", "Mobile")
+	app.run(t, 0, "task", "add", "Website", "Design")
+	app.run(t, 0, "task", "add", "Website", "Build")
+	app.run(t, 0, "task", "add", "Website", "Launch")
+	app.run(t, 0, "task", "add", "Mobile", "Ship beta")
+
+	out := app.run(t, 0, "task", "block", "3", "1", "2")
+	if !strings.Contains(out, "Task 3 is blocked by task 1 (Design): Launch") ||
+		!strings.Contains(out, "Task 3 is blocked by task 2 (Build): Launch") {
+		t.Errorf("unexpected block output: %q", out)
+	}
+	out = app.run(t, 0, "task", "ls", "Website", "--format", "csv")
+	if !strings.Contains(out, `3,1,Launch,,,`) || !strings.Contains(out, `"1,2"`) {
+		t.Errorf("expected the blockers in the CSV output, got %q", out)
+	}
+
+	app.run(t, 1, "task", "block", "1", "3")
+	app.run(t, 1, "task", "block", "1", "4")
+	app.run(t, 1, "task", "unblock", "2", "1")
+
+	out = app.run(t, 0, "task", "unblock", "3", "1")
+	if !strings.Contains(out, "Task 3 is no longer blocked by task 1 (Design)") {
+		t.Errorf("unexpected unblock output: %q", out)
+	}
+	task, err := app.svc.GetTask(3)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if len(task.BlockedBy) != 1 || task.BlockedBy[0] != 2 {
+		t.Errorf("expected Launch to be blocked by Build only, got %v", task.BlockedBy)
+	}
+	app.run(t, 2, "task", "block", "3")
+}
+
+func TestTaskRepeat(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Ops")
+	app.run(t, 0, "task", "add", "Ops", "Update dependencies", "--repeat", "every 14d")
+	app.run(t, 0, "task", "add", "Ops", "Rotate API keys", "--due", "today")
+
+	out := app.run(t, 0, "task", "repeat", "2", "weekly", "mon,thu")
+	if !strings.Contains(out, "Task 2 repeats every week on Mon, Thu: Rotate API keys") {
+		t.Errorf("unexpected repeat output: %q", out)
+	}
+	app.run(t, 2, "task", "repeat", "2", "fortnightly")
+	app.run(t, 2, "task", "add", "Ops", "Backups", "--repeat", "hourly")
+
+	out = app.run(t, 0, "task", "done", "1")
+	want := "next due " + time.Now().AddDate(0, 0, 14).Format(service.DueDateLayout)
+	if !strings.Contains(out, "It repeats 14 days after completion, "+want) {
+		t.Errorf("expected the next occurrence in the done output, got %q", out)
+	}
+	out = app.run(t, 0, "task", "ls", "Ops")
+	if !strings.Contains(out, "every 14d") || !strings.Contains(out, "weekly mon,thu") {
+		t.Errorf("expected the rules in the task list, got %q", out)
+	}
+
+	out = app.run(t, 0, "task", "repeat", "2")
+	if !strings.Contains(out, "Task 2 no longer repeats") {
+		t.Errorf("unexpected repeat output: %q", out)
+	}
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+const timerUsage = `Usage: addae timer <command> [arguments]
+
+Commands:
+  start  <task-id>                           Start timing a task
+  stop                                       Stop the running timer
+  status                                     Show the running timer
+  report <project>                           Show the time spent on a project's tasks
+
+status and report accept --format table|json|csv|markdown. Only one timer
+runs at a time: starting one stops the timer of any other task. In csv and
+markdown, report gives time in decimal hours, ready for invoicing.
+
+<project> is a project ID or a unique prefix of its name.`
+
+func (a *App) runTimer(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, timerUsage)
+		return usagef("missing timer command")
+	}
+
+	switch args[0] {
+	case "start":
+		return a.timerStart(args[1:])
+	case "stop":
+		return a.timerStop(args[1:])
+	case "status":
+		return a.timerStatus(args[1:])
+	case "report":
+		return a.timerReport(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stdout, timerUsage)
+		return nil
+	default:
+		fmt.Fprintln(a.stderr, timerUsage)
+		return usagef("unknown timer command %q", args[0])
+	}
+}
+
+func (a *App) timerStart(args []string) error {
+	fs := a.newFlagSet("timer start")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one task ID")
+	}
+
+	task, err := a.resolveTask(rest[0])
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	stopped, err := a.svc.StartTimer(task.ID, now)
+	if err != nil {
+		return err
+	}
+	if stopped != nil {
+		a.printStopped(*stopped, now)
+	}
+	fmt.Fprintf(a.stdout, "Started timer on task %d: %s\n", task.ID, task.Title)
+	return nil
+}
+
+func (a *App) timerStop(args []string) error {
+	fs := a.newFlagSet("timer stop")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+
+	now := time.Now()
+	stopped, err := a.svc.StopTimer(now)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			return errors.New("no timer is running")
+		}
+		return err
+	}
+	a.printStopped(*stopped, now)
+	return nil
+}
+
+func (a *App) printStopped(e service.TimeEntry, now time.Time) {
+	fmt.Fprintf(a.stdout, "Stopped timer on task %d after %s: %s\n",
+		e.TaskID, service.FormatDuration(e.Duration(now)), e.TaskTitle)
+}
+
+func (a *App) timerStatus(args []string) error {
+	fs := a.newFlagSet("timer status")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	running, err := a.svc.RunningTimer()
+	if err != nil {
+		return err
+	}
+	if *format == formatJSON {
+		return a.writeJSON(running)
+	}
+	if *format == formatTable {
+		if running == nil {
+			fmt.Fprintln(a.stdout, "No timer is running")
+			return nil
+		}
+		fmt.Fprintf(a.stdout, "Timing task %d (%s) in %s for %s\n", running.TaskID, running.TaskTitle,
+			running.ProjectName, service.FormatDuration(running.Duration(time.Now())))
+		return nil
+	}
+
+	t := table{header: []string{"task_id", "title", "project_id", "project", "started_at"}}
+	if running != nil {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(running.TaskID), running.TaskTitle, strconv.Itoa(running.ProjectID), running.ProjectName,
+			formatTime(*format, &running.StartedAt),
+		})
+	}
+	return a.writeTable(*format, t)
+}
+
+// taskTime is the time spent on a task, as timerReport prints it in JSON.
+type taskTime struct {
+	TaskID  int    `json:"task_id"`
+	Title   string `json:"title"`
+	Seconds int64  `json:"seconds"`
+}
+
+// timerReport prints the time spent on each task of a project, counting a
+// running timer up to now.
+func (a *App) timerReport(args []string) error {
+	fs := a.newFlagSet("timer report")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one project")
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	p, err := a.resolveProject(rest[0])
+	if err != nil {
+		return err
+	}
+	entries, err := a.svc.ListTimeEntries(p.ID)
+	if err != nil {
+		return err
+	}
+
+	// Tasks are listed in the order work on them started.
+	now := time.Now()
+	spent := service.TimeSpent(entries, now)
+	report := []taskTime{}
+	seen := make(map[int]bool)
+	var total time.Duration
+	for _, e := range entries {
+		if !seen[e.TaskID] {
+			seen[e.TaskID] = true
+			report = append(report, taskTime{TaskID: e.TaskID, Title: e.TaskTitle, Seconds: int64(spent[e.TaskID] / time.Second)})
+			total += spent[e.TaskID]
+		}
+	}
+	if *format == formatJSON {
+		return a.writeJSON(report)
+	}
+
+	var t table
+	if *format == formatTable {
+		t.header = []string{"id", "task", "time"}
+		for _, r := range report {
+			t.rows = append(t.rows, []string{strconv.Itoa(r.TaskID), r.Title, service.FormatDuration(spent[r.TaskID])})
+		}
+		t.rows = append(t.rows, []string{"", "total", service.FormatDuration(total)})
+		return a.writeTable(*format, t)
+	}
+
+	t.header = []string{"task_id", "title", "hours"}
+	for _, r := range report {
+		t.rows = append(t.rows, []string{strconv.Itoa(r.TaskID), r.Title, formatHours(spent[r.TaskID])})
+	}
+	return a.writeTable(*format, t)
+}
+
+// formatHours prints a duration in decimal hours, as in "1.75".
+func formatHours(d time.Duration) string {
+	return strconv.FormatFloat(d.Hours(), 'f', 2, 64)
+}
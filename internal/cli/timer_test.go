@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+func TestTimerCommands(t *testing.T) {
+	app := setupTestApp(t)
+	app.run(t, 0, "project", "add", "Client site")
+	app.run(t, 0, "task", "add", "Client", "Design")
+	app.run(t, 0, "task", "add", "Client", "Build")
+
+	out := app.run(t, 0, "timer", "status")
+	if !strings.Contains(out, "No timer is running") {
+		t.Errorf("unexpected status output: %q", out)
+	}
+	app.run(t, 1, "timer", "stop")
+	app.run(t, 1, "timer", "start", "99")
+	app.run(t, 2, "timer", "start")
+
+	out = app.run(t, 0, "timer", "start", "1")
+	if !strings.Contains(out, "Started timer on task 1: Design") {
+		t.Errorf("unexpected start output: %q", out)
+	}
+	out = app.run(t, 0, "timer", "status")
+	if !strings.Contains(out, "Timing task 1 (Design) in Client site for 0m") {
+		t.Errorf("unexpected status output: %q", out)
+	}
+
+	// Starting another timer stops the running one.
+	out = app.run(t, 0, "timer", "start", "2")
+	if !strings.Contains(out, "Stopped timer on task 1 after 0m: Design") || !strings.Contains(out, "Started timer on task 2: Build") {
+		t.Errorf("expected the design timer to stop, got %q", out)
+	}
+	out = app.run(t, 0, "timer", "status", "--format", "json")
+	var running service.TimeEntry
+	if err := json.Unmarshal([]byte(out), &running); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if running.TaskID != 2 || running.ProjectName != "Client site" || running.EndedAt != nil {
+		t.Errorf("expected the build timer running, got %+v", running)
+	}
+
+	out = app.run(t, 0, "timer", "stop")
+	if !strings.Contains(out, "Stopped timer on task 2 after 0m: Build") {
+		t.Errorf("unexpected stop output: %q", out)
+	}
+
+	// Backdate an entry to give the report something to add up.
+	start := time.Now().Add(-3 * time.Hour)
+	if _, err := app.svc.StartTimer(1, start); err != nil {
+		t.Fatalf("StartTimer failed: %v", err)
+	}
+	if _, err := app.svc.StopTimer(start.Add(105 * time.Minute)); err != nil {
+		t.Fatalf("StopTimer failed: %v", err)
+	}
+
+	out = app.run(t, 0, "timer", "report", "Client")
+	for _, want := range []string{"Design", "1h 45m", "Build", "total"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the report, got %q", want, out)
+		}
+	}
+	out = app.run(t, 0, "timer", "report", "Client", "--format", "csv")
+	if !strings.Contains(out, "task_id,title,hours\n1,Design,1.75\n2,Build,0.00\n") {
+		t.Errorf("unexpected csv report: %q", out)
+	}
+	out = app.run(t, 0, "timer", "report", "Client", "--format", "json")
+	var report []taskTime
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(report) != 2 || report[0].Seconds != 105*60 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/quamejnr/addae/internal/db"
+	"github.com/quamejnr/addae/internal/workspace"
+)
+
+const workspaceUsage = `Usage: addae workspace <command> [arguments]
+
+Commands:
+  list                  List workspaces, marking the default with *
+  create <name>         Create a workspace with its own database
+  use    <name>         Make a workspace the default
+  rm     <name> --force Delete a workspace and its database
+
+Open a workspace once with "addae --workspace <name>". "addae --db <path>" and
+$ADDAE_DB open a database file directly and take precedence over workspaces.`
+
+func (a *App) runWorkspace(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, workspaceUsage)
+		return usagef("missing workspace command")
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		return a.workspaceList(args[1:])
+	case "create", "add":
+		return a.workspaceCreate(args[1:])
+	case "use":
+		return a.workspaceUse(args[1:])
+	case "rm", "delete":
+		return a.workspaceRemove(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stdout, workspaceUsage)
+		return nil
+	default:
+		fmt.Fprintln(a.stderr, workspaceUsage)
+		return usagef("unknown workspace command %q", args[0])
+	}
+}
+
+func (a *App) workspaceList(args []string) error {
+	fs := a.newFlagSet("workspace list")
+	format := formatFlag(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usagef("unexpected argument %q", rest[0])
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	workspaces, err := a.store.List()
+	if err != nil {
+		return err
+	}
+	if *format == formatJSON {
+		return a.writeJSON(workspaces)
+	}
+
+	t := table{header: []string{"default", "name", "path"}}
+	for _, w := range workspaces {
+		mark := ""
+		if w.Default {
+			mark = "*"
+			if *format != formatTable {
+				mark = "true"
+			}
+		} else if *format != formatTable {
+			mark = "false"
+		}
+		t.rows = append(t.rows, []string{mark, w.Name, w.Path})
+	}
+	return a.writeTable(*format, t)
+}
+
+func (a *App) workspaceCreate(args []string) error {
+	fs := a.newFlagSet("workspace create")
+	use := fs.Bool("use", false, "also make the new workspace the default")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one workspace name")
+	}
+	name := rest[0]
+	if err := workspace.ValidateName(name); err != nil {
+		return usageError{msg: err.Error()}
+	}
+
+	path, err := a.store.Create(name)
+	if err != nil {
+		return err
+	}
+
+	// Apply the schema right away so the workspace is ready to use.
+	database, err := db.InitDB(path)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+	if a.opts.Migrations != nil {
+		if err := db.RunMigrationsFromFS(database, a.opts.Migrations); err != nil {
+			return err
+		}
+	}
+
+	if *use {
+		if err := a.store.Use(name); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(a.stdout, "Created workspace %s at %s\n", name, path)
+	return nil
+}
+
+func (a *App) workspaceUse(args []string) error {
+	fs := a.newFlagSet("workspace use")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one workspace name")
+	}
+
+	if err := a.store.Use(rest[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Default workspace is now %s\n", rest[0])
+	return nil
+}
+
+func (a *App) workspaceRemove(args []string) error {
+	fs := a.newFlagSet("workspace rm")
+	force := fs.Bool("force", false, "confirm deleting the workspace database")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("expected exactly one workspace name")
+	}
+	if !*force {
+		return usagef("this deletes every project in %q, pass --force to confirm", rest[0])
+	}
+
+	if err := a.store.Remove(rest[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Removed workspace %s\n", rest[0])
+	return nil
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/quamejnr/addae/internal/workspace"
+)
+
+// setupWorkspaceApp returns an App that opens databases from a temporary store.
+func setupWorkspaceApp(t *testing.T, opts Options) *testApp {
+	t.Helper()
+	t.Setenv("ADDAE_DB", "")
+	if opts.Migrations == nil {
+		opts.Migrations = os.DirFS("../db/migrations")
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	app := &App{
+		opts:   opts,
+		store:  workspace.NewStore(t.TempDir()),
+		stdin:  strings.NewReader(""),
+		stdout: stdout,
+		stderr: stderr,
+	}
+	return &testApp{App: app, stdout: stdout, stderr: stderr}
+}
+
+func TestWorkspaceCommands(t *testing.T) {
+	app := setupWorkspaceApp(t, Options{})
+
+	app.run(t, 0, "workspace", "create", "work")
+	app.run(t, 1, "workspace", "create", "work")
+	app.run(t, 2, "workspace", "create", "../escape")
+
+	out := app.run(t, 0, "workspace", "list")
+	if !strings.Contains(out, "* ") || !strings.Contains(out, "work") {
+		t.Errorf("unexpected list output: %q", out)
+	}
+
+	app.run(t, 0, "workspace", "use", "work")
+	if current, _ := app.store.Current(); current != "work" {
+		t.Errorf("expected work to be the default, got %q", current)
+	}
+
+	app.run(t, 1, "workspace", "rm", "work", "--force")
+	app.run(t, 0, "workspace", "use", "default")
+	app.run(t, 2, "workspace", "rm", "work")
+	app.run(t, 0, "workspace", "rm", "work", "--force")
+	if app.store.Exists("work") {
+		t.Errorf("expected workspace to be removed")
+	}
+}
+
+func TestWorkspacesAreSeparate(t *testing.T) {
+	app := setupWorkspaceApp(t, Options{})
+	app.run(t, 0, "workspace", "create", "work")
+
+	// The persisted default workspace gets the first project.
+	app.run(t, 0, "project", "add", "Personal")
+
+	// --workspace selects another database for a single run.
+	app.opts.Workspace = "work"
+	app.run(t, 0, "project", "add", "Client")
+	out := app.run(t, 0, "project", "list")
+	if !strings.Contains(out, "Client") || strings.Contains(out, "Personal") {
+		t.Errorf("expected only work projects, got %q", out)
+	}
+
+	// Persisting the default switches subsequent runs.
+	app.opts.Workspace = ""
+	app.run(t, 0, "workspace", "use", "work")
+	out = app.run(t, 0, "project", "list")
+	if !strings.Contains(out, "Client") || strings.Contains(out, "Personal") {
+		t.Errorf("expected only work projects, got %q", out)
+	}
+
+	app.opts.Workspace = "missing"
+	app.run(t, 1, "project", "list")
+}
+
+func TestDBPathPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	app := setupWorkspaceApp(t, Options{Workspace: "missing"})
+
+	// $ADDAE_DB wins over --workspace.
+	t.Setenv("ADDAE_DB", dir+"/env.db")
+	app.run(t, 0, "project", "add", "From env")
+
+	// --db wins over $ADDAE_DB.
+	app.opts.DBPath = dir + "/flag.db"
+	out := app.run(t, 0, "project", "list")
+	if strings.Contains(out, "From env") {
+		t.Errorf("expected --db to take precedence over $ADDAE_DB, got %q", out)
+	}
+
+	app.opts.DBPath = ""
+	out = app.run(t, 0, "project", "list")
+	if !strings.Contains(out, "From env") {
+		t.Errorf("expected $ADDAE_DB database, got %q", out)
+	}
+}
@@ -1,16 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"io"
 	"io/fs"
-	"log"
 	"os"
 	"path/filepath"
 
-	"github.com/pressly/goose/v3"
-
 	_ "modernc.org/sqlite"
 )
 
@@ -25,8 +22,11 @@ func InitDB(dbPath string) (*sql.DB, error) {
 		}
 	}
 
-	// Construct the DSN to automatically create the file and enable foreign keys
-	dsn := fmt.Sprintf("file:%s?_journal_mode=WAL&_foreign_keys=ON", dbPath)
+	// Construct the DSN to automatically create the file and enable foreign keys.
+	// modernc.org/sqlite only understands _pragma parameters, and runs them on
+	// every new connection in the pool. busy_timeout lets concurrent writers,
+	// such as addae serve and the TUI, wait for each other instead of failing.
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", dbPath)
 
 	// Open the database connection
 	db, err := sql.Open("sqlite", dsn)
@@ -65,33 +65,24 @@ func getDefaultDBPath() (string, error) {
 	return filepath.Join(appDir, "addae.db"), nil
 }
 
+// RunMigrations applies every pending migration found in migrationsDir.
 func RunMigrations(db *sql.DB, migrationsDir string) error {
-	// goose.SetBaseFS(embedMigrations)
+	return RunMigrationsFromFS(db, os.DirFS(migrationsDir))
+}
 
-	if err := goose.SetDialect("sqlite"); err != nil {
+// RunMigrationsFromFS applies every pending migration found at the root of
+// migrationsFS, unless a rollback pinned the database at an older version.
+func RunMigrationsFromFS(db *sql.DB, migrationsFS fs.FS) error {
+	ctx := context.Background()
+	if _, pinned, err := PinnedVersion(ctx, db); err != nil || pinned {
+		return err
+	}
+	m, err := NewMigrator(db, migrationsFS)
+	if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
-
-	// temporarily disabling logs in the goose.Up function after migration
-	log.SetOutput(io.Discard)
-
-	if err := goose.Up(db, migrationsDir); err != nil {
+	if _, err := m.Up(ctx); err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
-
-	// restore logs to stdout
-	log.SetOutput(os.Stdout)
 	return nil
 }
-
-func RunMigrationsFromFS(db *sql.DB, migrationsFS fs.FS) error {
-	if err := goose.SetDialect("sqlite"); err != nil {
-		return err
-	}
-
-	// temporarily disabling logs in the goose.Up function after migration
-	log.SetOutput(io.Discard)
-
-	goose.SetBaseFS(migrationsFS)
-	return goose.Up(db, ".")
-}
@@ -2,8 +2,12 @@
 package db
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"log"
 	"os"
+	"strings"
 	"testing"
 
 	_ "modernc.org/sqlite"
@@ -64,3 +68,91 @@ DROP TABLE projects;
 		t.Errorf("failed to query projects table: %v", err)
 	}
 }
+
+func TestRunMigrationsKeepsLogger(t *testing.T) {
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(prev)
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	defer db.Close()
+
+	if err := RunMigrations(db, "migrations"); err != nil {
+		t.Fatalf("RunMigrations failed: %v", err)
+	}
+	if log.Writer() != &buf {
+		t.Errorf("expected RunMigrations to leave the standard logger alone")
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	ctx := context.Background()
+	m, err := NewMigrator(db, os.DirFS("migrations"))
+	if err != nil {
+		t.Fatalf("NewMigrator failed: %v", err)
+	}
+
+	steps, err := m.Plan(ctx, "up", 0)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(steps) < 2 || !steps[0].Up || steps[0].Version != 20250204221253 {
+		t.Fatalf("unexpected plan: %+v", steps)
+	}
+	stmts, err := m.SQL(steps[1])
+	if err != nil {
+		t.Fatalf("SQL failed: %v", err)
+	}
+	if !strings.HasPrefix(stmts, "ALTER TABLE tasks ADD COLUMN completed_at TIMESTAMP;") || strings.Contains(stmts, "status TEXT") {
+		t.Errorf("expected only the up section, got %q", stmts)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if _, err := m.Redo(ctx); err != nil {
+		t.Fatalf("Redo failed: %v", err)
+	}
+	if _, err := m.DownTo(ctx, 0); err != nil {
+		t.Fatalf("DownTo failed: %v", err)
+	}
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	if version != 0 {
+		t.Errorf("expected every migration to be rolled back, got version %d", version)
+	}
+	if _, err := m.Down(ctx); err == nil {
+		t.Errorf("expected error rolling back an empty database")
+	}
+}
+
+func TestInitDBEnforcesForeignKeys(t *testing.T) {
+	db, err := InitDB(t.TempDir() + "/addae.db")
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	defer db.Close()
+
+	checks, err := Diagnose(context.Background(), db, nil)
+	if err != nil {
+		t.Fatalf("Diagnose failed: %v", err)
+	}
+	for _, c := range checks {
+		if c.Name == "foreign-keys" && c.Severity != SeverityOK {
+			t.Errorf("expected foreign keys to be enforced, got %q", c.Message)
+		}
+	}
+}
@@ -0,0 +1,494 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+// completedAtMigration is the migration that replaced tasks.status with
+// tasks.completed_at. It copies completion from tasks with status 'complete',
+// a value the schema never allowed, so every task that existed when it ran
+// came out open. It has shipped and is left as it is; new databases have no
+// tasks when it runs, so only databases created before it are affected.
+const completedAtMigration = 20250720174907
+
+// recoveredProject names the project that --fix moves orphaned items into.
+const recoveredProject = "Recovered"
+
+// Severity of a single diagnostic check.
+type Severity string
+
+const (
+	SeverityOK Severity = "ok"
+	// SeverityInfo reports something worth a look that may be fine.
+	SeverityInfo Severity = "info"
+	SeverityWarn Severity = "warn"
+	SeverityFail Severity = "fail"
+)
+
+// Check is the outcome of one diagnostic check.
+type Check struct {
+	Name     string   `json:"name"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// Fixable checks can be repaired by Repair.
+	Fixable bool `json:"fixable"`
+}
+
+// Diagnose inspects db for corruption, broken references and schema drift.
+// migrationsFS holds the migrations shipped with the binary; when it is nil
+// the migration check is skipped.
+func Diagnose(ctx context.Context, db *sql.DB, migrationsFS fs.FS) ([]Check, error) {
+	checks := []func(context.Context, *sql.DB) (Check, error){
+		checkIntegrity,
+		checkForeignKeysEnforced,
+		checkForeignKeyViolations,
+		checkOrphans,
+		checkDanglingReferences,
+		checkCompletedAtMigration,
+	}
+
+	var report []Check
+	for _, check := range checks {
+		c, err := check(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, c)
+	}
+
+	if migrationsFS != nil {
+		c, err := checkMigrations(ctx, db, migrationsFS)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, c)
+	}
+	return report, nil
+}
+
+func checkIntegrity(ctx context.Context, db *sql.DB) (Check, error) {
+	c := Check{Name: "integrity", Severity: SeverityOK, Message: "database file is intact"}
+
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return c, fmt.Errorf("integrity check failed: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return c, err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return c, err
+	}
+
+	if len(problems) > 0 {
+		c.Severity = SeverityFail
+		c.Message = fmt.Sprintf("%d problems, restore from a backup: %s", len(problems), strings.Join(problems, "; "))
+	}
+	return c, nil
+}
+
+func checkForeignKeysEnforced(ctx context.Context, db *sql.DB) (Check, error) {
+	c := Check{Name: "foreign-keys", Severity: SeverityOK, Message: "enforced on this connection"}
+
+	var enabled int
+	if err := db.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+		return c, fmt.Errorf("failed to read foreign_keys pragma: %w", err)
+	}
+	if enabled != 1 {
+		c.Severity = SeverityFail
+		c.Message = "not enforced, deleting a project leaves its tasks and logs behind"
+	}
+	return c, nil
+}
+
+func checkForeignKeyViolations(ctx context.Context, db *sql.DB) (Check, error) {
+	c := Check{Name: "foreign-key-check", Severity: SeverityOK, Message: "no broken references"}
+
+	rows, err := db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return c, fmt.Errorf("foreign key check failed: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	var tables []string
+	// Repair only re-homes tasks and logs whose project is missing.
+	fixable := true
+	for rows.Next() {
+		var table, parent string
+		var rowid, fkid sql.NullInt64
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return c, err
+		}
+		if counts[table] == 0 {
+			tables = append(tables, table)
+		}
+		counts[table]++
+		if parent != "projects" || (table != "tasks" && table != "logs") {
+			fixable = false
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return c, err
+	}
+
+	if len(tables) > 0 {
+		parts := make([]string, len(tables))
+		for i, table := range tables {
+			parts[i] = fmt.Sprintf("%d in %s", counts[table], table)
+		}
+		c.Severity = SeverityFail
+		c.Message = "rows reference missing parents: " + strings.Join(parts, ", ")
+		c.Fixable = fixable
+	}
+	return c, nil
+}
+
+func tableExists(ctx context.Context, db *sql.DB, name string) (bool, error) {
+	var n int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to read schema: %w", err)
+	}
+	return n > 0, nil
+}
+
+// orphanCondition matches rows that belong to no project.
+const orphanCondition = "project_id IS NULL OR project_id NOT IN (SELECT id FROM projects)"
+
+func checkOrphans(ctx context.Context, db *sql.DB) (Check, error) {
+	c := Check{Name: "orphans", Severity: SeverityOK, Message: "every task and log belongs to a project"}
+
+	if ok, err := tableExists(ctx, db, "tasks"); err != nil || !ok {
+		c.Message = "schema not created yet, run: addae migrate up"
+		return c, err
+	}
+
+	var tasks, logs int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE "+orphanCondition).Scan(&tasks); err != nil {
+		return c, fmt.Errorf("failed to count orphaned tasks: %w", err)
+	}
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM logs WHERE "+orphanCondition).Scan(&logs); err != nil {
+		return c, fmt.Errorf("failed to count orphaned logs: %w", err)
+	}
+
+	if tasks+logs > 0 {
+		c.Severity = SeverityFail
+		c.Message = fmt.Sprintf("%d tasks and %d logs belong to no project", tasks, logs)
+		c.Fixable = true
+	}
+	return c, nil
+}
+
+// danglingReferences are the columns that point at another row without a
+// foreign key, because SQLite cannot add one to an existing table. Clearing
+// one leaves the task outside a parent or milestone, or the project at the
+// top level.
+var danglingReferences = []struct {
+	table, column, parent string
+}{
+	{"tasks", "parent_task_id", "tasks"},
+	{"tasks", "milestone_id", "milestones"},
+	{"projects", "parent_id", "projects"},
+}
+
+// danglingCondition matches the rows whose column names a missing parent.
+func danglingCondition(column, parent string) string {
+	return column + " IS NOT NULL AND " + column + " NOT IN (SELECT id FROM " + parent + ")"
+}
+
+func columnExists(ctx context.Context, db *sql.DB, table, column string) (bool, error) {
+	var n int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to read schema: %w", err)
+	}
+	return n > 0, nil
+}
+
+// countDanglingReferences counts the dangling rows of each reference whose
+// column exists, keyed by table.column.
+func countDanglingReferences(ctx context.Context, db *sql.DB) (map[string]int, []string, error) {
+	counts := make(map[string]int)
+	var refs []string
+	for _, ref := range danglingReferences {
+		if ok, err := columnExists(ctx, db, ref.table, ref.column); err != nil || !ok {
+			if err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		var n int
+		err := db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM "+ref.table+" WHERE "+danglingCondition(ref.column, ref.parent)).Scan(&n)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to count dangling %s.%s: %w", ref.table, ref.column, err)
+		}
+		if n > 0 {
+			name := ref.table + "." + ref.column
+			counts[name] = n
+			refs = append(refs, name)
+		}
+	}
+	return counts, refs, nil
+}
+
+func checkDanglingReferences(ctx context.Context, db *sql.DB) (Check, error) {
+	c := Check{Name: "references", Severity: SeverityOK, Message: "every parent task, milestone and parent project exists"}
+
+	counts, refs, err := countDanglingReferences(ctx, db)
+	if err != nil {
+		return c, err
+	}
+	if len(refs) > 0 {
+		parts := make([]string, len(refs))
+		for i, ref := range refs {
+			parts[i] = fmt.Sprintf("%d in %s", counts[ref], ref)
+		}
+		c.Severity = SeverityFail
+		c.Message = "rows point at missing rows: " + strings.Join(parts, ", ")
+		c.Fixable = true
+	}
+	return c, nil
+}
+
+// checkCompletedAtMigration counts the open tasks that existed when the
+// completed_at migration ran. The migration dropped the column that said which
+// of them were completed, and date_updated is bumped by later migrations, so
+// they cannot be told apart from tasks that really are open. The check is only
+// informational.
+func checkCompletedAtMigration(ctx context.Context, db *sql.DB) (Check, error) {
+	c := Check{Name: "completed-at", Severity: SeverityOK, Message: "no open tasks predate the completed_at migration"}
+
+	if ok, err := tableExists(ctx, db, "goose_db_version"); err != nil || !ok {
+		c.Message = "no migrations applied"
+		return c, err
+	}
+
+	var appliedAt sql.NullString
+	err := db.QueryRowContext(ctx, `
+		SELECT MIN(tstamp) FROM goose_db_version WHERE version_id = ? AND is_applied = 1`,
+		completedAtMigration).Scan(&appliedAt)
+	if err != nil {
+		return c, fmt.Errorf("failed to read migration history: %w", err)
+	}
+	if !appliedAt.Valid {
+		c.Message = "migration not applied yet"
+		return c, nil
+	}
+
+	var open int
+	err = db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM tasks WHERE completed_at IS NULL AND date_created <= ?`,
+		appliedAt.String).Scan(&open)
+	if err != nil {
+		return c, fmt.Errorf("failed to count affected tasks: %w", err)
+	}
+
+	if open > 0 {
+		c.Severity = SeverityInfo
+		c.Message = fmt.Sprintf(
+			"%d tasks created before the completed_at migration ran on %s are open; "+
+				"the migration may have reopened some that were completed, but doctor cannot tell those "+
+				"from tasks that really are open, so review them by hand with: addae task ls <project>",
+			open, appliedAt.String)
+	}
+	return c, nil
+}
+
+func checkMigrations(ctx context.Context, db *sql.DB, migrationsFS fs.FS) (Check, error) {
+	c := Check{Name: "migrations", Severity: SeverityOK}
+
+	m, err := NewMigrator(db, migrationsFS)
+	if err != nil {
+		return c, err
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return c, err
+	}
+	version, err := m.Version(ctx)
+	if err != nil {
+		return c, err
+	}
+
+	_, pinned, err := PinnedVersion(ctx, db)
+	if err != nil {
+		return c, err
+	}
+
+	var latest int64
+	pending := 0
+	for _, s := range statuses {
+		latest = max(latest, s.Source.Version)
+		if s.State != goose.StateApplied {
+			pending++
+		}
+	}
+
+	switch {
+	case version > latest:
+		c.Severity = SeverityFail
+		c.Message = fmt.Sprintf("database version %d is newer than this build (%d), upgrade addae", version, latest)
+	case pending > 0 && pinned:
+		c.Severity = SeverityInfo
+		c.Message = fmt.Sprintf("database version %d, %d of %d migrations pending since a rollback, apply them with: addae migrate up",
+			version, pending, len(statuses))
+	case pending > 0:
+		c.Severity = SeverityWarn
+		c.Message = fmt.Sprintf("database version %d, %d of %d migrations pending", version, pending, len(statuses))
+		c.Fixable = true
+	default:
+		c.Message = fmt.Sprintf("database version %d is up to date", version)
+	}
+	return c, nil
+}
+
+// Backup writes a consistent copy of db to path.
+func Backup(ctx context.Context, db *sql.DB, path string) error {
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+	return nil
+}
+
+// Repair fixes the problems Diagnose marks as fixable and describes what it
+// changed. Orphaned tasks and logs are moved into a project named Recovered
+// rather than deleted, references to missing parent tasks, milestones and
+// parent projects are cleared, and pending migrations are applied unless a
+// rollback left them pending. Tasks reopened by the completed_at migration are not
+// touched: nothing records which of them were completed.
+func Repair(ctx context.Context, db *sql.DB, migrationsFS fs.FS) ([]string, error) {
+	var done []string
+
+	_, pinned, err := PinnedVersion(ctx, db)
+	if err != nil {
+		return done, err
+	}
+	if migrationsFS != nil && !pinned {
+		m, err := NewMigrator(db, migrationsFS)
+		if err != nil {
+			return done, err
+		}
+		results, err := m.Up(ctx)
+		if err != nil {
+			return done, fmt.Errorf("migration failed: %w", err)
+		}
+		if len(results) > 0 {
+			done = append(done, fmt.Sprintf("applied %d pending migrations", len(results)))
+		}
+	}
+
+	moved, err := recoverOrphans(ctx, db)
+	if err != nil {
+		return done, err
+	}
+	if moved != "" {
+		done = append(done, moved)
+	}
+
+	cleared, err := clearDanglingReferences(ctx, db)
+	if err != nil {
+		return done, err
+	}
+	if cleared != "" {
+		done = append(done, cleared)
+	}
+	return done, nil
+}
+
+// clearDanglingReferences sets the dangling references to NULL.
+func clearDanglingReferences(ctx context.Context, db *sql.DB) (string, error) {
+	counts, refs, err := countDanglingReferences(ctx, db)
+	if err != nil || len(refs) == 0 {
+		return "", err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	cleared := 0
+	for _, ref := range danglingReferences {
+		name := ref.table + "." + ref.column
+		if counts[name] == 0 {
+			continue
+		}
+		_, err := tx.ExecContext(ctx,
+			"UPDATE "+ref.table+" SET "+ref.column+" = NULL WHERE "+danglingCondition(ref.column, ref.parent))
+		if err != nil {
+			return "", fmt.Errorf("failed to clear %s: %w", name, err)
+		}
+		cleared += counts[name]
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("cleared %d references to missing tasks, milestones or projects (%s)",
+		cleared, strings.Join(refs, ", ")), nil
+}
+
+func recoverOrphans(ctx context.Context, db *sql.DB) (string, error) {
+	if ok, err := tableExists(ctx, db, "tasks"); err != nil || !ok {
+		return "", err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var orphans int
+	err = tx.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM tasks WHERE `+orphanCondition+`)
+		     + (SELECT COUNT(*) FROM logs WHERE `+orphanCondition+`)`).Scan(&orphans)
+	if err != nil {
+		return "", fmt.Errorf("failed to count orphans: %w", err)
+	}
+	if orphans == 0 {
+		return "", nil
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO projects (name, summary, desc, status, date_created, date_updated)
+		VALUES (?, ?, ?, 'todo', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+		recoveredProject,
+		"Tasks and logs whose project was missing",
+		"Created by addae doctor --fix on "+time.Now().Format("2006-01-02")+".")
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s project: %w", recoveredProject, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+
+	for _, table := range []string{"tasks", "logs"} {
+		if _, err := tx.ExecContext(ctx, "UPDATE "+table+" SET project_id = ? WHERE "+orphanCondition, id); err != nil {
+			return "", fmt.Errorf("failed to recover %s: %w", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("moved %d orphaned tasks and logs into project %d (%s)", orphans, id, recoveredProject), nil
+}
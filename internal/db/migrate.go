@@ -0,0 +1,231 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Migrator inspects and applies the migrations in a migrations filesystem.
+// Unlike the goose package-level functions it keeps no global state, so it
+// never touches the standard logger.
+type Migrator struct {
+	db       *sql.DB
+	provider *goose.Provider
+	fsys     fs.FS
+}
+
+// pinTable holds the version a rollback left the database at. While it
+// exists RunMigrationsFromFS applies nothing, so that opening the database
+// does not undo the rollback; Up drops it.
+const pinTable = "migration_pin"
+
+// Step is a migration the Migrator would run, in the given direction.
+type Step struct {
+	Version int64
+	Path    string
+	Up      bool
+}
+
+// NewMigrator returns a Migrator for db using the .sql files at the root of fsys.
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return &Migrator{db: db, provider: provider, fsys: fsys}, nil
+}
+
+// PinnedVersion returns the version Down or DownTo left the database at, and
+// false once Up has run since.
+func PinnedVersion(ctx context.Context, db *sql.DB) (int64, bool, error) {
+	if ok, err := tableExists(ctx, db, pinTable); err != nil || !ok {
+		return 0, false, err
+	}
+	var version int64
+	err := db.QueryRowContext(ctx, "SELECT version FROM "+pinTable).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read pinned version: %w", err)
+	}
+	return version, true, nil
+}
+
+// pin records the current version as the one to stay at.
+func (m *Migrator) pin(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		"CREATE TABLE IF NOT EXISTS " + pinTable + " (version INTEGER NOT NULL)",
+		"DELETE FROM " + pinTable,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to pin version: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO "+pinTable+" (version) VALUES (?)", version); err != nil {
+		return fmt.Errorf("failed to pin version: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Status returns every known migration, ordered by version.
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return m.provider.Status(ctx)
+}
+
+// Version returns the highest applied version, or 0 on an empty database.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	return m.provider.GetDBVersion(ctx)
+}
+
+// Up applies every pending migration and lifts the pin left by a rollback.
+func (m *Migrator) Up(ctx context.Context) ([]*goose.MigrationResult, error) {
+	if _, err := m.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+pinTable); err != nil {
+		return nil, fmt.Errorf("failed to unpin version: %w", err)
+	}
+	return m.provider.Up(ctx)
+}
+
+// Down rolls back the most recently applied migration and pins the database
+// at the version below it.
+func (m *Migrator) Down(ctx context.Context) (*goose.MigrationResult, error) {
+	res, err := m.down(ctx)
+	if err != nil {
+		return res, err
+	}
+	return res, m.pin(ctx)
+}
+
+func (m *Migrator) down(ctx context.Context) (*goose.MigrationResult, error) {
+	res, err := m.provider.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return nil, errors.New("no migrations to roll back")
+	}
+	return res, err
+}
+
+// DownTo rolls back every migration newer than version and pins the database
+// there.
+func (m *Migrator) DownTo(ctx context.Context, version int64) ([]*goose.MigrationResult, error) {
+	results, err := m.provider.DownTo(ctx, version)
+	if len(results) > 0 {
+		if pinErr := m.pin(ctx); err == nil {
+			err = pinErr
+		}
+	}
+	return results, err
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) ([]*goose.MigrationResult, error) {
+	down, err := m.down(ctx)
+	if err != nil {
+		return nil, err
+	}
+	up, err := m.provider.ApplyVersion(ctx, down.Source.Version, true)
+	if err != nil {
+		return []*goose.MigrationResult{down}, err
+	}
+	return []*goose.MigrationResult{down, up}, nil
+}
+
+// Plan returns the steps command would run without running them. command is
+// one of "up", "down", "down-to" or "redo"; version is only used by "down-to".
+func (m *Migrator) Plan(ctx context.Context, command string, version int64) ([]Step, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied, pending []Step
+	for _, s := range statuses {
+		step := Step{Version: s.Source.Version, Path: s.Source.Path}
+		if s.State == goose.StateApplied {
+			applied = append(applied, step)
+		} else {
+			step.Up = true
+			pending = append(pending, step)
+		}
+	}
+	slices.Reverse(applied)
+
+	switch command {
+	case "up":
+		return pending, nil
+	case "down", "redo":
+		if len(applied) == 0 {
+			return nil, errors.New("no migrations to roll back")
+		}
+		if command == "down" {
+			return applied[:1], nil
+		}
+		up := applied[0]
+		up.Up = true
+		return []Step{applied[0], up}, nil
+	case "down-to":
+		var steps []Step
+		for _, s := range applied {
+			if s.Version > version {
+				steps = append(steps, s)
+			}
+		}
+		return steps, nil
+	default:
+		return nil, fmt.Errorf("unknown migrate command %q", command)
+	}
+}
+
+// SQL returns the statements a step runs, as written in its migration file.
+func (m *Migrator) SQL(step Step) (string, error) {
+	f, err := m.fsys.Open(step.Path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	want := "-- +goose Down"
+	if step.Up {
+		want = "-- +goose Up"
+	}
+
+	var b strings.Builder
+	inSection := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "-- +goose ") {
+			switch {
+			case strings.HasPrefix(trimmed, "-- +goose Up"), strings.HasPrefix(trimmed, "-- +goose Down"):
+				inSection = strings.HasPrefix(trimmed, want)
+			}
+			continue
+		}
+		if inSection {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
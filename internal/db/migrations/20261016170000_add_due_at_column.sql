@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN due_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN due_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_id INTEGER NOT NULL,
+    blocked_by_task_id INTEGER NOT NULL CHECK(blocked_by_task_id != task_id),
+    PRIMARY KEY (task_id, blocked_by_task_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_by_task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocked_by_task_id ON task_dependencies(blocked_by_task_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS task_dependencies;
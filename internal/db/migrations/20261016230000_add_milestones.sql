@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS milestones (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    target_date TIMESTAMP,
+    desc TEXT NOT NULL DEFAULT '',
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_milestones_project_id ON milestones(project_id);
+
+ALTER TABLE tasks ADD COLUMN milestone_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_milestone_id ON tasks(milestone_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tasks_milestone_id;
+
+ALTER TABLE tasks DROP COLUMN milestone_id;
+
+DROP TABLE IF EXISTS milestones;
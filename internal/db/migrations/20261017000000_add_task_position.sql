@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+-- Number each project's existing tasks in the order they were created.
+UPDATE tasks SET position = (
+    SELECT COUNT(*) FROM tasks t
+    WHERE t.project_id = tasks.project_id
+        AND (t.date_created < tasks.date_created OR (t.date_created = tasks.date_created AND t.id < tasks.id))
+);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_position ON tasks(project_id, position);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tasks_position;
+ALTER TABLE tasks DROP COLUMN position;
@@ -0,0 +1,44 @@
+-- +goose Up
+-- The workflow states a task moves through before it is done. Done itself is
+-- not listed: a task is done exactly when it has a completed_at.
+CREATE TABLE IF NOT EXISTS task_states (
+    name TEXT PRIMARY KEY CHECK(length(name) BETWEEN 1 AND 30),
+    position INTEGER NOT NULL
+);
+
+INSERT INTO task_states (name, position) VALUES
+    ('todo', 0),
+    ('in progress', 1),
+    ('blocked', 2),
+    ('in review', 3);
+
+ALTER TABLE tasks ADD COLUMN state TEXT NOT NULL DEFAULT 'todo';
+
+UPDATE tasks SET state = 'done' WHERE completed_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS task_state_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    state TEXT NOT NULL,
+    entered_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_state_changes_task_id ON task_state_changes(task_id);
+
+-- Existing tasks entered todo when they were created, and done when they
+-- were completed.
+INSERT INTO task_state_changes (task_id, state, entered_at)
+    SELECT id, 'todo', COALESCE(date_created, CURRENT_TIMESTAMP) FROM tasks;
+
+INSERT INTO task_state_changes (task_id, state, entered_at)
+    SELECT id, 'done', completed_at FROM tasks WHERE completed_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_task_state_changes_task_id;
+
+DROP TABLE IF EXISTS task_state_changes;
+
+ALTER TABLE tasks DROP COLUMN state;
+
+DROP TABLE IF EXISTS task_states;
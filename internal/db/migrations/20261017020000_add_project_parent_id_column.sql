@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE projects ADD COLUMN parent_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_projects_parent_id ON projects(parent_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_projects_parent_id;
+
+ALTER TABLE projects DROP COLUMN parent_id;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER,
+    task_id INTEGER,
+    label TEXT NOT NULL,
+    target TEXT NOT NULL,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- A link is on a project or on a task, never both.
+    CHECK ((project_id IS NULL) != (task_id IS NULL)),
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_links_project_id ON links(project_id);
+CREATE INDEX IF NOT EXISTS idx_links_task_id ON links(task_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS links;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS log_tasks (
+    log_id INTEGER NOT NULL,
+    task_id INTEGER NOT NULL,
+    PRIMARY KEY (log_id, task_id),
+    FOREIGN KEY (log_id) REFERENCES logs(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_log_tasks_task_id ON log_tasks(task_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS log_tasks;
@@ -0,0 +1,1519 @@
+// Package server exposes projects, tasks and logs as a local JSON API.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/quamejnr/addae/internal/service"
+)
+
+// projectStatuses mirrors the CHECK constraint on projects.status.
+var projectStatuses = []string{"todo", "in progress", "completed", "archived"}
+
+// maxBodySize limits request bodies; log bodies are the largest expected.
+const maxBodySize = 1 << 20
+
+// Server handles the /api routes on top of a service.Service.
+type Server struct {
+	svc *service.Service
+	mux *http.ServeMux
+	// anyHost accepts every Host header. Otherwise only loopback names are
+	// accepted, so that a web page cannot reach the API through DNS
+	// rebinding.
+	anyHost bool
+}
+
+// New returns a Server backed by svc.
+func New(svc *service.Service) *Server {
+	s := &Server{svc: svc, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET /api/projects", s.listProjects)
+	s.mux.HandleFunc("POST /api/projects", s.createProject)
+	s.mux.HandleFunc("GET /api/projects/{id}", s.getProject)
+	s.mux.HandleFunc("PATCH /api/projects/{id}", s.updateProject)
+	s.mux.HandleFunc("DELETE /api/projects/{id}", s.deleteProject)
+	s.mux.HandleFunc("GET /api/projects/{id}/tasks", s.listProjectTasks)
+	s.mux.HandleFunc("POST /api/projects/{id}/tasks", s.createTask)
+	s.mux.HandleFunc("GET /api/projects/{id}/logs", s.listProjectLogs)
+	s.mux.HandleFunc("POST /api/projects/{id}/logs", s.createLog)
+	s.mux.HandleFunc("GET /api/projects/{id}/time-entries", s.listTimeEntries)
+	s.mux.HandleFunc("GET /api/projects/{id}/milestones", s.listMilestones)
+	s.mux.HandleFunc("POST /api/projects/{id}/milestones", s.createMilestone)
+	s.mux.HandleFunc("GET /api/projects/{id}/links", s.listProjectLinks)
+	s.mux.HandleFunc("POST /api/projects/{id}/links", s.createProjectLink)
+
+	s.mux.HandleFunc("GET /api/tasks", s.listTasks)
+	s.mux.HandleFunc("GET /api/tasks/{id}", s.getTask)
+	s.mux.HandleFunc("PATCH /api/tasks/{id}", s.updateTask)
+	s.mux.HandleFunc("DELETE /api/tasks/{id}", s.deleteTask)
+	s.mux.HandleFunc("GET /api/tasks/{id}/states", s.taskStateHistory)
+	s.mux.HandleFunc("POST /api/tasks/{id}/timer", s.startTimer)
+	s.mux.HandleFunc("GET /api/tasks/{id}/logs", s.listTaskLogs)
+	s.mux.HandleFunc("GET /api/tasks/{id}/links", s.listTaskLinks)
+	s.mux.HandleFunc("POST /api/tasks/{id}/links", s.createTaskLink)
+	s.mux.HandleFunc("GET /api/tasks/{id}/attachments", s.listTaskAttachments)
+	s.mux.HandleFunc("POST /api/tasks/{id}/attachments", s.createTaskAttachment)
+
+	s.mux.HandleFunc("GET /api/logs", s.listLogs)
+	s.mux.HandleFunc("GET /api/logs/{id}", s.getLog)
+	s.mux.HandleFunc("PATCH /api/logs/{id}", s.updateLog)
+	s.mux.HandleFunc("DELETE /api/logs/{id}", s.deleteLog)
+	s.mux.HandleFunc("GET /api/logs/{id}/attachments", s.listLogAttachments)
+	s.mux.HandleFunc("POST /api/logs/{id}/attachments", s.createLogAttachment)
+
+	s.mux.HandleFunc("GET /api/milestones/{id}", s.getMilestone)
+	s.mux.HandleFunc("PATCH /api/milestones/{id}", s.updateMilestone)
+	s.mux.HandleFunc("DELETE /api/milestones/{id}", s.deleteMilestone)
+
+	s.mux.HandleFunc("DELETE /api/links/{id}", s.deleteLink)
+
+	s.mux.HandleFunc("GET /api/attachments/{id}", s.getAttachment)
+	s.mux.HandleFunc("GET /api/attachments/{id}/content", s.getAttachmentContent)
+	s.mux.HandleFunc("DELETE /api/attachments/{id}", s.deleteAttachment)
+
+	s.mux.HandleFunc("GET /api/tags", s.listTags)
+	s.mux.HandleFunc("GET /api/states", s.listStates)
+
+	s.mux.HandleFunc("GET /api/timer", s.getTimer)
+	s.mux.HandleFunc("DELETE /api/timer", s.stopTimer)
+
+	return s
+}
+
+// ServeHTTP implements http.Handler. The API has no authentication, so it
+// turns away requests that a web page may have sent: those for a host other
+// than loopback, and those from a page that is not served from loopback.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.anyHost && !isLoopbackHost(r.Host) {
+		writeError(w, http.StatusForbidden, "host %q is not allowed", r.Host)
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || !isLoopbackHost(u.Host) {
+			writeError(w, http.StatusForbidden, "origin %q is not allowed", origin)
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// isLoopbackHost reports whether host, with or without a port, names the
+// local machine.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Serve accepts connections on ln until ctx is cancelled, then waits up to
+// five seconds for in-flight requests to finish. When ln is not on a loopback
+// address, requests may name any host.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	if tcp, ok := ln.Addr().(*net.TCPAddr); ok && !tcp.IP.IsLoopback() {
+		s.anyHost = true
+	}
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown failed: %w", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// apiError is the body of every error response.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, apiError{Error: fmt.Sprintf(format, args...)})
+}
+
+// writeServiceError maps err to 404 when the record does not exist and to
+// 500 otherwise.
+func writeServiceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "%v", err)
+		return
+	}
+	if errors.Is(err, service.ErrInvalid) {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "%v", err)
+}
+
+// decode reads a single JSON object from the request body into v. The body
+// must be sent as application/json, which a web page cannot do across
+// origins without the browser asking first.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "expected Content-Type application/json")
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		writeError(w, http.StatusBadRequest, "invalid request body: expected a single JSON object")
+		return false
+	}
+	return true
+}
+
+// pathID parses the {id} path value.
+func pathID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid id %q", r.PathValue("id"))
+		return 0, false
+	}
+	return id, true
+}
+
+// queryBool parses an optional boolean query parameter.
+func queryBool(w http.ResponseWriter, r *http.Request, name string) (*bool, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, true
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid %s %q, expected true or false", name, v)
+		return nil, false
+	}
+	return &b, true
+}
+
+// queryProjectID parses the optional project_id query parameter, returning 0
+// when it is absent.
+func queryProjectID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("project_id")
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid project_id %q", v)
+		return 0, false
+	}
+	return id, true
+}
+
+func validateProject(p service.Project) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("name is required")
+	}
+	if utf8.RuneCountInString(p.Name) > 100 {
+		return errors.New("name must be at most 100 characters")
+	}
+	if utf8.RuneCountInString(p.Summary) > 255 {
+		return errors.New("summary must be at most 255 characters")
+	}
+	if !slices.Contains(projectStatuses, p.Status) {
+		return fmt.Errorf("invalid status %q, expected one of: %s", p.Status, strings.Join(projectStatuses, ", "))
+	}
+	return nil
+}
+
+func validateTitle(title string, required bool) error {
+	if required && strings.TrimSpace(title) == "" {
+		return errors.New("title is required")
+	}
+	if utf8.RuneCountInString(title) > 100 {
+		return errors.New("title must be at most 100 characters")
+	}
+	return nil
+}
+
+// Projects
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && !slices.Contains(projectStatuses, status) {
+		writeError(w, http.StatusBadRequest, "invalid status %q", status)
+		return
+	}
+
+	tag := r.URL.Query().Get("tag")
+
+	f := service.ProjectFilter{Tag: tag}
+	if status != "" {
+		f.Statuses = []string{status}
+	}
+	projects, err := s.svc.FilterProjects(f)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if projects == nil {
+		projects = []service.Project{}
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
+// projectInput is the body of project requests. Fields left out of a PATCH
+// keep their current value.
+type projectInput struct {
+	Name    *string `json:"name"`
+	Summary *string `json:"summary"`
+	Desc    *string `json:"desc"`
+	Status  *string `json:"status"`
+	// ParentID puts the project under another one, or makes it top-level
+	// when 0.
+	ParentID *int `json:"parent_id"`
+	// Tags replaces the project's tags.
+	Tags *[]string `json:"tags"`
+}
+
+func (in projectInput) apply(p *service.Project) {
+	if in.Name != nil {
+		p.Name = strings.TrimSpace(*in.Name)
+	}
+	if in.Summary != nil {
+		p.Summary = *in.Summary
+	}
+	if in.Desc != nil {
+		p.Desc = *in.Desc
+	}
+	if in.Status != nil {
+		p.Status = *in.Status
+	}
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
+	var in projectInput
+	if !decode(w, r, &in) {
+		return
+	}
+
+	p := service.Project{Status: "todo"}
+	in.apply(&p)
+	if err := validateProject(p); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if err := validateTags(in.Tags); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if in.ParentID != nil && *in.ParentID != 0 {
+		if _, err := s.svc.GetProject(*in.ParentID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+
+	if err := s.svc.CreateProject(&p); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if in.ParentID != nil {
+		if err := s.setProjectParent(p.ID, *in.ParentID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.Tags != nil {
+		if err := s.svc.SetProjectTags(p.ID, *in.Tags); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	created, err := s.svc.GetProject(p.ID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/projects/%d", p.ID))
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	p, err := s.svc.GetProject(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var in projectInput
+	if !decode(w, r, &in) {
+		return
+	}
+
+	p, err := s.svc.GetProject(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	in.apply(p)
+	if err := validateProject(*p); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if err := validateTags(in.Tags); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	if err := s.svc.UpdateProject(p); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if in.ParentID != nil {
+		if err := s.setProjectParent(p.ID, *in.ParentID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.Tags != nil {
+		if err := s.svc.SetProjectTags(p.ID, *in.Tags); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	updated, err := s.svc.GetProject(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.svc.DeleteProject(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Tasks
+
+func (s *Server) listProjectTasks(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	s.writeTasks(w, r, id)
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := queryProjectID(w, r)
+	if !ok {
+		return
+	}
+	s.writeTasks(w, r, projectID)
+}
+
+// writeTasks lists the tasks of projectID, or of every project when it is 0,
+// filtered by the optional completed and tag query parameters.
+func (s *Server) writeTasks(w http.ResponseWriter, r *http.Request, projectID int) {
+	completed, ok := queryBool(w, r, "completed")
+	if !ok {
+		return
+	}
+	tag := r.URL.Query().Get("tag")
+
+	if projectID != 0 {
+		if _, err := s.svc.GetProject(projectID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	tasks, err := s.svc.FilterTasks(service.TaskFilter{ProjectID: projectID, Completed: completed, Tag: tag})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if tasks == nil {
+		tasks = []service.Task{}
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// taskInput is the body of task requests. Completed sets completed_at to now,
+// or clears it, when it changes.
+type taskInput struct {
+	Title     *string `json:"title"`
+	Desc      *string `json:"desc"`
+	Completed *bool   `json:"completed"`
+	// DueAt sets the due date from a YYYY-MM-DD date, or clears it when empty.
+	DueAt      *string             `json:"due_at"`
+	Priority   *service.Priority   `json:"priority"`
+	Recurrence *service.Recurrence `json:"recurrence"`
+	// ParentTaskID makes the task a subtask, or a top-level task when 0.
+	ParentTaskID *int `json:"parent_task_id"`
+	// MilestoneID assigns the task to a milestone, or clears it when 0.
+	MilestoneID *int `json:"milestone_id"`
+	// Position moves the task in its project's manual order.
+	Position *int `json:"position"`
+	// State moves the task to a workflow state, after Completed is applied.
+	State *string `json:"state"`
+	// BlockedBy replaces the IDs of the tasks blocking the task.
+	BlockedBy *[]int `json:"blocked_by"`
+	// Tags replaces the task's tags.
+	Tags *[]string `json:"tags"`
+}
+
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var in taskInput
+	if !decode(w, r, &in) {
+		return
+	}
+
+	var title, desc string
+	if in.Title != nil {
+		title = strings.TrimSpace(*in.Title)
+	}
+	if in.Desc != nil {
+		desc = *in.Desc
+	}
+	if err := validateTitle(title, true); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if err := validateTags(in.Tags); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	var dueAt *time.Time
+	if in.DueAt != nil {
+		var err error
+		if dueAt, err = parseDueDate(*in.DueAt); err != nil {
+			writeError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+	}
+	if in.Position != nil && *in.Position < 0 {
+		writeError(w, http.StatusBadRequest, "position must not be negative")
+		return
+	}
+	if _, err := s.svc.GetProject(projectID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if in.ParentTaskID != nil && *in.ParentTaskID != 0 {
+		parent, err := s.svc.GetTask(*in.ParentTaskID)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		if parent.ProjectID != projectID {
+			writeError(w, http.StatusBadRequest, "parent task %d is in another project", parent.ID)
+			return
+		}
+	}
+	if in.MilestoneID != nil && *in.MilestoneID != 0 {
+		m, err := s.svc.GetMilestone(*in.MilestoneID)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		if m.ProjectID != projectID {
+			writeError(w, http.StatusBadRequest, "milestone %d is in another project", m.ID)
+			return
+		}
+	}
+	if in.BlockedBy != nil {
+		for _, blockerID := range *in.BlockedBy {
+			blocker, err := s.svc.GetTask(blockerID)
+			if err != nil {
+				writeServiceError(w, err)
+				return
+			}
+			if blocker.ProjectID != projectID {
+				writeError(w, http.StatusBadRequest, "blocking task %d is in another project", blocker.ID)
+				return
+			}
+		}
+	}
+
+	id, err := s.svc.CreateTask(projectID, title, desc, dueAt)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if in.Priority != nil {
+		if err := s.svc.SetTaskPriority(id, *in.Priority); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.Recurrence != nil {
+		if err := s.svc.SetTaskRecurrence(id, *in.Recurrence); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.ParentTaskID != nil {
+		if err := s.setTaskParent(id, *in.ParentTaskID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.MilestoneID != nil {
+		if err := s.setTaskMilestone(id, *in.MilestoneID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.Position != nil {
+		if err := s.svc.MoveTask(id, *in.Position); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.BlockedBy != nil {
+		if err := s.svc.SetTaskBlockers(id, *in.BlockedBy); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.Tags != nil {
+		if err := s.svc.SetTaskTags(id, *in.Tags); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	// Complete the task last, so a repeating task's next occurrence copies it.
+	if in.Completed != nil && *in.Completed {
+		now := time.Now()
+		if err := s.svc.UpdateTask(id, title, desc, &now, dueAt); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.State != nil {
+		if err := s.svc.SetTaskState(id, *in.State, time.Now()); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+
+	task, err := s.svc.GetTask(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/tasks/%d", id))
+	writeJSON(w, http.StatusCreated, task)
+}
+
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	task, err := s.svc.GetTask(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, task)
+}
+
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var in taskInput
+	if !decode(w, r, &in) {
+		return
+	}
+
+	task, err := s.svc.GetTask(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if in.Title != nil {
+		task.Title = strings.TrimSpace(*in.Title)
+	}
+	if in.Desc != nil {
+		task.Desc = *in.Desc
+	}
+	if err := validateTitle(task.Title, true); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if err := validateTags(in.Tags); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if in.DueAt != nil {
+		if task.DueAt, err = parseDueDate(*in.DueAt); err != nil {
+			writeError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+	}
+	if in.Position != nil && *in.Position < 0 {
+		writeError(w, http.StatusBadRequest, "position must not be negative")
+		return
+	}
+	if in.Completed != nil && *in.Completed != (task.CompletedAt != nil) {
+		task.CompletedAt = nil
+		if *in.Completed {
+			now := time.Now()
+			task.CompletedAt = &now
+		}
+	}
+
+	// Set the rule first, so completing the task in the same request repeats it.
+	if in.Recurrence != nil {
+		if err := s.svc.SetTaskRecurrence(task.ID, *in.Recurrence); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if err := s.svc.UpdateTask(task.ID, task.Title, task.Desc, task.CompletedAt, task.DueAt); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if in.Priority != nil {
+		if err := s.svc.SetTaskPriority(task.ID, *in.Priority); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.ParentTaskID != nil {
+		if err := s.setTaskParent(task.ID, *in.ParentTaskID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.MilestoneID != nil {
+		if err := s.setTaskMilestone(task.ID, *in.MilestoneID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.Position != nil {
+		if err := s.svc.MoveTask(task.ID, *in.Position); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.BlockedBy != nil {
+		if err := s.svc.SetTaskBlockers(task.ID, *in.BlockedBy); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.Tags != nil {
+		if err := s.svc.SetTaskTags(task.ID, *in.Tags); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if in.State != nil {
+		if err := s.svc.SetTaskState(task.ID, *in.State, time.Now()); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	updated, err := s.svc.GetTask(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.svc.DeleteTask(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Logs
+
+func (s *Server) listProjectLogs(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	s.writeLogs(w, id)
+}
+
+func (s *Server) listLogs(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := queryProjectID(w, r)
+	if !ok {
+		return
+	}
+	s.writeLogs(w, projectID)
+}
+
+// writeLogs lists the logs of projectID, or of every project when it is 0.
+func (s *Server) writeLogs(w http.ResponseWriter, projectID int) {
+	var logs []service.Log
+	var err error
+	if projectID != 0 {
+		if _, err := s.svc.GetProject(projectID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		logs, err = s.svc.ListProjectLogs(projectID)
+	} else {
+		logs, err = s.svc.ListLogs()
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if logs == nil {
+		logs = []service.Log{}
+	}
+	writeJSON(w, http.StatusOK, logs)
+}
+
+// logInput is the body of log requests.
+type logInput struct {
+	Title *string `json:"title"`
+	Desc  *string `json:"desc"`
+	// TaskIDs replaces the IDs of the tasks the log is about.
+	TaskIDs *[]int `json:"task_ids"`
+}
+
+func (s *Server) listTaskLogs(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.svc.GetTask(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	logs, err := s.svc.ListTaskLogs(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if logs == nil {
+		logs = []service.Log{}
+	}
+	writeJSON(w, http.StatusOK, logs)
+}
+
+func (s *Server) createLog(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var in logInput
+	if !decode(w, r, &in) {
+		return
+	}
+
+	var title, desc string
+	if in.Title != nil {
+		title = strings.TrimSpace(*in.Title)
+	}
+	if in.Desc != nil {
+		desc = *in.Desc
+	}
+	if err := validateTitle(title, false); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if _, err := s.svc.GetProject(projectID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if in.TaskIDs != nil {
+		for _, taskID := range *in.TaskIDs {
+			task, err := s.svc.GetTask(taskID)
+			if err != nil {
+				writeServiceError(w, err)
+				return
+			}
+			if task.ProjectID != projectID {
+				writeError(w, http.StatusBadRequest, "task %d is in another project", task.ID)
+				return
+			}
+		}
+	}
+
+	id, err := s.svc.CreateLog(projectID, title, desc)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if in.TaskIDs != nil {
+		if err := s.svc.SetLogTasks(id, *in.TaskIDs); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	l, err := s.svc.GetLog(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/logs/%d", id))
+	writeJSON(w, http.StatusCreated, l)
+}
+
+func (s *Server) getLog(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	l, err := s.svc.GetLog(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, l)
+}
+
+func (s *Server) updateLog(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var in logInput
+	if !decode(w, r, &in) {
+		return
+	}
+
+	l, err := s.svc.GetLog(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if in.Title != nil {
+		l.Title = strings.TrimSpace(*in.Title)
+	}
+	if in.Desc != nil {
+		l.Desc = *in.Desc
+	}
+	if err := validateTitle(l.Title, false); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	if err := s.svc.UpdateLog(l.ID, l.Title, l.Desc); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if in.TaskIDs != nil {
+		if err := s.svc.SetLogTasks(l.ID, *in.TaskIDs); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	updated, err := s.svc.GetLog(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) deleteLog(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.svc.DeleteLog(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Milestones
+
+func (s *Server) listMilestones(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.svc.GetProject(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	milestones, err := s.svc.ListProjectMilestones(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if milestones == nil {
+		milestones = []service.Milestone{}
+	}
+	writeJSON(w, http.StatusOK, milestones)
+}
+
+// milestoneInput is the body of milestone requests.
+type milestoneInput struct {
+	Name *string `json:"name"`
+	// TargetDate sets the target date from a YYYY-MM-DD date, or clears it
+	// when empty.
+	TargetDate *string `json:"target_date"`
+	Desc       *string `json:"desc"`
+}
+
+// apply copies the fields present in the input to m.
+func (in milestoneInput) apply(m *service.Milestone) error {
+	if in.Name != nil {
+		m.Name = strings.TrimSpace(*in.Name)
+	}
+	if in.Desc != nil {
+		m.Desc = *in.Desc
+	}
+	if m.Name == "" {
+		return errors.New("name is required")
+	}
+	if utf8.RuneCountInString(m.Name) > 100 {
+		return errors.New("name must be at most 100 characters")
+	}
+	if in.TargetDate != nil {
+		target, err := parseDueDate(*in.TargetDate)
+		if err != nil {
+			return errors.New("target_date must be a date like 2025-03-01")
+		}
+		m.TargetDate = target
+	}
+	return nil
+}
+
+func (s *Server) createMilestone(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var in milestoneInput
+	if !decode(w, r, &in) {
+		return
+	}
+
+	m := service.Milestone{ProjectID: projectID}
+	if err := in.apply(&m); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if _, err := s.svc.GetProject(projectID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if err := s.svc.CreateMilestone(&m); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	created, err := s.svc.GetMilestone(m.ID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/milestones/%d", m.ID))
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) getMilestone(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	m, err := s.svc.GetMilestone(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+func (s *Server) updateMilestone(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var in milestoneInput
+	if !decode(w, r, &in) {
+		return
+	}
+
+	m, err := s.svc.GetMilestone(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if err := in.apply(m); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if err := s.svc.UpdateMilestone(m); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	updated, err := s.svc.GetMilestone(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// deleteMilestone deletes a milestone. Its tasks stay, without a milestone.
+func (s *Server) deleteMilestone(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.svc.DeleteMilestone(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Tags
+
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.svc.ListTags()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if tags == nil {
+		tags = []service.Tag{}
+	}
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// States
+
+func (s *Server) listStates(w http.ResponseWriter, r *http.Request) {
+	states, err := s.svc.TaskStates()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, states)
+}
+
+// taskStateHistory returns the states a task has entered, oldest first.
+func (s *Server) taskStateHistory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	history, err := s.svc.TaskStateHistory(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if history == nil {
+		history = []service.StateChange{}
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// Links
+
+type linkInput struct {
+	// Label defaults to the target when empty.
+	Label  string `json:"label"`
+	Target string `json:"target"`
+}
+
+// listProjectLinks returns the links on a project and on its tasks.
+func (s *Server) listProjectLinks(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.svc.GetProject(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	links, err := s.svc.ListProjectLinks(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeLinks(w, links)
+}
+
+func (s *Server) listTaskLinks(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.svc.GetTask(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	links, err := s.svc.ListTaskLinks(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeLinks(w, links)
+}
+
+func (s *Server) createProjectLink(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	s.createLink(w, r, service.Link{ProjectID: &id})
+}
+
+func (s *Server) createTaskLink(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	s.createLink(w, r, service.Link{TaskID: &id})
+}
+
+// createLink adds the link in the request body to the project or task l is
+// on.
+func (s *Server) createLink(w http.ResponseWriter, r *http.Request, l service.Link) {
+	var in linkInput
+	if !decode(w, r, &in) {
+		return
+	}
+	if strings.TrimSpace(in.Target) == "" {
+		writeError(w, http.StatusBadRequest, "target is required")
+		return
+	}
+	l.Label, l.Target = in.Label, in.Target
+	if err := s.svc.AddLink(&l); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, l)
+}
+
+func (s *Server) deleteLink(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.svc.DeleteLink(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeLinks(w http.ResponseWriter, links []service.Link) {
+	if links == nil {
+		links = []service.Link{}
+	}
+	writeJSON(w, http.StatusOK, links)
+}
+
+// Attachments
+
+func (s *Server) listTaskAttachments(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.svc.GetTask(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	attachments, err := s.svc.ListTaskAttachments(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeAttachments(w, attachments)
+}
+
+func (s *Server) listLogAttachments(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.svc.GetLog(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	attachments, err := s.svc.ListLogAttachments(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeAttachments(w, attachments)
+}
+
+func (s *Server) createTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	s.createAttachment(w, r, service.Attachment{TaskID: &id})
+}
+
+func (s *Server) createLogAttachment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	s.createAttachment(w, r, service.Attachment{LogID: &id})
+}
+
+// createAttachment stores the raw request body as an attachment on the task
+// or log att is on. The name comes from ?name= and the MIME type from
+// ?mime_type=, or is guessed when it is not given.
+func (s *Server) createAttachment(w http.ResponseWriter, r *http.Request, att service.Attachment) {
+	att.Name = r.URL.Query().Get("name")
+	att.MIMEType = r.URL.Query().Get("mime_type")
+	if strings.TrimSpace(att.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	// One byte past the cap is enough for the service to refuse the body.
+	data, err := io.ReadAll(io.LimitReader(r.Body, s.svc.MaxAttachmentSize()+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+	if err := s.svc.AddAttachment(&att, data); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/attachments/%d", att.ID))
+	writeJSON(w, http.StatusCreated, att)
+}
+
+func (s *Server) getAttachment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	att, err := s.svc.GetAttachment(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, att)
+}
+
+// getAttachmentContent serves the stored file with its MIME type.
+func (s *Server) getAttachmentContent(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	att, err := s.svc.GetAttachment(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	data, err := s.svc.ReadAttachment(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", att.MIMEType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": att.Name}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (s *Server) deleteAttachment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.svc.DeleteAttachment(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeAttachments(w http.ResponseWriter, attachments []service.Attachment) {
+	if attachments == nil {
+		attachments = []service.Attachment{}
+	}
+	writeJSON(w, http.StatusOK, attachments)
+}
+
+// Timers
+
+// getTimer returns the entry of the running timer, or null when no timer is
+// running.
+func (s *Server) getTimer(w http.ResponseWriter, r *http.Request) {
+	running, err := s.svc.RunningTimer()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, running)
+}
+
+// startTimer starts timing a task, stopping the timer of any other task, and
+// returns the running entry.
+func (s *Server) startTimer(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.svc.StartTimer(id, time.Now()); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	running, err := s.svc.RunningTimer()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, running)
+}
+
+// stopTimer stops the running timer and returns its entry.
+func (s *Server) stopTimer(w http.ResponseWriter, r *http.Request) {
+	stopped, err := s.svc.StopTimer(time.Now())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stopped)
+}
+
+func (s *Server) listTimeEntries(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.svc.GetProject(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	entries, err := s.svc.ListTimeEntries(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if entries == nil {
+		entries = []service.TimeEntry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func validateTags(tags *[]string) error {
+	if tags == nil {
+		return nil
+	}
+	for _, tag := range *tags {
+		if _, err := service.NormalizeTag(tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTaskParent applies a parent_task_id from a request body, where 0 means
+// no parent.
+func (s *Server) setProjectParent(projectID, parentID int) error {
+	if parentID == 0 {
+		return s.svc.SetProjectParent(projectID, nil)
+	}
+	return s.svc.SetProjectParent(projectID, &parentID)
+}
+
+func (s *Server) setTaskParent(taskID, parentID int) error {
+	if parentID == 0 {
+		return s.svc.SetTaskParent(taskID, nil)
+	}
+	return s.svc.SetTaskParent(taskID, &parentID)
+}
+
+func (s *Server) setTaskMilestone(taskID, milestoneID int) error {
+	if milestoneID == 0 {
+		return s.svc.SetTaskMilestone(taskID, nil)
+	}
+	return s.svc.SetTaskMilestone(taskID, &milestoneID)
+}
+
+// parseDueDate parses a due date given as YYYY-MM-DD, in the server's local
+// time. An empty string clears the due date.
+func parseDueDate(s string) (*time.Time, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	due, err := time.ParseInLocation(service.DueDateLayout, strings.TrimSpace(s), time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("due_at must be a date like 2025-03-01")
+	}
+	return &due, nil
+}
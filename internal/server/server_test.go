@@ -0,0 +1,658 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	adb "github.com/quamejnr/addae/internal/db"
+	"github.com/quamejnr/addae/internal/service"
+	_ "modernc.org/sqlite"
+)
+
+func setupTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	// Every new connection to :memory: is a fresh database, so pin the pool to one.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := adb.RunMigrations(db, "../db/migrations"); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	ts := httptest.NewServer(New(service.NewService(db)))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// do sends a request with an optional JSON body, checks the status code and
+// decodes the response into out when it is not nil.
+func do(t *testing.T, ts *httptest.Server, method, path, body string, want int, out any) {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, ts.URL+path, r)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, want, resp.StatusCode, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("%s %s: invalid JSON %q: %v", method, path, data, err)
+		}
+	}
+}
+
+func TestProjects(t *testing.T) {
+	ts := setupTestServer(t)
+
+	var p service.Project
+	do(t, ts, "POST", "/api/projects", `{"name": "Website", "summary": "Relaunch"}`, http.StatusCreated, &p)
+	if p.ID != 1 || p.Name != "Website" || p.Status != "todo" {
+		t.Errorf("unexpected project: %+v", p)
+	}
+	do(t, ts, "POST", "/api/projects", `{"name": "Mobile", "status": "in progress"}`, http.StatusCreated, nil)
+
+	var projects []service.Project
+	do(t, ts, "GET", "/api/projects?status=in+progress", "", http.StatusOK, &projects)
+	if len(projects) != 1 || projects[0].Name != "Mobile" {
+		t.Errorf("expected only Mobile, got %+v", projects)
+	}
+
+	do(t, ts, "PATCH", "/api/projects/1", `{"status": "completed"}`, http.StatusOK, &p)
+	if p.Status != "completed" || p.Summary != "Relaunch" {
+		t.Errorf("expected only the status to change, got %+v", p)
+	}
+
+	do(t, ts, "GET", "/api/projects/1", "", http.StatusOK, &p)
+	do(t, ts, "DELETE", "/api/projects/1", "", http.StatusNoContent, nil)
+	do(t, ts, "GET", "/api/projects/1", "", http.StatusNotFound, nil)
+	do(t, ts, "PATCH", "/api/projects/1", `{"name": "Gone"}`, http.StatusNotFound, nil)
+	do(t, ts, "DELETE", "/api/projects/1", "", http.StatusNotFound, nil)
+
+	do(t, ts, "POST", "/api/projects", `{"name": ""}`, http.StatusBadRequest, nil)
+	do(t, ts, "POST", "/api/projects", `{"name": "X", "status": "someday"}`, http.StatusBadRequest, nil)
+	do(t, ts, "POST", "/api/projects", `{"name": "X", "owner": "me"}`, http.StatusBadRequest, nil)
+	do(t, ts, "GET", "/api/projects/abc", "", http.StatusBadRequest, nil)
+	do(t, ts, "GET", "/api/projects?status=someday", "", http.StatusBadRequest, nil)
+	do(t, ts, "PUT", "/api/projects/2", `{}`, http.StatusMethodNotAllowed, nil)
+}
+
+func TestTasks(t *testing.T) {
+	ts := setupTestServer(t)
+	do(t, ts, "POST", "/api/projects", `{"name": "Website"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects", `{"name": "Mobile"}`, http.StatusCreated, nil)
+
+	var task service.Task
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Write copy"}`, http.StatusCreated, &task)
+	if task.ProjectID != 1 || task.CompletedAt != nil {
+		t.Errorf("unexpected task: %+v", task)
+	}
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Pick fonts", "completed": true}`, http.StatusCreated, &task)
+	if task.CompletedAt == nil {
+		t.Errorf("expected task to be created completed")
+	}
+	do(t, ts, "POST", "/api/projects/2/tasks", `{"title": "Ship beta"}`, http.StatusCreated, nil)
+
+	var tasks []service.Task
+	do(t, ts, "GET", "/api/tasks", "", http.StatusOK, &tasks)
+	if len(tasks) != 3 {
+		t.Errorf("expected 3 tasks, got %d", len(tasks))
+	}
+	do(t, ts, "GET", "/api/tasks?project_id=1&completed=false", "", http.StatusOK, &tasks)
+	if len(tasks) != 1 || tasks[0].Title != "Write copy" {
+		t.Errorf("expected only the open Website task, got %+v", tasks)
+	}
+	do(t, ts, "GET", "/api/projects/1/tasks?completed=true", "", http.StatusOK, &tasks)
+	if len(tasks) != 1 || tasks[0].Title != "Pick fonts" {
+		t.Errorf("expected only the completed Website task, got %+v", tasks)
+	}
+
+	do(t, ts, "PATCH", "/api/tasks/1", `{"completed": true}`, http.StatusOK, &task)
+	if task.CompletedAt == nil || task.Title != "Write copy" {
+		t.Errorf("expected task to be completed, got %+v", task)
+	}
+	do(t, ts, "PATCH", "/api/tasks/1", `{"completed": false, "desc": "Hero section"}`, http.StatusOK, &task)
+	if task.CompletedAt != nil || task.Desc != "Hero section" {
+		t.Errorf("expected task to be reopened, got %+v", task)
+	}
+
+	do(t, ts, "PATCH", "/api/tasks/1", `{"due_at": "2025-03-14"}`, http.StatusOK, &task)
+	if task.DueAt == nil || task.DueAt.Format("2006-01-02") != "2025-03-14" || task.Desc != "Hero section" {
+		t.Errorf("expected task to be due on 2025-03-14, got %+v", task)
+	}
+	do(t, ts, "PATCH", "/api/tasks/1", `{"due_at": ""}`, http.StatusOK, &task)
+	if task.DueAt != nil {
+		t.Errorf("expected the due date to be cleared, got %v", task.DueAt)
+	}
+	do(t, ts, "PATCH", "/api/tasks/1", `{"due_at": "soon"}`, http.StatusBadRequest, nil)
+	do(t, ts, "PATCH", "/api/tasks/1", `{"priority": "high"}`, http.StatusOK, &task)
+	if task.Priority != service.PriorityHigh {
+		t.Errorf("expected high priority, got %v", task.Priority)
+	}
+	do(t, ts, "PATCH", "/api/tasks/1", `{"priority": "critical"}`, http.StatusBadRequest, nil)
+
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Hero", "parent_task_id": 1}`, http.StatusCreated, &task)
+	if task.ParentTaskID == nil || *task.ParentTaskID != 1 {
+		t.Errorf("expected a subtask of task 1, got %+v", task)
+	}
+	do(t, ts, "PATCH", "/api/tasks/1", fmt.Sprintf(`{"parent_task_id": %d}`, task.ID), http.StatusBadRequest, nil)
+	do(t, ts, "POST", "/api/projects/2/tasks", `{"title": "Elsewhere", "parent_task_id": 1}`, http.StatusBadRequest, nil)
+	do(t, ts, "PATCH", fmt.Sprintf("/api/tasks/%d", task.ID), `{"parent_task_id": 0}`, http.StatusOK, &task)
+	if task.ParentTaskID != nil {
+		t.Errorf("expected a top-level task, got %v", *task.ParentTaskID)
+	}
+
+	do(t, ts, "PATCH", "/api/tasks/1", fmt.Sprintf(`{"blocked_by": [%d]}`, task.ID), http.StatusOK, &task)
+	if len(task.BlockedBy) != 1 {
+		t.Errorf("expected task 1 to be blocked, got %+v", task)
+	}
+	do(t, ts, "PATCH", fmt.Sprintf("/api/tasks/%d", task.BlockedBy[0]), `{"blocked_by": [1]}`, http.StatusBadRequest, nil)
+	do(t, ts, "POST", "/api/projects/2/tasks", `{"title": "Elsewhere", "blocked_by": [1]}`, http.StatusBadRequest, nil)
+	var repeating service.Task
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Update deps", "recurrence": "weekly mon"}`, http.StatusCreated, &repeating)
+	if repeating.Recurrence.String() != "weekly mon" {
+		t.Errorf("expected a weekly task, got %+v", repeating)
+	}
+	do(t, ts, "PATCH", fmt.Sprintf("/api/tasks/%d", repeating.ID), `{"recurrence": "hourly"}`, http.StatusBadRequest, nil)
+	do(t, ts, "PATCH", fmt.Sprintf("/api/tasks/%d", repeating.ID), `{"completed": true}`, http.StatusOK, &repeating)
+	if !repeating.Recurrence.IsZero() {
+		t.Errorf("expected the rule to move to the next occurrence, got %q", repeating.Recurrence)
+	}
+	var next []service.Task
+	do(t, ts, "GET", "/api/projects/1/tasks?completed=false", "", http.StatusOK, &next)
+	if i := slices.IndexFunc(next, func(t service.Task) bool { return t.Title == "Update deps" }); i < 0 || next[i].Recurrence.String() != "weekly mon" {
+		t.Errorf("expected the next occurrence to be pending, got %+v", next)
+	}
+
+	var unblocked service.Task
+	do(t, ts, "PATCH", "/api/tasks/1", `{"blocked_by": []}`, http.StatusOK, &unblocked)
+	if unblocked.BlockedBy != nil {
+		t.Errorf("expected task 1 to be unblocked, got %v", unblocked.BlockedBy)
+	}
+
+	do(t, ts, "PATCH", fmt.Sprintf("/api/tasks/%d", next[len(next)-1].ID), `{"position": 0}`, http.StatusOK, &task)
+	if task.Position != 0 {
+		t.Errorf("expected the task moved to the top, got position %d", task.Position)
+	}
+	do(t, ts, "PATCH", "/api/tasks/1", `{"position": -1}`, http.StatusBadRequest, nil)
+
+	do(t, ts, "DELETE", "/api/tasks/1", "", http.StatusNoContent, nil)
+	do(t, ts, "GET", "/api/tasks/1", "", http.StatusNotFound, nil)
+
+	do(t, ts, "POST", "/api/projects/9/tasks", `{"title": "Orphan"}`, http.StatusNotFound, nil)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": ""}`, http.StatusBadRequest, nil)
+	do(t, ts, "GET", "/api/tasks?completed=maybe", "", http.StatusBadRequest, nil)
+	do(t, ts, "GET", "/api/tasks?project_id=9", "", http.StatusNotFound, nil)
+}
+
+func TestLogs(t *testing.T) {
+	ts := setupTestServer(t)
+	do(t, ts, "POST", "/api/projects", `{"name": "Website"}`, http.StatusCreated, nil)
+
+	var l service.Log
+	do(t, ts, "POST", "/api/projects/1/logs", `{"title": "Kickoff", "desc": "# Notes"}`, http.StatusCreated, &l)
+	if l.ID != 1 || l.Desc != "# Notes" {
+		t.Errorf("unexpected log: %+v", l)
+	}
+
+	var logs []service.Log
+	do(t, ts, "GET", "/api/logs?project_id=1", "", http.StatusOK, &logs)
+	if len(logs) != 1 {
+		t.Errorf("expected 1 log, got %d", len(logs))
+	}
+
+	do(t, ts, "PATCH", "/api/logs/1", `{"title": "Kick-off"}`, http.StatusOK, &l)
+	if l.Title != "Kick-off" || l.Desc != "# Notes" {
+		t.Errorf("expected only the title to change, got %+v", l)
+	}
+	do(t, ts, "DELETE", "/api/logs/1", "", http.StatusNoContent, nil)
+	do(t, ts, "GET", "/api/projects/1/logs", "", http.StatusOK, &logs)
+	if len(logs) != 0 {
+		t.Errorf("expected no logs, got %+v", logs)
+	}
+}
+
+func TestServeShutsDownGracefully(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- New(nil).Serve(ctx, ln) }()
+
+	cancel()
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
+
+func TestRequestChecks(t *testing.T) {
+	ts := setupTestServer(t)
+	do(t, ts, "POST", "/api/projects", `{"name": "Website"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Write copy"}`, http.StatusCreated, nil)
+
+	for _, tt := range []struct {
+		name        string
+		method      string
+		path        string
+		host        string
+		origin      string
+		contentType string
+		want        int
+	}{
+		{"loopback name", "GET", "/api/projects", "localhost:7777", "", "", http.StatusOK},
+		{"loopback IPv6", "GET", "/api/projects", "[::1]:7777", "", "", http.StatusOK},
+		{"rebound host", "GET", "/api/projects", "attacker.example:7777", "", "", http.StatusForbidden},
+		{"loopback origin", "POST", "/api/projects", "127.0.0.1:7777", "http://localhost:3000", "application/json", http.StatusCreated},
+		{"foreign origin", "GET", "/api/projects", "127.0.0.1:7777", "https://attacker.example", "", http.StatusForbidden},
+		{"null origin", "GET", "/api/projects", "127.0.0.1:7777", "null", "", http.StatusForbidden},
+		{"foreign upload", "POST", "/api/tasks/1/attachments?name=x.txt", "127.0.0.1:7777", "https://attacker.example", "text/plain", http.StatusForbidden},
+		{"plain text body", "POST", "/api/projects", "127.0.0.1:7777", "", "text/plain", http.StatusUnsupportedMediaType},
+		{"form body", "PATCH", "/api/tasks/1", "127.0.0.1:7777", "", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"JSON with charset", "PATCH", "/api/tasks/1", "127.0.0.1:7777", "", "application/json; charset=utf-8", http.StatusOK},
+	} {
+		body := `{"name": "Garden"}`
+		if tt.method == "PATCH" {
+			body = `{"completed": true}`
+		}
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(body))
+		req.Host = tt.host
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		rec := httptest.NewRecorder()
+		ts.Config.Handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.want, rec.Code, rec.Body)
+		}
+	}
+
+	var attachments []service.Attachment
+	do(t, ts, "GET", "/api/tasks/1/attachments", "", http.StatusOK, &attachments)
+	if len(attachments) != 0 {
+		t.Errorf("expected the foreign upload to be refused, got %+v", attachments)
+	}
+}
+
+func TestServeAcceptsAnyHostOffLoopback(t *testing.T) {
+	ln, err := net.Listen("tcp", "0.0.0.0:0")
+	if err != nil {
+		t.Skipf("cannot listen on every interface: %v", err)
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if err := adb.RunMigrations(db, "../db/migrations"); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go New(service.NewService(db)).Serve(ctx, ln)
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	req, err := http.NewRequest("GET", fmt.Sprintf("http://127.0.0.1:%d/api/projects", port), nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.Host = fmt.Sprintf("workstation.lan:%d", port)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected a server bound off loopback to accept its LAN name, got %d", resp.StatusCode)
+	}
+}
+
+func TestTags(t *testing.T) {
+	ts := setupTestServer(t)
+
+	var p service.Project
+	do(t, ts, "POST", "/api/projects", `{"name": "Website", "tags": ["acme", "web"]}`, http.StatusCreated, &p)
+	if len(p.Tags) != 2 || p.Tags[0] != "acme" {
+		t.Errorf("expected the project to be tagged, got %+v", p.Tags)
+	}
+	do(t, ts, "POST", "/api/projects", `{"name": "Garden"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects", `{"name": "Bad", "tags": ["two words"]}`, http.StatusBadRequest, nil)
+
+	var task service.Task
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Copy", "tags": ["acme"]}`, http.StatusCreated, &task)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Footer"}`, http.StatusCreated, nil)
+	do(t, ts, "PATCH", "/api/projects/1", `{"tags": ["acme"]}`, http.StatusOK, &p)
+	if len(p.Tags) != 1 {
+		t.Errorf("expected PATCH to replace the tags, got %+v", p.Tags)
+	}
+
+	var projects []service.Project
+	do(t, ts, "GET", "/api/projects?tag=ACME", "", http.StatusOK, &projects)
+	if len(projects) != 1 || projects[0].Name != "Website" {
+		t.Errorf("expected only the tagged project, got %+v", projects)
+	}
+	var tasks []service.Task
+	do(t, ts, "GET", "/api/tasks?tag=acme", "", http.StatusOK, &tasks)
+	if len(tasks) != 1 || tasks[0].ID != task.ID {
+		t.Errorf("expected only the tagged task, got %+v", tasks)
+	}
+
+	var tags []service.Tag
+	do(t, ts, "GET", "/api/tags", "", http.StatusOK, &tags)
+	if len(tags) != 1 || tags[0].Name != "acme" || tags[0].Projects != 1 || tags[0].Tasks != 1 {
+		t.Errorf("unexpected tags: %+v", tags)
+	}
+
+	// A project also matches when only its tasks carry the tag.
+	do(t, ts, "POST", "/api/projects/2/tasks", `{"title": "Sow", "tags": ["spring"]}`, http.StatusCreated, nil)
+	do(t, ts, "GET", "/api/projects?tag=spring", "", http.StatusOK, &projects)
+	if len(projects) != 1 || projects[0].Name != "Garden" {
+		t.Errorf("expected the project holding the tagged task, got %+v", projects)
+	}
+}
+
+func TestTimer(t *testing.T) {
+	ts := setupTestServer(t)
+	do(t, ts, "POST", "/api/projects", `{"name": "Client site"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Design"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Build"}`, http.StatusCreated, nil)
+
+	var running *service.TimeEntry
+	do(t, ts, "GET", "/api/timer", "", http.StatusOK, &running)
+	if running != nil {
+		t.Errorf("expected no running timer, got %+v", running)
+	}
+	do(t, ts, "DELETE", "/api/timer", "", http.StatusNotFound, nil)
+	do(t, ts, "POST", "/api/tasks/99/timer", "", http.StatusNotFound, nil)
+
+	do(t, ts, "POST", "/api/tasks/1/timer", "", http.StatusOK, &running)
+	if running == nil || running.TaskID != 1 || running.ProjectName != "Client site" || running.EndedAt != nil {
+		t.Errorf("expected the design timer running, got %+v", running)
+	}
+	do(t, ts, "POST", "/api/tasks/2/timer", "", http.StatusOK, &running)
+	if running == nil || running.TaskID != 2 {
+		t.Errorf("expected the build timer running, got %+v", running)
+	}
+
+	var stopped service.TimeEntry
+	do(t, ts, "DELETE", "/api/timer", "", http.StatusOK, &stopped)
+	if stopped.TaskID != 2 || stopped.EndedAt == nil {
+		t.Errorf("expected the build timer stopped, got %+v", stopped)
+	}
+
+	var entries []service.TimeEntry
+	do(t, ts, "GET", "/api/projects/1/time-entries", "", http.StatusOK, &entries)
+	if len(entries) != 2 || entries[0].TaskID != 1 || entries[0].EndedAt == nil {
+		t.Errorf("expected both entries stopped, got %+v", entries)
+	}
+	do(t, ts, "GET", "/api/projects/9/time-entries", "", http.StatusNotFound, nil)
+}
+
+func TestMilestones(t *testing.T) {
+	ts := setupTestServer(t)
+	do(t, ts, "POST", "/api/projects", `{"name": "Addae"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects", `{"name": "Other"}`, http.StatusCreated, nil)
+
+	var milestones []service.Milestone
+	do(t, ts, "GET", "/api/projects/1/milestones", "", http.StatusOK, &milestones)
+	if milestones == nil || len(milestones) != 0 {
+		t.Errorf("expected an empty list, got %+v", milestones)
+	}
+	do(t, ts, "GET", "/api/projects/9/milestones", "", http.StatusNotFound, nil)
+	do(t, ts, "POST", "/api/projects/1/milestones", `{"name": " "}`, http.StatusBadRequest, nil)
+	do(t, ts, "POST", "/api/projects/1/milestones", `{"name": "v1", "target_date": "soon"}`, http.StatusBadRequest, nil)
+	do(t, ts, "POST", "/api/projects/9/milestones", `{"name": "v1"}`, http.StatusNotFound, nil)
+
+	var m service.Milestone
+	do(t, ts, "POST", "/api/projects/1/milestones", `{"name": "v1", "target_date": "2026-12-01"}`, http.StatusCreated, &m)
+	if m.ID != 1 || m.ProjectID != 1 || m.TargetDate == nil || m.TargetDate.Format("2006-01-02") != "2026-12-01" {
+		t.Errorf("unexpected milestone: %+v", m)
+	}
+	do(t, ts, "POST", "/api/projects/2/milestones", `{"name": "elsewhere"}`, http.StatusCreated, nil)
+
+	var task service.Task
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Schema", "milestone_id": 1}`, http.StatusCreated, &task)
+	if task.MilestoneID == nil || *task.MilestoneID != 1 {
+		t.Errorf("expected the task in milestone 1, got %+v", task)
+	}
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Docs", "milestone_id": 2}`, http.StatusBadRequest, nil)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Docs", "milestone_id": 9}`, http.StatusNotFound, nil)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Docs"}`, http.StatusCreated, nil)
+	do(t, ts, "PATCH", "/api/tasks/2", `{"milestone_id": 1, "completed": true}`, http.StatusOK, nil)
+	do(t, ts, "PATCH", "/api/tasks/2", `{"milestone_id": 2}`, http.StatusBadRequest, nil)
+
+	do(t, ts, "GET", "/api/milestones/1", "", http.StatusOK, &m)
+	if m.Tasks != 2 || m.Done != 1 {
+		t.Errorf("expected 1 of 2 tasks done, got %+v", m)
+	}
+	do(t, ts, "PATCH", "/api/milestones/1", `{"name": "v1.0", "target_date": "", "desc": "First release"}`, http.StatusOK, &m)
+	if m.Name != "v1.0" || m.TargetDate != nil || m.Desc != "First release" {
+		t.Errorf("unexpected milestone after update: %+v", m)
+	}
+	do(t, ts, "PATCH", "/api/milestones/9", `{"name": "v2"}`, http.StatusNotFound, nil)
+
+	do(t, ts, "PATCH", "/api/tasks/1", `{"milestone_id": 0}`, http.StatusOK, &task)
+	if task.MilestoneID != nil {
+		t.Errorf("expected the milestone cleared, got %d", *task.MilestoneID)
+	}
+
+	do(t, ts, "DELETE", "/api/milestones/1", "", http.StatusNoContent, nil)
+	do(t, ts, "DELETE", "/api/milestones/1", "", http.StatusNotFound, nil)
+	do(t, ts, "GET", "/api/tasks/2", "", http.StatusOK, &task)
+	if task.MilestoneID != nil {
+		t.Errorf("expected the task to leave the deleted milestone, got %d", *task.MilestoneID)
+	}
+}
+
+func TestStates(t *testing.T) {
+	ts := setupTestServer(t)
+	do(t, ts, "POST", "/api/projects", `{"name": "Addae"}`, http.StatusCreated, nil)
+
+	var states []string
+	do(t, ts, "GET", "/api/states", "", http.StatusOK, &states)
+	if len(states) == 0 || states[0] != "todo" || states[len(states)-1] != service.StateDone {
+		t.Errorf("unexpected states: %v", states)
+	}
+
+	var task service.Task
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Schema", "state": "in progress"}`, http.StatusCreated, &task)
+	if task.State != "in progress" || task.CompletedAt != nil {
+		t.Errorf("expected the task in progress, got %+v", task)
+	}
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Docs", "state": "nope"}`, http.StatusBadRequest, nil)
+	do(t, ts, "PATCH", "/api/tasks/1", `{"state": "nope"}`, http.StatusBadRequest, nil)
+
+	do(t, ts, "PATCH", "/api/tasks/1", `{"state": "done"}`, http.StatusOK, &task)
+	if task.State != service.StateDone || task.CompletedAt == nil {
+		t.Errorf("expected the task completed in done, got %+v", task)
+	}
+	do(t, ts, "PATCH", "/api/tasks/1", `{"completed": false}`, http.StatusOK, &task)
+	if task.State != "todo" || task.CompletedAt != nil {
+		t.Errorf("expected the reopened task in todo, got %+v", task)
+	}
+
+	var history []service.StateChange
+	do(t, ts, "GET", "/api/tasks/1/states", "", http.StatusOK, &history)
+	var got []string
+	for _, c := range history {
+		got = append(got, c.State)
+	}
+	if strings.Join(got, ",") != "todo,in progress,done,todo" {
+		t.Errorf("unexpected state history: %v", got)
+	}
+	do(t, ts, "GET", "/api/tasks/9/states", "", http.StatusNotFound, nil)
+}
+
+func TestProjectParents(t *testing.T) {
+	ts := setupTestServer(t)
+	do(t, ts, "POST", "/api/projects", `{"name": "OSS"}`, http.StatusCreated, nil)
+
+	var p service.Project
+	do(t, ts, "POST", "/api/projects", `{"name": "addae", "parent_id": 1}`, http.StatusCreated, &p)
+	if p.ParentID == nil || *p.ParentID != 1 {
+		t.Errorf("expected the project under OSS, got %+v", p)
+	}
+	do(t, ts, "POST", "/api/projects", `{"name": "Docs", "parent_id": 9}`, http.StatusNotFound, nil)
+	do(t, ts, "POST", "/api/projects/2/tasks", `{"title": "Release", "completed": true}`, http.StatusCreated, nil)
+
+	do(t, ts, "GET", "/api/projects/1", "", http.StatusOK, &p)
+	if p.Tasks != 1 || p.Done != 1 {
+		t.Errorf("expected the task of the subproject rolled up, got %+v", p)
+	}
+	do(t, ts, "PATCH", "/api/projects/1", `{"parent_id": 2}`, http.StatusBadRequest, nil)
+	do(t, ts, "PATCH", "/api/projects/2", `{"parent_id": 0}`, http.StatusOK, &p)
+	if p.ParentID != nil {
+		t.Errorf("expected a top-level project, got %+v", p)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	ts := setupTestServer(t)
+	do(t, ts, "POST", "/api/projects", `{"name": "Addae"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Schema"}`, http.StatusCreated, nil)
+
+	var l service.Link
+	do(t, ts, "POST", "/api/projects/1/links", `{"label": "Design doc", "target": "https://example.com/design"}`,
+		http.StatusCreated, &l)
+	if l.ID != 1 || l.ProjectID == nil || l.Label != "Design doc" {
+		t.Errorf("unexpected link: %+v", l)
+	}
+	do(t, ts, "POST", "/api/tasks/1/links", `{"target": "https://example.com/pull/42"}`, http.StatusCreated, &l)
+	if l.TaskID == nil || *l.TaskID != 1 || l.Label != "https://example.com/pull/42" {
+		t.Errorf("expected the label to default to the target, got %+v", l)
+	}
+	do(t, ts, "POST", "/api/tasks/1/links", `{"label": "Nothing"}`, http.StatusBadRequest, nil)
+	do(t, ts, "POST", "/api/tasks/9/links", `{"target": "https://example.com"}`, http.StatusNotFound, nil)
+	do(t, ts, "POST", "/api/projects/9/links", `{"target": "https://example.com"}`, http.StatusNotFound, nil)
+
+	var links []service.Link
+	do(t, ts, "GET", "/api/projects/1/links", "", http.StatusOK, &links)
+	if len(links) != 2 {
+		t.Errorf("expected the project's and the task's links, got %+v", links)
+	}
+	do(t, ts, "GET", "/api/tasks/1/links", "", http.StatusOK, &links)
+	if len(links) != 1 || links[0].ID != 2 {
+		t.Errorf("expected the task's link, got %+v", links)
+	}
+	do(t, ts, "GET", "/api/tasks/9/links", "", http.StatusNotFound, nil)
+
+	do(t, ts, "DELETE", "/api/links/1", "", http.StatusNoContent, nil)
+	do(t, ts, "DELETE", "/api/links/1", "", http.StatusNotFound, nil)
+}
+
+func TestAttachments(t *testing.T) {
+	ts := setupTestServer(t)
+	do(t, ts, "POST", "/api/projects", `{"name": "Addae"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Fix login"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects/1/logs", `{"title": "Outage"}`, http.StatusCreated, nil)
+
+	var att service.Attachment
+	do(t, ts, "POST", "/api/tasks/1/attachments?name=config.yaml", "port: 8080\n", http.StatusCreated, &att)
+	if att.ID != 1 || att.TaskID == nil || att.Name != "config.yaml" || att.Size != 11 || len(att.SHA256) != 64 {
+		t.Errorf("unexpected attachment: %+v", att)
+	}
+	do(t, ts, "POST", "/api/logs/1/attachments?name=trace&mime_type=text/x-log", "panic: nil map", http.StatusCreated, &att)
+	if att.LogID == nil || att.MIMEType != "text/x-log" {
+		t.Errorf("expected the given MIME type, got %+v", att)
+	}
+	do(t, ts, "POST", "/api/tasks/1/attachments", "data", http.StatusBadRequest, nil)
+	do(t, ts, "POST", "/api/logs/9/attachments?name=x.txt", "data", http.StatusNotFound, nil)
+
+	var attachments []service.Attachment
+	do(t, ts, "GET", "/api/tasks/1/attachments", "", http.StatusOK, &attachments)
+	if len(attachments) != 1 || attachments[0].Name != "config.yaml" {
+		t.Errorf("expected the task's attachment, got %+v", attachments)
+	}
+	do(t, ts, "GET", "/api/logs/1/attachments", "", http.StatusOK, &attachments)
+	if len(attachments) != 1 || attachments[0].Name != "trace" {
+		t.Errorf("expected the log's attachment, got %+v", attachments)
+	}
+	do(t, ts, "GET", "/api/tasks/9/attachments", "", http.StatusNotFound, nil)
+
+	resp, err := ts.Client().Get(ts.URL + "/api/attachments/1/content")
+	if err != nil {
+		t.Fatalf("GET content failed: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(data) != "port: 8080\n" || !strings.Contains(resp.Header.Get("Content-Disposition"), "config.yaml") {
+		t.Errorf("expected the file back, got %q with %v", data, resp.Header)
+	}
+
+	do(t, ts, "DELETE", "/api/attachments/1", "", http.StatusNoContent, nil)
+	do(t, ts, "GET", "/api/attachments/1", "", http.StatusNotFound, nil)
+}
+
+func TestLogTasks(t *testing.T) {
+	ts := setupTestServer(t)
+	do(t, ts, "POST", "/api/projects", `{"name": "Addae"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects", `{"name": "Other"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "Schema"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects/1/tasks", `{"title": "API"}`, http.StatusCreated, nil)
+	do(t, ts, "POST", "/api/projects/2/tasks", `{"title": "Elsewhere"}`, http.StatusCreated, nil)
+
+	var l service.Log
+	do(t, ts, "POST", "/api/projects/1/logs", `{"title": "Monday", "task_ids": [1]}`, http.StatusCreated, &l)
+	if len(l.TaskIDs) != 1 || l.TaskIDs[0] != 1 {
+		t.Errorf("expected Monday linked to task 1, got %+v", l)
+	}
+	do(t, ts, "POST", "/api/projects/1/logs", `{"title": "Nope", "task_ids": [3]}`, http.StatusBadRequest, nil)
+	do(t, ts, "POST", "/api/projects/1/logs", `{"title": "Nope", "task_ids": [9]}`, http.StatusNotFound, nil)
+
+	do(t, ts, "PATCH", "/api/logs/1", `{"task_ids": [2, 1]}`, http.StatusOK, &l)
+	if len(l.TaskIDs) != 2 || l.TaskIDs[0] != 1 || l.TaskIDs[1] != 2 {
+		t.Errorf("expected Monday linked to tasks 1 and 2, got %+v", l)
+	}
+	do(t, ts, "PATCH", "/api/logs/1", `{"task_ids": [3]}`, http.StatusBadRequest, nil)
+
+	var logs []service.Log
+	do(t, ts, "GET", "/api/tasks/2/logs", "", http.StatusOK, &logs)
+	if len(logs) != 1 || logs[0].Title != "Monday" {
+		t.Errorf("expected Monday among task 2's logs, got %+v", logs)
+	}
+	do(t, ts, "GET", "/api/tasks/3/logs", "", http.StatusOK, &logs)
+	if len(logs) != 0 {
+		t.Errorf("expected no logs for task 3, got %+v", logs)
+	}
+	do(t, ts, "GET", "/api/tasks/9/logs", "", http.StatusNotFound, nil)
+}
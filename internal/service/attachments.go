@@ -0,0 +1,239 @@
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxAttachmentSize is the largest attachment accepted unless
+// SetMaxAttachmentSize says otherwise. Attachments live in the database, so
+// they are meant for screenshots, logs and snippets rather than large files.
+const DefaultMaxAttachmentSize = 5 << 20
+
+// Attachment is a small file stored in the database on a task or a log.
+// Exactly one of TaskID and LogID is set. The content itself is read with
+// ReadAttachment.
+type Attachment struct {
+	ID          int       `json:"id"`
+	TaskID      *int      `json:"task_id"`
+	LogID       *int      `json:"log_id"`
+	Name        string    `json:"name"`
+	MIMEType    string    `json:"mime_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	DateCreated time.Time `json:"created_at"`
+}
+
+const attachmentColumns = `
+	SELECT id, task_id, log_id, name, mime_type, size, sha256, date_created FROM attachments
+`
+
+func scanAttachment(row interface{ Scan(...any) error }) (*Attachment, error) {
+	var a Attachment
+	err := row.Scan(&a.ID, &a.TaskID, &a.LogID, &a.Name, &a.MIMEType, &a.Size, &a.SHA256, &a.DateCreated)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// MaxAttachmentSize returns the largest attachment accepted, in bytes.
+func (s *Service) MaxAttachmentSize() int64 {
+	return s.maxAttachmentSize
+}
+
+// SetMaxAttachmentSize changes the largest attachment accepted. Attachments
+// already stored are kept whatever their size.
+func (s *Service) SetMaxAttachmentSize(n int64) {
+	s.maxAttachmentSize = n
+}
+
+// AddAttachment stores data as an attachment on the task or log a names.
+// The name is reduced to its base name, and the MIME type is guessed from
+// the name or the content when a leaves it empty. Size and SHA256 are
+// filled in from data.
+func (s *Service) AddAttachment(a *Attachment, data []byte) error {
+	if (a.TaskID == nil) == (a.LogID == nil) {
+		return fmt.Errorf("%w attachment: it must be on either a task or a log", ErrInvalid)
+	}
+	name := filepath.Base(strings.TrimSpace(a.Name))
+	if name == "." || name == string(filepath.Separator) {
+		return fmt.Errorf("%w attachment: a file name is required", ErrInvalid)
+	}
+	if size := int64(len(data)); size > s.maxAttachmentSize {
+		return fmt.Errorf("%w attachment: %s is %s, over the %s limit", ErrInvalid,
+			name, FormatSize(size), FormatSize(s.maxAttachmentSize))
+	}
+	mimeType := strings.TrimSpace(a.MIMEType)
+	if mimeType == "" {
+		mimeType = detectMIMEType(name, data)
+	}
+	sum := sha256.Sum256(data)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if a.TaskID != nil {
+		_, err = taskProject(tx, *a.TaskID, "task")
+	} else {
+		_, err = logProject(tx, *a.LogID)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO attachments (task_id, log_id, name, mime_type, size, sha256, data, date_created)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, a.TaskID, a.LogID, name, mimeType, len(data), hex.EncodeToString(sum[:]), data)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	created, err := scanAttachment(tx.QueryRow(attachmentColumns+"WHERE id = ?", id))
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*a = *created
+	return nil
+}
+
+// detectMIMEType guesses a file's MIME type from its extension, falling back
+// to sniffing its content.
+func detectMIMEType(name string, data []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(data)
+}
+
+func (s *Service) GetAttachment(id int) (*Attachment, error) {
+	a, err := scanAttachment(s.db.QueryRow(attachmentColumns+"WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("attachment %w", ErrNotFound)
+	}
+	return a, err
+}
+
+// ReadAttachment returns the content of an attachment, checking it against
+// the SHA-256 hash taken when it was added.
+func (s *Service) ReadAttachment(id int) ([]byte, error) {
+	var data []byte
+	var want string
+	err := s.db.QueryRow("SELECT data, sha256 FROM attachments WHERE id = ?", id).Scan(&data, &want)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("attachment %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != want {
+		return nil, fmt.Errorf("attachment %d is corrupt: its content does not match its SHA-256 hash", id)
+	}
+	return data, nil
+}
+
+func (s *Service) DeleteAttachment(id int) error {
+	result, err := s.db.Exec("DELETE FROM attachments WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("attachment %w", ErrNotFound)
+	}
+	return nil
+}
+
+// ListTaskAttachments returns the attachments on a task in the order they
+// were added.
+func (s *Service) ListTaskAttachments(taskID int) ([]Attachment, error) {
+	return s.queryAttachments("WHERE task_id = ? ORDER BY id", taskID)
+}
+
+// ListLogAttachments returns the attachments on a log in the order they
+// were added.
+func (s *Service) ListLogAttachments(logID int) ([]Attachment, error) {
+	return s.queryAttachments("WHERE log_id = ? ORDER BY id", logID)
+}
+
+func (s *Service) queryAttachments(where string, args ...any) ([]Attachment, error) {
+	rows, err := s.db.Query(attachmentColumns+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *a)
+	}
+	return attachments, rows.Err()
+}
+
+// sizeUnits are the units ParseSize accepts and FormatSize writes, largest
+// first. They count in powers of 1024.
+var sizeUnits = []struct {
+	name  string
+	bytes int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a size in bytes, or with a KB, MB or GB suffix, as in
+// "512KB" or "5MB".
+func ParseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	for _, u := range sizeUnits {
+		if number, ok := strings.CutSuffix(upper, u.name); ok {
+			n, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+			if err != nil || n < 0 {
+				break
+			}
+			return int64(n * float64(u.bytes)), nil
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected bytes or a size like 512KB or 5MB", s)
+	}
+	return n, nil
+}
+
+// FormatSize writes a size in bytes with the largest unit that keeps it at
+// least 1, as in "1.5 MB".
+func FormatSize(n int64) string {
+	for _, u := range sizeUnits {
+		if n >= u.bytes && u.bytes > 1 {
+			return strconv.FormatFloat(float64(n)/float64(u.bytes), 'f', 1, 64) + " " + u.name
+		}
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// SetTaskBlockers replaces the tasks blocking a task. A task stays blocked
+// until all of its blockers are completed. Blockers must be other tasks of the
+// same project that are not themselves waiting on the task, and none are
+// changed if one is rejected.
+func (s *Service) SetTaskBlockers(taskID int, blockerIDs []int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	projectID, err := taskProject(tx, taskID, "task")
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM task_dependencies WHERE task_id = ?", taskID); err != nil {
+		return err
+	}
+	for _, blockerID := range blockerIDs {
+		if err := checkBlocker(tx, projectID, taskID, blockerID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO task_dependencies (task_id, blocked_by_task_id) VALUES (?, ?)
+		`, taskID, blockerID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// IsBlocked reports whether any of the tasks blocking t, looked up in tasks,
+// is still pending.
+func IsBlocked(t Task, tasks []Task) bool {
+	if t.CompletedAt != nil {
+		return false
+	}
+	for _, id := range t.BlockedBy {
+		for _, blocker := range tasks {
+			if blocker.ID == id && blocker.CompletedAt == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// taskProject returns the project of a task, naming it kind when it does not
+// exist.
+func taskProject(tx *sql.Tx, taskID int, kind string) (int, error) {
+	var projectID int
+	err := tx.QueryRow("SELECT project_id FROM tasks WHERE id = ?", taskID).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%s %w", kind, ErrNotFound)
+	}
+	return projectID, err
+}
+
+// checkBlocker checks that blockerID may block taskID, a task of projectID.
+func checkBlocker(tx *sql.Tx, projectID, taskID, blockerID int) error {
+	if blockerID == taskID {
+		return fmt.Errorf("%w dependency: task %d cannot block itself", ErrInvalid, taskID)
+	}
+	blockerProjectID, err := taskProject(tx, blockerID, "blocking task")
+	if err != nil {
+		return err
+	}
+	if blockerProjectID != projectID {
+		return fmt.Errorf("%w dependency: task %d is in another project", ErrInvalid, blockerID)
+	}
+
+	// Follow what the blocker waits on: meeting the task closes a cycle.
+	var loops int
+	err = tx.QueryRow(`
+		WITH RECURSIVE waits(id) AS (
+			SELECT ?
+			UNION
+			SELECT d.blocked_by_task_id FROM task_dependencies d JOIN waits w ON d.task_id = w.id
+		)
+		SELECT COUNT(*) FROM waits WHERE id = ?
+	`, blockerID, taskID).Scan(&loops)
+	if err != nil {
+		return err
+	}
+	if loops > 0 {
+		return fmt.Errorf("%w dependency: task %d already waits on task %d", ErrInvalid, blockerID, taskID)
+	}
+	return nil
+}
+
+// loadBlockers returns the sorted IDs of the tasks blocking the tasks matched
+// by where, keyed by task ID. where may refer to the dependencies as d.
+func (s *Service) loadBlockers(where string, args ...any) (map[int][]int, error) {
+	rows, err := s.db.Query("SELECT d.task_id, d.blocked_by_task_id FROM task_dependencies d "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blockers := make(map[int][]int)
+	for rows.Next() {
+		var id, blockerID int
+		if err := rows.Scan(&id, &blockerID); err != nil {
+			return nil, err
+		}
+		blockers[id] = append(blockers[id], blockerID)
+	}
+	for _, ids := range blockers {
+		sort.Ints(ids)
+	}
+	return blockers, rows.Err()
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DueDateLayout is the layout due dates are entered and printed with.
+const DueDateLayout = "2006-01-02"
+
+// ParseDueDate parses a due date entered relative to now. It accepts a date
+// such as 2025-03-01, "today", "tomorrow", or a number of days or weeks ahead
+// such as "3d" or "2w". An empty string means no due date. Due dates fall at
+// midnight in now's location.
+func ParseDueDate(s string, now time.Time) (*time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return nil, nil
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var due time.Time
+	switch {
+	case s == "today":
+		due = today
+	case s == "tomorrow":
+		due = today.AddDate(0, 0, 1)
+	case strings.HasSuffix(s, "d") || strings.HasSuffix(s, "w"):
+		n, err := strconv.Atoi(strings.TrimPrefix(s[:len(s)-1], "+"))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid due date %q", s)
+		}
+		if strings.HasSuffix(s, "w") {
+			n *= 7
+		}
+		due = today.AddDate(0, 0, n)
+	default:
+		var err error
+		if due, err = time.ParseInLocation(DueDateLayout, s, now.Location()); err != nil {
+			return nil, fmt.Errorf("invalid due date %q, expected YYYY-MM-DD, today, tomorrow or a number of days or weeks like 3d or 2w", s)
+		}
+	}
+	return &due, nil
+}
+
+// DaysUntil returns the number of calendar days from now until due, in now's
+// location. It is negative once due has passed and 0 on the day itself.
+func DaysUntil(due, now time.Time) int {
+	y, m, d := due.In(now.Location()).Date()
+	dueDay := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	y, m, d = now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return int(dueDay.Sub(today).Hours() / 24)
+}
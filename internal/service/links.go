@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Link is a labelled reference from a project or a task to a URL or a local
+// file, such as a design doc, a pull request or a dashboard. Exactly one of
+// ProjectID and TaskID is set.
+type Link struct {
+	ID          int       `json:"id"`
+	ProjectID   *int      `json:"project_id"`
+	TaskID      *int      `json:"task_id"`
+	Label       string    `json:"label"`
+	Target      string    `json:"target"`
+	DateCreated time.Time `json:"created_at"`
+}
+
+// URL returns the link's target as a URL, turning a file path into a
+// file:// URL.
+func (l Link) URL() string {
+	if isURL(l.Target) {
+		return l.Target
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(l.Target)}).String()
+}
+
+// isURL reports whether target starts with a URL scheme such as https: or
+// mailto:. Anything else is a file path.
+func isURL(target string) bool {
+	u, err := url.Parse(target)
+	// A single letter is a Windows drive, not a scheme.
+	return err == nil && len(u.Scheme) > 1
+}
+
+// linkTarget trims target and makes a file path absolute, expanding a
+// leading ~, so the link opens the same file from any directory.
+func linkTarget(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", fmt.Errorf("%w link: a URL or file path is required", ErrInvalid)
+	}
+	if isURL(target) {
+		return target, nil
+	}
+	if target == "~" || strings.HasPrefix(target, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("%w link: %v", ErrInvalid, err)
+		}
+		target = filepath.Join(home, target[1:])
+	}
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return "", fmt.Errorf("%w link: %v", ErrInvalid, err)
+	}
+	return abs, nil
+}
+
+// AddLink adds l to the project or task it names. The label defaults to the
+// target, and a file path is stored as an absolute path.
+func (s *Service) AddLink(l *Link) error {
+	if (l.ProjectID == nil) == (l.TaskID == nil) {
+		return fmt.Errorf("%w link: it must be on either a project or a task", ErrInvalid)
+	}
+	target, err := linkTarget(l.Target)
+	if err != nil {
+		return err
+	}
+	label := strings.TrimSpace(l.Label)
+	if label == "" {
+		label = target
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if l.ProjectID != nil {
+		err = projectExists(tx, *l.ProjectID, "project")
+	} else {
+		_, err = taskProject(tx, *l.TaskID, "task")
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO links (project_id, task_id, label, target, date_created)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, l.ProjectID, l.TaskID, label, target)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	var created time.Time
+	if err := tx.QueryRow("SELECT date_created FROM links WHERE id = ?", id).Scan(&created); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	l.ID, l.Label, l.Target, l.DateCreated = int(id), label, target, created
+	return nil
+}
+
+// DeleteLink deletes a link.
+func (s *Service) DeleteLink(id int) error {
+	result, err := s.db.Exec("DELETE FROM links WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("link %w", ErrNotFound)
+	}
+	return nil
+}
+
+// ListProjectLinks returns the links on a project and on its tasks, the
+// project's own first, each in the order they were added.
+func (s *Service) ListProjectLinks(projectID int) ([]Link, error) {
+	return s.queryLinks(`
+		WHERE project_id = ? OR task_id IN (SELECT id FROM tasks WHERE project_id = ?)
+		ORDER BY task_id IS NOT NULL, task_id, id
+	`, projectID, projectID)
+}
+
+// ListTaskLinks returns the links on a task in the order they were added.
+func (s *Service) ListTaskLinks(taskID int) ([]Link, error) {
+	return s.queryLinks("WHERE task_id = ? ORDER BY id", taskID)
+}
+
+func (s *Service) queryLinks(where string, args ...any) ([]Link, error) {
+	rows, err := s.db.Query("SELECT id, project_id, task_id, label, target, date_created FROM links "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []Link
+	for rows.Next() {
+		var l Link
+		if err := rows.Scan(&l.ID, &l.ProjectID, &l.TaskID, &l.Label, &l.Target, &l.DateCreated); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
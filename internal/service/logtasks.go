@@ -0,0 +1,111 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// SetLogTasks replaces the tasks a log is about, such as the ones it records
+// work on. They must be tasks of the log's project, and none are changed if
+// one is rejected.
+func (s *Service) SetLogTasks(logID int, taskIDs []int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	projectID, err := logProject(tx, logID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM log_tasks WHERE log_id = ?", logID); err != nil {
+		return err
+	}
+	for _, taskID := range taskIDs {
+		taskProjectID, err := taskProject(tx, taskID, "task")
+		if err != nil {
+			return err
+		}
+		if taskProjectID != projectID {
+			return fmt.Errorf("%w log: task %d is in another project", ErrInvalid, taskID)
+		}
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO log_tasks (log_id, task_id) VALUES (?, ?)
+		`, logID, taskID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListTaskLogs returns the logs linked to a task in the order they were
+// written.
+func (s *Service) ListTaskLogs(taskID int) ([]Log, error) {
+	rows, err := s.db.Query(`
+		SELECT l.id, l.project_id, l.title, l.desc, l.date_created, l.date_updated
+		FROM logs l JOIN log_tasks lt ON lt.log_id = l.id
+		WHERE lt.task_id = ?
+		ORDER BY l.id
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []Log
+	for rows.Next() {
+		var l Log
+		if err := rows.Scan(&l.ID, &l.ProjectID, &l.Title, &l.Desc, &l.DateCreated, &l.DateUpdated); err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tasks, err := s.loadLogTasks("WHERE lt.log_id IN (SELECT log_id FROM log_tasks WHERE task_id = ?)", taskID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range logs {
+		logs[i].TaskIDs = tasks[logs[i].ID]
+	}
+	return logs, nil
+}
+
+// logProject returns the project of a log.
+func logProject(tx *sql.Tx, logID int) (int, error) {
+	var projectID int
+	err := tx.QueryRow("SELECT project_id FROM logs WHERE id = ?", logID).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("log %w", ErrNotFound)
+	}
+	return projectID, err
+}
+
+// loadLogTasks returns the sorted IDs of the tasks linked to the logs matched
+// by where, keyed by log ID. where may refer to the links as lt.
+func (s *Service) loadLogTasks(where string, args ...any) (map[int][]int, error) {
+	rows, err := s.db.Query("SELECT lt.log_id, lt.task_id FROM log_tasks lt "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := make(map[int][]int)
+	for rows.Next() {
+		var logID, taskID int
+		if err := rows.Scan(&logID, &taskID); err != nil {
+			return nil, err
+		}
+		tasks[logID] = append(tasks[logID], taskID)
+	}
+	for _, ids := range tasks {
+		sort.Ints(ids)
+	}
+	return tasks, rows.Err()
+}
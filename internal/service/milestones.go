@@ -0,0 +1,167 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Milestone is a target inside a project, such as a release, that tasks can
+// be assigned to.
+type Milestone struct {
+	ID         int        `json:"id"`
+	ProjectID  int        `json:"project_id"`
+	Name       string     `json:"name"`
+	TargetDate *time.Time `json:"target_date"`
+	Desc       string     `json:"desc"`
+	// Tasks and Done count the tasks assigned to the milestone and how many
+	// of them are completed. They are filled in when milestones are read.
+	Tasks       int       `json:"tasks"`
+	Done        int       `json:"done"`
+	DateCreated time.Time `json:"created_at"`
+	DateUpdated time.Time `json:"updated_at"`
+}
+
+const milestoneColumns = `
+	SELECT m.id, m.project_id, m.name, m.target_date, m.desc,
+		(SELECT COUNT(*) FROM tasks t WHERE t.milestone_id = m.id),
+		(SELECT COUNT(*) FROM tasks t WHERE t.milestone_id = m.id AND t.completed_at IS NOT NULL),
+		m.date_created, m.date_updated
+	FROM milestones m
+`
+
+func scanMilestone(row interface{ Scan(...any) error }) (*Milestone, error) {
+	var m Milestone
+	err := row.Scan(&m.ID, &m.ProjectID, &m.Name, &m.TargetDate, &m.Desc, &m.Tasks, &m.Done,
+		&m.DateCreated, &m.DateUpdated)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *Service) CreateMilestone(m *Milestone) error {
+	result, err := s.db.Exec(`
+		INSERT INTO milestones (project_id, name, target_date, desc, date_created, date_updated)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, m.ProjectID, m.Name, m.TargetDate, m.Desc)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	m.ID = int(id)
+	return nil
+}
+
+func (s *Service) GetMilestone(id int) (*Milestone, error) {
+	m, err := scanMilestone(s.db.QueryRow(milestoneColumns+"WHERE m.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("milestone %w", ErrNotFound)
+	}
+	return m, err
+}
+
+// UpdateMilestone changes a milestone's name, target date and description.
+func (s *Service) UpdateMilestone(m *Milestone) error {
+	result, err := s.db.Exec(`
+		UPDATE milestones SET name = ?, target_date = ?, desc = ?, date_updated = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, m.Name, m.TargetDate, m.Desc, m.ID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("milestone %w", ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteMilestone deletes a milestone. Its tasks stay, without a milestone.
+func (s *Service) DeleteMilestone(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM milestones WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("milestone %w", ErrNotFound)
+	}
+	if _, err := tx.Exec("UPDATE tasks SET milestone_id = NULL WHERE milestone_id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListProjectMilestones returns a project's milestones by target date, with
+// undated ones last.
+func (s *Service) ListProjectMilestones(projectID int) ([]Milestone, error) {
+	rows, err := s.db.Query(milestoneColumns+`
+		WHERE m.project_id = ?
+		ORDER BY m.target_date IS NULL, m.target_date, m.id
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var milestones []Milestone
+	for rows.Next() {
+		m, err := scanMilestone(rows)
+		if err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, *m)
+	}
+	return milestones, rows.Err()
+}
+
+// SetTaskMilestone assigns a task to a milestone of its project, or takes it
+// off its milestone when milestoneID is nil.
+func (s *Service) SetTaskMilestone(taskID int, milestoneID *int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	projectID, err := taskProject(tx, taskID, "task")
+	if err != nil {
+		return err
+	}
+	if milestoneID != nil {
+		var milestoneProjectID int
+		err := tx.QueryRow("SELECT project_id FROM milestones WHERE id = ?", *milestoneID).Scan(&milestoneProjectID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("milestone %w", ErrNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		if milestoneProjectID != projectID {
+			return fmt.Errorf("%w milestone: milestone %d is in another project", ErrInvalid, *milestoneID)
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE tasks SET milestone_id = ?, date_updated = CURRENT_TIMESTAMP WHERE id = ?
+	`, milestoneID, taskID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
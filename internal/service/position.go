@@ -0,0 +1,70 @@
+package service
+
+import (
+	"database/sql"
+	"slices"
+)
+
+// MoveTask moves a task to position in its project's manual order, counted
+// from 0, shifting the tasks in between. A position past the end moves the
+// task to the end. Positions are renumbered so they stay dense and unique.
+func (s *Service) MoveTask(taskID, position int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	projectID, err := taskProject(tx, taskID, "task")
+	if err != nil {
+		return err
+	}
+	order, err := taskOrder(tx, projectID)
+	if err != nil {
+		return err
+	}
+
+	order = slices.DeleteFunc(order, func(id int) bool { return id == taskID })
+	position = max(0, min(position, len(order)))
+	order = slices.Insert(order, position, taskID)
+	if err := renumberTasks(tx, projectID, order); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// taskOrder returns the IDs of a project's tasks in their manual order.
+func taskOrder(tx *sql.Tx, projectID int) ([]int, error) {
+	rows, err := tx.Query("SELECT id FROM tasks WHERE project_id = ? ORDER BY position, id", projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// renumberTasks gives a project's tasks the positions 0, 1, 2... in the
+// order of ids, or in their current order when ids is nil.
+func renumberTasks(tx *sql.Tx, projectID int, ids []int) error {
+	if ids == nil {
+		var err error
+		if ids, err = taskOrder(tx, projectID); err != nil {
+			return err
+		}
+	}
+	for i, id := range ids {
+		if _, err := tx.Exec("UPDATE tasks SET position = ? WHERE id = ? AND position != ?", i, id, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
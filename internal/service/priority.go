@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Priority ranks how pressing a task is. Tasks default to PriorityNone.
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+// PriorityNames lists the priority levels from lowest to highest.
+var PriorityNames = []string{"none", "low", "medium", "high", "urgent"}
+
+func (p Priority) String() string {
+	if p < PriorityNone || p > PriorityUrgent {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return PriorityNames[p]
+}
+
+// ParsePriority parses a priority level by name, regardless of case.
+func ParsePriority(s string) (Priority, error) {
+	for i, name := range PriorityNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return Priority(i), nil
+		}
+	}
+	return PriorityNone, fmt.Errorf("invalid priority %q, expected one of %s", s, strings.Join(PriorityNames, ", "))
+}
+
+// Bump returns the priority delta levels higher, or lower when delta is
+// negative, kept within PriorityNone and PriorityUrgent.
+func (p Priority) Bump(delta int) Priority {
+	return min(max(p+Priority(delta), PriorityNone), PriorityUrgent)
+}
+
+// MarshalText encodes a priority by name, so JSON shows "high" rather than 3.
+func (p Priority) MarshalText() ([]byte, error) {
+	if p < PriorityNone || p > PriorityUrgent {
+		return nil, fmt.Errorf("invalid priority %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+func (p *Priority) UnmarshalText(text []byte) error {
+	parsed, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// SetTaskPriority changes the priority of a task.
+func (s *Service) SetTaskPriority(taskID int, p Priority) error {
+	if p < PriorityNone || p > PriorityUrgent {
+		return fmt.Errorf("invalid priority %d", int(p))
+	}
+	result, err := s.db.Exec(`
+		UPDATE tasks SET priority = ?, date_updated = CURRENT_TIMESTAMP WHERE id = ?
+	`, p, taskID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("task %w", ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// RecurrenceKind is how often a recurring task comes back.
+type RecurrenceKind int
+
+const (
+	RepeatNever RecurrenceKind = iota
+	RepeatDaily
+	RepeatWeekly
+	RepeatMonthly
+	// RepeatAfter brings a task back a number of days after it is completed.
+	RepeatAfter
+)
+
+// Recurrence is the rule a task repeats by. Completing a recurring task
+// creates its next occurrence, due on the date the rule gives.
+type Recurrence struct {
+	Kind RecurrenceKind
+	// Weekdays are the days a weekly task is due on. When empty it is due on
+	// the weekday it was due on before.
+	Weekdays []time.Weekday
+	// Days is the number of days after completion for RepeatAfter.
+	Days int
+}
+
+// RecurrenceWords lists the words rules start with, for completion.
+var RecurrenceWords = []string{"daily", "weekly", "monthly", "every"}
+
+var weekdayNames = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// ParseRecurrence parses a rule written as "daily", "weekly" followed by
+// optional weekdays such as "weekly mon,thu", "monthly", or "every 3d" or
+// "every 3 days" for a number of days after completion. An empty string,
+// "none" or "never" means the task does not repeat.
+func ParseRecurrence(s string) (Recurrence, error) {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return unicode.IsSpace(r) || r == ',' })
+	invalid := fmt.Errorf("invalid recurrence %q, expected daily, weekly [mon,tue,...], monthly or every N days", s)
+	if len(fields) == 0 || (len(fields) == 1 && (fields[0] == "none" || fields[0] == "never")) {
+		return Recurrence{}, nil
+	}
+
+	switch fields[0] {
+	case "daily":
+		if len(fields) == 1 {
+			return Recurrence{Kind: RepeatDaily}, nil
+		}
+	case "monthly":
+		if len(fields) == 1 {
+			return Recurrence{Kind: RepeatMonthly}, nil
+		}
+	case "weekly":
+		r := Recurrence{Kind: RepeatWeekly}
+		days := fields[1:]
+		if len(days) > 0 && days[0] == "on" {
+			days = days[1:]
+		}
+		for _, day := range days {
+			d, ok := parseWeekday(day)
+			if !ok {
+				return Recurrence{}, invalid
+			}
+			if !slices.Contains(r.Weekdays, d) {
+				r.Weekdays = append(r.Weekdays, d)
+			}
+		}
+		sortWeekdays(r.Weekdays)
+		return r, nil
+	case "every":
+		var n string
+		switch {
+		case len(fields) == 2 && strings.HasSuffix(fields[1], "d"):
+			n = strings.TrimSuffix(fields[1], "d")
+		case len(fields) == 3 && (fields[2] == "day" || fields[2] == "days"):
+			n = fields[1]
+		default:
+			return Recurrence{}, invalid
+		}
+		days, err := strconv.Atoi(n)
+		if err != nil || days < 1 {
+			return Recurrence{}, invalid
+		}
+		return Recurrence{Kind: RepeatAfter, Days: days}, nil
+	}
+	return Recurrence{}, invalid
+}
+
+// parseWeekday parses a weekday by its name or the first three or more
+// letters of it.
+func parseWeekday(s string) (time.Weekday, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+	for i, name := range weekdayNames {
+		if strings.HasPrefix(name, s) {
+			return time.Weekday(i), true
+		}
+	}
+	return 0, false
+}
+
+// sortWeekdays orders weekdays from Monday to Sunday.
+func sortWeekdays(days []time.Weekday) {
+	slices.SortFunc(days, func(a, b time.Weekday) int { return int((a+6)%7) - int((b+6)%7) })
+}
+
+// IsZero reports whether the rule is RepeatNever.
+func (r Recurrence) IsZero() bool {
+	return r.Kind == RepeatNever
+}
+
+// String writes the rule the way ParseRecurrence reads it.
+func (r Recurrence) String() string {
+	switch r.Kind {
+	case RepeatDaily:
+		return "daily"
+	case RepeatWeekly:
+		if len(r.Weekdays) == 0 {
+			return "weekly"
+		}
+		days := make([]string, len(r.Weekdays))
+		for i, d := range r.Weekdays {
+			days[i] = weekdayNames[d][:3]
+		}
+		return "weekly " + strings.Join(days, ",")
+	case RepeatMonthly:
+		return "monthly"
+	case RepeatAfter:
+		return fmt.Sprintf("every %dd", r.Days)
+	}
+	return ""
+}
+
+// Describe phrases the rule for people, as in "every week on Mon, Thu".
+func (r Recurrence) Describe() string {
+	switch r.Kind {
+	case RepeatDaily:
+		return "every day"
+	case RepeatWeekly:
+		if len(r.Weekdays) == 0 {
+			return "every week"
+		}
+		days := make([]string, len(r.Weekdays))
+		for i, d := range r.Weekdays {
+			days[i] = d.String()[:3]
+		}
+		return "every week on " + strings.Join(days, ", ")
+	case RepeatMonthly:
+		return "every month"
+	case RepeatAfter:
+		if r.Days == 1 {
+			return "1 day after completion"
+		}
+		return fmt.Sprintf("%d days after completion", r.Days)
+	}
+	return "never"
+}
+
+// Next returns the due date of the occurrence after one that was due on due,
+// or undated when due is nil, and completed at completedAt. Scheduled rules
+// skip the dates that have already passed, so a task completed late comes
+// back once rather than once for each missed date. Dates fall at midnight in
+// completedAt's location.
+func (r Recurrence) Next(due *time.Time, completedAt time.Time) time.Time {
+	loc := completedAt.Location()
+	today := time.Date(completedAt.Year(), completedAt.Month(), completedAt.Day(), 0, 0, 0, 0, loc)
+	anchor := today
+	if due != nil {
+		y, m, d := due.In(loc).Date()
+		anchor = time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+	after := anchor
+	if today.After(after) {
+		after = today
+	}
+
+	switch r.Kind {
+	case RepeatWeekly:
+		days := r.Weekdays
+		if len(days) == 0 {
+			days = []time.Weekday{anchor.Weekday()}
+		}
+		next := after.AddDate(0, 0, 1)
+		for !slices.Contains(days, next.Weekday()) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	case RepeatMonthly:
+		for months := 1; ; months++ {
+			if next := addMonths(anchor, months); next.After(after) {
+				return next
+			}
+		}
+	case RepeatAfter:
+		return today.AddDate(0, 0, r.Days)
+	}
+	return after.AddDate(0, 0, 1)
+}
+
+// addMonths moves t forward by months, keeping its day of the month unless
+// the month is shorter, in which case it falls on the last day.
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+// MarshalText encodes a rule as it is written, so JSON shows "weekly mon,thu".
+func (r Recurrence) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Recurrence) UnmarshalText(text []byte) error {
+	parsed, err := ParseRecurrence(string(text))
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Value stores a rule as it is written.
+func (r Recurrence) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+func (r *Recurrence) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*r = Recurrence{}
+		return nil
+	case string:
+		return r.UnmarshalText([]byte(src))
+	case []byte:
+		return r.UnmarshalText(src)
+	}
+	return fmt.Errorf("cannot scan %T into a recurrence", src)
+}
+
+// SetTaskRecurrence changes the rule a task repeats by. A zero Recurrence
+// stops it repeating.
+func (s *Service) SetTaskRecurrence(taskID int, r Recurrence) error {
+	result, err := s.db.Exec(`
+		UPDATE tasks SET recurrence = ?, date_updated = CURRENT_TIMESTAMP WHERE id = ?
+	`, r, taskID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("task %w", ErrNotFound)
+	}
+	return nil
+}
+
+// createNextOccurrence adds the next occurrence of a recurring task that was
+// just completed. The occurrence keeps the task's title, description,
+// priority, parent and tags, and takes over the rule, so completing the same
+// task twice does not create two occurrences.
+func createNextOccurrence(tx *sql.Tx, taskID int, completedAt time.Time) error {
+	var t Task
+	err := tx.QueryRow(`
+		SELECT project_id, title, desc, due_at, priority, parent_task_id, recurrence FROM tasks WHERE id = ?
+	`, taskID).Scan(&t.ProjectID, &t.Title, &t.Desc, &t.DueAt, &t.Priority, &t.ParentTaskID, &t.Recurrence)
+	if err != nil {
+		return err
+	}
+	next := t.Recurrence.Next(t.DueAt, completedAt)
+	state, err := firstState(tx)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO tasks (
+			project_id, title, desc, due_at, priority, parent_task_id, recurrence, state, position,
+			date_created, date_updated
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, (SELECT COUNT(*) FROM tasks WHERE project_id = ?),
+			CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, t.ProjectID, t.Title, t.Desc, next, t.Priority, t.ParentTaskID, t.Recurrence, state, t.ProjectID)
+	if err != nil {
+		return err
+	}
+	nextID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := enterState(tx, int(nextID), state, completedAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO task_tags (task_id, tag_id) SELECT ?, tag_id FROM task_tags WHERE task_id = ?
+	`, nextID, taskID); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE tasks SET recurrence = '' WHERE id = ?", taskID)
+	return err
+}
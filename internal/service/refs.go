@@ -0,0 +1,70 @@
+package service
+
+import "time"
+
+// TaskRef names a task along with its project. Listing refs reads nothing
+// else, so it stays quick with hundreds of projects, as shell completion
+// needs.
+type TaskRef struct {
+	ID          int
+	Title       string
+	ProjectName string
+	CompletedAt *time.Time
+}
+
+// MilestoneRef names a milestone along with its project.
+type MilestoneRef struct {
+	ID          int
+	Name        string
+	ProjectName string
+}
+
+// ListTaskRefs returns refs to the tasks of a project, or of every project
+// when projectID is 0.
+func (s *Service) ListTaskRefs(projectID int) ([]TaskRef, error) {
+	rows, err := s.db.Query(`
+		SELECT t.id, t.title, p.name, t.completed_at
+		FROM tasks t JOIN projects p ON p.id = t.project_id
+		WHERE ? = 0 OR t.project_id = ?
+		ORDER BY t.id
+	`, projectID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []TaskRef
+	for rows.Next() {
+		var r TaskRef
+		if err := rows.Scan(&r.ID, &r.Title, &r.ProjectName, &r.CompletedAt); err != nil {
+			return nil, err
+		}
+		refs = append(refs, r)
+	}
+	return refs, rows.Err()
+}
+
+// ListMilestoneRefs returns refs to the milestones of a project, or of every
+// project when projectID is 0.
+func (s *Service) ListMilestoneRefs(projectID int) ([]MilestoneRef, error) {
+	rows, err := s.db.Query(`
+		SELECT m.id, m.name, p.name
+		FROM milestones m JOIN projects p ON p.id = m.project_id
+		WHERE ? = 0 OR m.project_id = ?
+		ORDER BY m.id
+	`, projectID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []MilestoneRef
+	for rows.Next() {
+		var r MilestoneRef
+		if err := rows.Scan(&r.ID, &r.Name, &r.ProjectName); err != nil {
+			return nil, err
+		}
+		refs = append(refs, r)
+	}
+	return refs, rows.Err()
+}
@@ -2,28 +2,48 @@ package service
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// ErrNotFound is wrapped by errors for projects, tasks and logs that do not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrInvalid is wrapped by errors for changes that would break the shape of
+// the data, such as making a task a subtask of itself.
+var ErrInvalid = errors.New("invalid")
+
 type Service struct {
 	db *sql.DB
+	// maxAttachmentSize is the largest attachment accepted, in bytes.
+	maxAttachmentSize int64
 }
 
 func NewService(db *sql.DB) *Service {
-	return &Service{db: db}
+	return &Service{db: db, maxAttachmentSize: DefaultMaxAttachmentSize}
 }
 
 type Project struct {
-	ID          int
-	Name        string
-	Summary     string
-	Desc        string
-	Status      string
-	DateCreated time.Time
-	DateUpdated time.Time
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
+	Desc    string `json:"desc"`
+	Status  string `json:"status"`
+	// ParentID is the project, such as an area like "OSS", this project
+	// belongs to, if any.
+	ParentID *int     `json:"parent_id"`
+	Tags     []string `json:"tags,omitempty"`
+	// Tasks and Done count the tasks of the project and of its subprojects,
+	// and how many of them are completed. They are filled in when projects
+	// are read.
+	Tasks       int       `json:"tasks"`
+	Done        int       `json:"done"`
+	DateCreated time.Time `json:"created_at"`
+	DateUpdated time.Time `json:"updated_at"`
 }
 
 func (p Project) Title() string       { return p.Name }
@@ -31,44 +51,80 @@ func (p Project) Description() string { return p.Status }
 func (p Project) FilterValue() string { return p.Name }
 
 type Task struct {
-	ID          int
-	ProjectID   int
-	Title       string
-	Desc        string
-	CompletedAt *time.Time
-	DateCreated time.Time
-	DateUpdated time.Time
+	ID          int        `json:"id"`
+	ProjectID   int        `json:"project_id"`
+	Title       string     `json:"title"`
+	Desc        string     `json:"desc"`
+	CompletedAt *time.Time `json:"completed_at"`
+	// State is the task's workflow state. It is StateDone exactly when
+	// CompletedAt is set.
+	State        string     `json:"state"`
+	DueAt        *time.Time `json:"due_at"`
+	Priority     Priority   `json:"priority"`
+	ParentTaskID *int       `json:"parent_task_id"`
+	MilestoneID  *int       `json:"milestone_id"`
+	// Position is the task's place in its project's manual order, counted
+	// from 0. Tasks of the same priority are listed in this order.
+	Position    int        `json:"position"`
+	BlockedBy   []int      `json:"blocked_by,omitempty"`
+	Recurrence  Recurrence `json:"recurrence"`
+	Tags        []string   `json:"tags,omitempty"`
+	DateCreated time.Time  `json:"created_at"`
+	DateUpdated time.Time  `json:"updated_at"`
 }
 
 type Log struct {
-	ID          int
-	ProjectID   int
-	Title       string
-	Desc        string
-	DateCreated time.Time
-	DateUpdated time.Time
+	ID        int    `json:"id"`
+	ProjectID int    `json:"project_id"`
+	Title     string `json:"title"`
+	Desc      string `json:"desc"`
+	// TaskIDs are the tasks of the project the log is about.
+	TaskIDs     []int     `json:"task_ids,omitempty"`
+	DateCreated time.Time `json:"created_at"`
+	DateUpdated time.Time `json:"updated_at"`
 }
 
 // Project CRUD operations
 func (s *Service) CreateProject(p *Project) error {
-	_, err := s.db.Exec(`
+	result, err := s.db.Exec(`
 		INSERT INTO projects (name, summary, desc, status, date_created, date_updated)
 		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`, p.Name, p.Summary, p.Desc, p.Status)
-	return err
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = int(id)
+	return nil
 }
 
 func (s *Service) GetProject(id int) (*Project, error) {
 	project := &Project{}
 	err := s.db.QueryRow(`
-		SELECT id, name, summary, desc, status, date_created, date_updated 
+		SELECT id, name, summary, desc, status, parent_id, date_created, date_updated 
 		FROM projects WHERE id = ?
 	`, id).Scan(&project.ID, &project.Name, &project.Summary, &project.Desc, &project.Status,
-		&project.DateCreated, &project.DateUpdated)
+		&project.ParentID, &project.DateCreated, &project.DateUpdated)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("project not found")
+		return nil, fmt.Errorf("project %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.loadTags(projectTagLinks, "WHERE l.project_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	project.Tags = tags[id]
+	counts, err := s.countProjectTasks("WHERE id = ?", id)
+	if err != nil {
+		return nil, err
 	}
-	return project, err
+	project.Tasks, project.Done = counts[id].tasks, counts[id].done
+	return project, nil
 }
 
 func (s *Service) UpdateProject(p *Project) error {
@@ -85,13 +141,25 @@ func (s *Service) UpdateProject(p *Project) error {
 		return err
 	}
 	if rows == 0 {
-		return fmt.Errorf("project not found")
+		return fmt.Errorf("project %w", ErrNotFound)
 	}
 	return nil
 }
 
+// DeleteProject deletes a project. Its subprojects move up to its parent.
 func (s *Service) DeleteProject(id int) error {
-	result, err := s.db.Exec("DELETE FROM projects WHERE id = ?", id)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE projects SET parent_id = (SELECT parent_id FROM projects WHERE id = ?) WHERE parent_id = ?
+	`, id, id); err != nil {
+		return err
+	}
+	result, err := tx.Exec("DELETE FROM projects WHERE id = ?", id)
 	if err != nil {
 		return err
 	}
@@ -100,61 +168,180 @@ func (s *Service) DeleteProject(id int) error {
 		return err
 	}
 	if rows == 0 {
-		return fmt.Errorf("project not found")
+		return fmt.Errorf("project %w", ErrNotFound)
 	}
-	return nil
+	return tx.Commit()
 }
 
 // Task CRUD operations
-func (s *Service) CreateTask(projectID int, title, desc string) error {
-	_, err := s.db.Exec(`
-		INSERT INTO tasks (project_id, title, desc, date_created, date_updated)
-		VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-	`, projectID, title, desc)
-	return err
+func (s *Service) CreateTask(projectID int, title, desc string, dueAt *time.Time) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	state, err := firstState(tx)
+	if err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(`
+		INSERT INTO tasks (project_id, title, desc, due_at, state, position, date_created, date_updated)
+		VALUES (?, ?, ?, ?, ?, (SELECT COUNT(*) FROM tasks WHERE project_id = ?), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, projectID, title, desc, dueAt, state, projectID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := enterState(tx, int(id), state, time.Now()); err != nil {
+		return 0, err
+	}
+	return int(id), tx.Commit()
 }
 
-func (s *Service) UpdateTask(id int, title, desc string, completedAt *time.Time) error {
-	result, err := s.db.Exec(`
-		UPDATE tasks 
-		SET title = ?, desc = ?, completed_at = ?, date_updated = CURRENT_TIMESTAMP
-		WHERE id = ?
-	`, title, desc, completedAt, id)
+func (s *Service) GetTask(id int) (*Task, error) {
+	task := &Task{}
+	err := s.db.QueryRow(`
+		SELECT id, project_id, title, desc, completed_at, state, due_at, priority, parent_task_id, milestone_id, position,
+			recurrence, date_created, date_updated
+		FROM tasks WHERE id = ?
+	`, id).Scan(&task.ID, &task.ProjectID, &task.Title, &task.Desc, &task.CompletedAt, &task.State, &task.DueAt,
+		&task.Priority, &task.ParentTaskID, &task.MilestoneID, &task.Position, &task.Recurrence, &task.DateCreated,
+		&task.DateUpdated)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("task %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.loadTags(taskTagLinks, "WHERE l.task_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	task.Tags = tags[id]
+	blockers, err := s.loadBlockers("WHERE d.task_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	task.BlockedBy = blockers[id]
+	return task, nil
+}
+
+// UpdateTask changes a task. Completing a task moves it to StateDone, and
+// reopening it moves it back to the first state. Completing a recurring task
+// creates its next occurrence.
+func (s *Service) UpdateTask(id int, title, desc string, completedAt, dueAt *time.Time) error {
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	var wasCompleted bool
+	var recurrence Recurrence
+	err = tx.QueryRow("SELECT completed_at IS NOT NULL, recurrence FROM tasks WHERE id = ?", id).
+		Scan(&wasCompleted, &recurrence)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("task %w", ErrNotFound)
+	}
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return fmt.Errorf("task not found")
+
+	if _, err := tx.Exec(`
+		UPDATE tasks 
+		SET title = ?, desc = ?, completed_at = ?, due_at = ?, date_updated = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, title, desc, completedAt, dueAt, id); err != nil {
+		return err
 	}
-	return nil
+	if wasCompleted != (completedAt != nil) {
+		state, at := StateDone, time.Now()
+		if completedAt != nil {
+			at = *completedAt
+		} else if state, err = firstState(tx); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE tasks SET state = ? WHERE id = ?", state, id); err != nil {
+			return err
+		}
+		if err := enterState(tx, id, state, at); err != nil {
+			return err
+		}
+	}
+	if !wasCompleted && completedAt != nil && !recurrence.IsZero() {
+		if err := createNextOccurrence(tx, id, *completedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
+// DeleteTask deletes a task along with its subtasks.
 func (s *Service) DeleteTask(id int) error {
-	result, err := s.db.Exec("DELETE FROM tasks WHERE id = ?", id)
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	projectID, err := taskProject(tx, id, "task")
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return fmt.Errorf("task not found")
+	if _, err := tx.Exec(`
+		DELETE FROM tasks WHERE id IN (
+			WITH RECURSIVE subtree(id) AS (
+				SELECT ?
+				UNION
+				SELECT t.id FROM tasks t JOIN subtree ON t.parent_task_id = subtree.id
+			)
+			SELECT id FROM subtree
+		)
+	`, id); err != nil {
+		return err
 	}
-	return nil
+	// Close the gap the deleted tasks leave in the manual order.
+	if err := renumberTasks(tx, projectID, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // Log CRUD operations
-func (s *Service) CreateLog(projectID int, title, desc string) error {
-	_, err := s.db.Exec(`
+func (s *Service) CreateLog(projectID int, title, desc string) (int, error) {
+	result, err := s.db.Exec(`
 		INSERT INTO logs (project_id, title, desc, date_created, date_updated)
 		VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`, projectID, title, desc)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (s *Service) GetLog(id int) (*Log, error) {
+	log := &Log{}
+	err := s.db.QueryRow(`
+		SELECT id, project_id, title, desc, date_created, date_updated
+		FROM logs WHERE id = ?
+	`, id).Scan(&log.ID, &log.ProjectID, &log.Title, &log.Desc, &log.DateCreated, &log.DateUpdated)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("log %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.loadLogTasks("WHERE lt.log_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	log.TaskIDs = tasks[id]
+	return log, nil
 }
 
 func (s *Service) UpdateLog(id int, title, desc string) error {
@@ -171,7 +358,7 @@ func (s *Service) UpdateLog(id int, title, desc string) error {
 		return err
 	}
 	if rows == 0 {
-		return fmt.Errorf("log not found")
+		return fmt.Errorf("log %w", ErrNotFound)
 	}
 	return nil
 }
@@ -186,17 +373,61 @@ func (s *Service) DeleteLog(id int) error {
 		return err
 	}
 	if rows == 0 {
-		return fmt.Errorf("log not found")
+		return fmt.Errorf("log %w", ErrNotFound)
 	}
 	return nil
 }
 
+// ProjectFilter narrows the projects FilterProjects returns. Empty fields
+// leave them unfiltered.
+type ProjectFilter struct {
+	// Statuses keeps the projects with one of these statuses.
+	Statuses []string
+	// ExcludeStatuses drops the projects with one of these statuses.
+	ExcludeStatuses []string
+	// Tag keeps the projects labelled with it, or holding a task labelled
+	// with it.
+	Tag string
+}
+
 // List functions
 func (s *Service) ListProjects() ([]Project, error) {
-	rows, err := s.db.Query(`
-		SELECT id, name, summary, desc, status, date_created, date_updated 
-		FROM projects
-	`)
+	return s.FilterProjects(ProjectFilter{})
+}
+
+// FilterProjects returns the projects matching f.
+func (s *Service) FilterProjects(f ProjectFilter) ([]Project, error) {
+	var where []string
+	var args []any
+	if len(f.Statuses) > 0 {
+		where = append(where, "status IN (?"+strings.Repeat(", ?", len(f.Statuses)-1)+")")
+		for _, status := range f.Statuses {
+			args = append(args, status)
+		}
+	}
+	if len(f.ExcludeStatuses) > 0 {
+		where = append(where, "status NOT IN (?"+strings.Repeat(", ?", len(f.ExcludeStatuses)-1)+")")
+		for _, status := range f.ExcludeStatuses {
+			args = append(args, status)
+		}
+	}
+	if f.Tag != "" {
+		where = append(where, `(id IN (
+			SELECT pt.project_id FROM project_tags pt JOIN tags t ON t.id = pt.tag_id WHERE t.name = ?
+		) OR id IN (
+			SELECT k.project_id FROM tasks k
+			JOIN task_tags tt ON tt.task_id = k.id
+			JOIN tags t ON t.id = tt.tag_id
+			WHERE t.name = ?
+		))`)
+		args = append(args, f.Tag, f.Tag)
+	}
+	query := "SELECT id, name, summary, desc, status, parent_id, date_created, date_updated FROM projects"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -205,22 +436,82 @@ func (s *Service) ListProjects() ([]Project, error) {
 	var projects []Project
 	for rows.Next() {
 		var p Project
-		err := rows.Scan(&p.ID, &p.Name, &p.Summary, &p.Desc, &p.Status,
+		err := rows.Scan(&p.ID, &p.Name, &p.Summary, &p.Desc, &p.Status, &p.ParentID,
 			&p.DateCreated, &p.DateUpdated)
 		if err != nil {
 			return nil, err
 		}
 		projects = append(projects, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tags, err := s.loadTags(projectTagLinks, "")
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.countProjectTasks("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range projects {
+		projects[i].Tags = tags[projects[i].ID]
+		projects[i].Tasks, projects[i].Done = counts[projects[i].ID].tasks, counts[projects[i].ID].done
+	}
 	return projects, nil
 }
 
+// TaskFilter narrows the tasks listed by FilterTasks. The zero value keeps
+// every task of every project.
+type TaskFilter struct {
+	// ProjectID keeps the tasks of this project, or of every project when 0.
+	ProjectID int
+	// Completed keeps the completed tasks when true and the pending ones when
+	// false.
+	Completed *bool
+	// Tag keeps the tasks labelled with it.
+	Tag string
+}
+
 func (s *Service) ListProjectTasks(projectID int) ([]Task, error) {
+	return s.FilterTasks(TaskFilter{ProjectID: projectID})
+}
+
+// FilterTasks lists the tasks matching f, grouped by project and in the
+// order of ListProjectTasks within each.
+func (s *Service) FilterTasks(f TaskFilter) ([]Task, error) {
+	var where []string
+	var args []any
+	if f.ProjectID != 0 {
+		where = append(where, "project_id = ?")
+		args = append(args, f.ProjectID)
+	}
+	if f.Completed != nil {
+		if *f.Completed {
+			where = append(where, "completed_at IS NOT NULL")
+		} else {
+			where = append(where, "completed_at IS NULL")
+		}
+	}
+	if f.Tag != "" {
+		where = append(where, `id IN (
+			SELECT tt.task_id FROM task_tags tt JOIN tags t ON t.id = tt.tag_id WHERE t.name = ?
+		)`)
+		args = append(args, f.Tag)
+	}
+	var cond string
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+
 	rows, err := s.db.Query(`
-		SELECT id, project_id, title, desc, completed_at, date_created, date_updated 
-		FROM tasks 
-		WHERE project_id = ?
-	`, projectID)
+		SELECT id, project_id, title, desc, completed_at, state, due_at, priority, parent_task_id, milestone_id, position,
+			recurrence, date_created, date_updated
+		FROM tasks`+cond+`
+		ORDER BY project_id, priority DESC, due_at IS NULL, due_at, position, id
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -229,22 +520,50 @@ func (s *Service) ListProjectTasks(projectID int) ([]Task, error) {
 	var tasks []Task
 	for rows.Next() {
 		var t Task
-		err := rows.Scan(&t.ID, &t.ProjectID, &t.Title, &t.Desc, &t.CompletedAt,
-			&t.DateCreated, &t.DateUpdated)
+		err := rows.Scan(&t.ID, &t.ProjectID, &t.Title, &t.Desc, &t.CompletedAt, &t.State, &t.DueAt, &t.Priority,
+			&t.ParentTaskID, &t.MilestoneID, &t.Position, &t.Recurrence, &t.DateCreated, &t.DateUpdated)
 		if err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, t)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tags, err := s.loadTags(taskTagLinks, "WHERE l.task_id IN (SELECT id FROM tasks"+cond+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	blockers, err := s.loadBlockers("WHERE d.task_id IN (SELECT id FROM tasks"+cond+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		tasks[i].Tags = tags[tasks[i].ID]
+		tasks[i].BlockedBy = blockers[tasks[i].ID]
+	}
 	return tasks, nil
 }
 
 func (s *Service) ListProjectLogs(projectID int) ([]Log, error) {
+	return s.listLogs("WHERE project_id = ?", projectID)
+}
+
+// ListLogs lists the logs of every project, grouped by project.
+func (s *Service) ListLogs() ([]Log, error) {
+	return s.listLogs("")
+}
+
+// listLogs lists the logs matching where, with the tasks they are about.
+func (s *Service) listLogs(where string, args ...any) ([]Log, error) {
 	rows, err := s.db.Query(`
 		SELECT id, project_id, title, desc, date_created, date_updated 
 		FROM logs 
-		WHERE project_id = ?
-	`, projectID)
+		`+where+`
+		ORDER BY project_id, id
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -259,5 +578,17 @@ func (s *Service) ListProjectLogs(projectID int) ([]Log, error) {
 		}
 		logs = append(logs, l)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tasks, err := s.loadLogTasks("WHERE lt.log_id IN (SELECT id FROM logs "+where+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range logs {
+		logs[i].TaskIDs = tasks[logs[i].ID]
+	}
 	return logs, nil
 }
@@ -1,7 +1,13 @@
 package service
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -232,7 +238,7 @@ func TestCreateTask(t *testing.T) {
 	}
 
 	// Create a task
-	err = service.CreateTask(projectID, "Test Task", "Test Description")
+	_, err = service.CreateTask(projectID, "Test Task", "Test Description", nil)
 	if err != nil {
 		t.Fatalf("CreateTask failed: %v", err)
 	}
@@ -249,6 +255,43 @@ func TestCreateTask(t *testing.T) {
 	}
 }
 
+func TestGetTask(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewService(db)
+
+	project := &Project{Name: "Test Project", Status: "todo"}
+	if err := service.CreateProject(project); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	if _, err := service.CreateTask(project.ID, "Test Task", "Test Description", nil); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	var taskID int
+	err := db.QueryRow("SELECT id FROM tasks WHERE title = ?", "Test Task").Scan(&taskID)
+	if err != nil {
+		t.Fatalf("failed to query for task id: %v", err)
+	}
+
+	task, err := service.GetTask(taskID)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.Title != "Test Task" || task.ProjectID != project.ID {
+		t.Errorf("unexpected task: %+v", task)
+	}
+	if task.CompletedAt != nil {
+		t.Errorf("expected new task to be pending")
+	}
+
+	if _, err := service.GetTask(taskID + 1); err == nil {
+		t.Errorf("expected error for missing task")
+	}
+}
+
 func TestUpdateTask(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -275,7 +318,7 @@ func TestUpdateTask(t *testing.T) {
 	}
 
 	// Create a task to update
-	err = service.CreateTask(projectID, "Test Task", "Test Description")
+	_, err = service.CreateTask(projectID, "Test Task", "Test Description", nil)
 	if err != nil {
 		t.Fatalf("CreateTask failed: %v", err)
 	}
@@ -289,7 +332,7 @@ func TestUpdateTask(t *testing.T) {
 
 	// Update the task
 	now := time.Now()
-	err = service.UpdateTask(taskID, "Updated Task", "Updated Description", &now)
+	err = service.UpdateTask(taskID, "Updated Task", "Updated Description", &now, nil)
 	if err != nil {
 		t.Fatalf("UpdateTask failed: %v", err)
 	}
@@ -341,7 +384,7 @@ func TestDeleteTask(t *testing.T) {
 	}
 
 	// Create a task to delete
-	err = service.CreateTask(projectID, "Test Task", "Test Description")
+	_, err = service.CreateTask(projectID, "Test Task", "Test Description", nil)
 	if err != nil {
 		t.Fatalf("CreateTask failed: %v", err)
 	}
@@ -397,7 +440,7 @@ func TestCreateLog(t *testing.T) {
 	}
 
 	// Create a log
-	err = service.CreateLog(projectID, "Test Log", "Test Description")
+	_, err = service.CreateLog(projectID, "Test Log", "Test Description")
 	if err != nil {
 		t.Fatalf("CreateLog failed: %v", err)
 	}
@@ -414,6 +457,35 @@ func TestCreateLog(t *testing.T) {
 	}
 }
 
+func TestGetLog(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewService(db)
+
+	project := &Project{Name: "Test Project", Status: "todo"}
+	if err := service.CreateProject(project); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	logID, err := service.CreateLog(project.ID, "Test Log", "Test Description")
+	if err != nil {
+		t.Fatalf("CreateLog failed: %v", err)
+	}
+
+	log, err := service.GetLog(logID)
+	if err != nil {
+		t.Fatalf("GetLog failed: %v", err)
+	}
+	if log.Title != "Test Log" || log.Desc != "Test Description" || log.ProjectID != project.ID {
+		t.Errorf("unexpected log: %+v", log)
+	}
+
+	if _, err := service.GetLog(logID + 1); err == nil {
+		t.Errorf("expected error for missing log")
+	}
+}
+
 func TestUpdateLog(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -440,7 +512,7 @@ func TestUpdateLog(t *testing.T) {
 	}
 
 	// Create a log to update
-	err = service.CreateLog(projectID, "Test Log", "Test Description")
+	_, err = service.CreateLog(projectID, "Test Log", "Test Description")
 	if err != nil {
 		t.Fatalf("CreateLog failed: %v", err)
 	}
@@ -500,7 +572,7 @@ func TestDeleteLog(t *testing.T) {
 	}
 
 	// Create a log to delete
-	err = service.CreateLog(projectID, "Test Log", "Test Description")
+	_, err = service.CreateLog(projectID, "Test Log", "Test Description")
 	if err != nil {
 		t.Fatalf("CreateLog failed: %v", err)
 	}
@@ -596,11 +668,11 @@ func TestListProjectTasks(t *testing.T) {
 	}
 
 	// Create a few tasks for the project
-	err = service.CreateTask(projectID, "Test Task 1", "Description 1")
+	_, err = service.CreateTask(projectID, "Test Task 1", "Description 1", nil)
 	if err != nil {
 		t.Fatalf("CreateTask failed: %v", err)
 	}
-	err = service.CreateTask(projectID, "Test Task 2", "Description 2")
+	_, err = service.CreateTask(projectID, "Test Task 2", "Description 2", nil)
 	if err != nil {
 		t.Fatalf("CreateTask failed: %v", err)
 	}
@@ -648,11 +720,11 @@ func TestListProjectLogs(t *testing.T) {
 	}
 
 	// Create a few logs for the project
-	err = service.CreateLog(projectID, "Test Log 1", "Description 1")
+	_, err = service.CreateLog(projectID, "Test Log 1", "Description 1")
 	if err != nil {
 		t.Fatalf("CreateLog failed: %v", err)
 	}
-	err = service.CreateLog(projectID, "Test Log 2", "Description 2")
+	_, err = service.CreateLog(projectID, "Test Log 2", "Description 2")
 	if err != nil {
 		t.Fatalf("CreateLog failed: %v", err)
 	}
@@ -673,3 +745,1457 @@ func TestListProjectLogs(t *testing.T) {
 		t.Errorf("expected logs to be 'Test Log 1' and 'Test Log 2', got %s and %s", logs[0].Title, logs[1].Title)
 	}
 }
+
+func TestTags(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewService(db)
+
+	website := &Project{Name: "Website", Status: "todo"}
+	api := &Project{Name: "API", Status: "todo"}
+	for _, p := range []*Project{website, api} {
+		if err := service.CreateProject(p); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+	}
+	taskID, err := service.CreateTask(api.ID, "Write docs", "", nil)
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	if err := service.AddProjectTag(website.ID, "#acme"); err != nil {
+		t.Fatalf("AddProjectTag failed: %v", err)
+	}
+	if err := service.AddProjectTag(website.ID, "frontend"); err != nil {
+		t.Fatalf("AddProjectTag failed: %v", err)
+	}
+	// Tag names are unique regardless of case.
+	if err := service.AddTaskTag(taskID, "ACME"); err != nil {
+		t.Fatalf("AddTaskTag failed: %v", err)
+	}
+	if err := service.AddProjectTag(website.ID, "two words"); err == nil {
+		t.Errorf("expected error for a tag with spaces")
+	}
+	if err := service.AddProjectTag(999, "acme"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing project, got %v", err)
+	}
+
+	p, err := service.GetProject(website.ID)
+	if err != nil {
+		t.Fatalf("GetProject failed: %v", err)
+	}
+	if !reflect.DeepEqual(p.Tags, []string{"acme", "frontend"}) {
+		t.Errorf("expected project tags [acme frontend], got %v", p.Tags)
+	}
+	tasks, err := service.ListProjectTasks(api.ID)
+	if err != nil {
+		t.Fatalf("ListProjectTasks failed: %v", err)
+	}
+	if !reflect.DeepEqual(tasks[0].Tags, []string{"acme"}) {
+		t.Errorf("expected task tags [acme], got %v", tasks[0].Tags)
+	}
+
+	tags, err := service.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if len(tags) != 2 || tags[0].Name != "acme" || tags[0].Projects != 1 || tags[0].Tasks != 1 {
+		t.Errorf("unexpected tags: %+v", tags)
+	}
+
+	tagged, err := service.ListProjectsByTag("acme")
+	if err != nil {
+		t.Fatalf("ListProjectsByTag failed: %v", err)
+	}
+	if len(tagged) != 2 {
+		t.Errorf("expected the tagged project and the project with a tagged task, got %+v", tagged)
+	}
+
+	if err := service.RenameTag("acme", "frontend"); err == nil {
+		t.Errorf("expected error renaming onto an existing tag")
+	}
+	if err := service.RenameTag(" #acme", "client-acme"); err != nil {
+		t.Fatalf("RenameTag failed: %v", err)
+	}
+	if err := service.RenameTag("acme", "other"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound renaming a missing tag, got %v", err)
+	}
+
+	if err := service.RemoveProjectTag(website.ID, "frontend"); err != nil {
+		t.Fatalf("RemoveProjectTag failed: %v", err)
+	}
+	if err := service.RemoveProjectTag(website.ID, "frontend"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound removing a missing tag, got %v", err)
+	}
+	if err := service.SetTaskTags(taskID, []string{"docs"}); err != nil {
+		t.Fatalf("SetTaskTags failed: %v", err)
+	}
+
+	tags, err = service.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"client-acme", "docs"}) {
+		t.Errorf("expected unused tags to be dropped, got %v", names)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags(" acme, #frontend  ACME,,ops ")
+	if err != nil {
+		t.Fatalf("ParseTags failed: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"acme", "frontend", "ops"}) {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+	if _, err := ParseTags(strings.Repeat("x", MaxTagLength+1)); err == nil {
+		t.Errorf("expected error for a long tag")
+	}
+	// The limit counts characters, like the CHECK constraint, not bytes.
+	if _, err := NormalizeTag(strings.Repeat("é", MaxTagLength)); err != nil {
+		t.Errorf("expected a %d character tag to be accepted, got %v", MaxTagLength, err)
+	}
+}
+
+func TestDueDates(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	project := &Project{Name: "Website", Status: "todo"}
+	if err := service.CreateProject(project); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.Local)
+	due, err := ParseDueDate("2025-03-14", now)
+	if err != nil {
+		t.Fatalf("ParseDueDate failed: %v", err)
+	}
+	id, err := service.CreateTask(project.ID, "Launch", "", due)
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	task, err := service.GetTask(id)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.DueAt == nil || !task.DueAt.Equal(*due) {
+		t.Errorf("expected due date %v, got %v", due, task.DueAt)
+	}
+	if days := DaysUntil(*task.DueAt, now); days != 4 {
+		t.Errorf("expected task to be due in 4 days, got %d", days)
+	}
+
+	if err := service.UpdateTask(id, task.Title, task.Desc, nil, nil); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	tasks, err := service.ListProjectTasks(project.ID)
+	if err != nil {
+		t.Fatalf("ListProjectTasks failed: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].DueAt != nil {
+		t.Errorf("expected the due date to be cleared, got %+v", tasks)
+	}
+}
+
+func TestParseDueDate(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.Local)
+	for _, tt := range []struct {
+		in   string
+		want string
+	}{
+		{"2025-04-01", "2025-04-01"},
+		{"today", "2025-03-10"},
+		{"Tomorrow", "2025-03-11"},
+		{"3d", "2025-03-13"},
+		{"+2w", "2025-03-24"},
+	} {
+		due, err := ParseDueDate(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseDueDate(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got := due.Format(DueDateLayout); got != tt.want || due.Hour() != 0 {
+			t.Errorf("ParseDueDate(%q) = %v, want midnight on %s", tt.in, due, tt.want)
+		}
+	}
+
+	if due, err := ParseDueDate("  ", now); due != nil || err != nil {
+		t.Errorf("expected an empty date to mean no due date, got %v, %v", due, err)
+	}
+	for _, in := range []string{"someday", "2025-13-01", "-3d", "d"} {
+		if _, err := ParseDueDate(in, now); err == nil {
+			t.Errorf("expected ParseDueDate(%q) to fail", in)
+		}
+	}
+
+	if days := DaysUntil(now.Add(-time.Hour), now); days != 0 {
+		t.Errorf("expected earlier the same day to be due today, got %d", days)
+	}
+	if days := DaysUntil(time.Date(2025, 3, 8, 23, 0, 0, 0, time.Local), now); days != -2 {
+		t.Errorf("expected a date two days ago to be 2 days overdue, got %d", days)
+	}
+}
+
+func TestPriorities(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	project := &Project{Name: "Website", Status: "todo"}
+	if err := service.CreateProject(project); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	var ids []int
+	for _, title := range []string{"Write copy", "Fix nav", "Pick fonts"} {
+		id, err := service.CreateTask(project.ID, title, "", nil)
+		if err != nil {
+			t.Fatalf("CreateTask failed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := service.SetTaskPriority(ids[2], PriorityUrgent); err != nil {
+		t.Fatalf("SetTaskPriority failed: %v", err)
+	}
+	if err := service.SetTaskPriority(ids[1], PriorityLow); err != nil {
+		t.Fatalf("SetTaskPriority failed: %v", err)
+	}
+	tasks, err := service.ListProjectTasks(project.ID)
+	if err != nil {
+		t.Fatalf("ListProjectTasks failed: %v", err)
+	}
+	var titles []string
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	if !reflect.DeepEqual(titles, []string{"Pick fonts", "Fix nav", "Write copy"}) {
+		t.Errorf("expected tasks by priority, then creation, got %v", titles)
+	}
+	if tasks[0].Priority != PriorityUrgent {
+		t.Errorf("expected urgent priority, got %v", tasks[0].Priority)
+	}
+
+	// Within a priority, tasks due sooner come first and undated ones last.
+	soon, later := time.Now().AddDate(0, 0, 2), time.Now().AddDate(0, 0, 5)
+	if err := service.UpdateTask(ids[1], "Fix nav", "", nil, &later); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	for _, dueAt := range []*time.Time{nil, &soon} {
+		id, err := service.CreateTask(project.ID, "Book venue", "", dueAt)
+		if err != nil {
+			t.Fatalf("CreateTask failed: %v", err)
+		}
+		if err := service.SetTaskPriority(id, PriorityLow); err != nil {
+			t.Fatalf("SetTaskPriority failed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	tasks, err = service.ListProjectTasks(project.ID)
+	if err != nil {
+		t.Fatalf("ListProjectTasks failed: %v", err)
+	}
+	var order []int
+	for _, task := range tasks {
+		order = append(order, task.ID)
+	}
+	if want := []int{ids[2], ids[4], ids[1], ids[3], ids[0]}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected tasks by priority, then due date, got %v, want %v", order, want)
+	}
+
+	if err := service.SetTaskPriority(999, PriorityHigh); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := service.SetTaskPriority(ids[0], Priority(7)); err == nil {
+		t.Errorf("expected an out of range priority to fail")
+	}
+
+	if p, err := ParsePriority(" High "); err != nil || p != PriorityHigh {
+		t.Errorf("ParsePriority(High) = %v, %v", p, err)
+	}
+	if _, err := ParsePriority("critical"); err == nil {
+		t.Errorf("expected an unknown priority to fail")
+	}
+	if PriorityUrgent.Bump(1) != PriorityUrgent || PriorityNone.Bump(-1) != PriorityNone || PriorityLow.Bump(2) != PriorityHigh {
+		t.Errorf("expected Bump to move within none and urgent")
+	}
+
+	data, err := json.Marshal(Task{Priority: PriorityMedium})
+	if err != nil || !strings.Contains(string(data), `"priority":"medium"`) {
+		t.Errorf("expected priority to marshal by name, got %s, %v", data, err)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	website := &Project{Name: "Website", Status: "todo"}
+	mobile := &Project{Name: "Mobile", Status: "todo"}
+	for _, p := range []*Project{website, mobile} {
+		if err := service.CreateProject(p); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+	}
+	launch, _ := service.CreateTask(website.ID, "Launch", "", nil)
+	copyID, _ := service.CreateTask(website.ID, "Write copy", "", nil)
+	hero, _ := service.CreateTask(website.ID, "Hero section", "", nil)
+	beta, _ := service.CreateTask(mobile.ID, "Ship beta", "", nil)
+
+	if err := service.SetTaskParent(copyID, &launch); err != nil {
+		t.Fatalf("SetTaskParent failed: %v", err)
+	}
+	if err := service.SetTaskParent(hero, &copyID); err != nil {
+		t.Fatalf("SetTaskParent failed: %v", err)
+	}
+	task, err := service.GetTask(hero)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.ParentTaskID == nil || *task.ParentTaskID != copyID {
+		t.Errorf("expected Hero section under Write copy, got %v", task.ParentTaskID)
+	}
+
+	for _, tt := range []struct {
+		task, parent int
+	}{
+		{launch, launch},
+		{launch, hero},
+		{launch, beta},
+	} {
+		if err := service.SetTaskParent(tt.task, &tt.parent); !errors.Is(err, ErrInvalid) {
+			t.Errorf("SetTaskParent(%d, %d): expected ErrInvalid, got %v", tt.task, tt.parent, err)
+		}
+	}
+	missing := 999
+	if err := service.SetTaskParent(launch, &missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing parent, got %v", err)
+	}
+	if err := service.SetTaskParent(missing, &launch); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing task, got %v", err)
+	}
+
+	if err := service.SetTaskParent(hero, nil); err != nil {
+		t.Fatalf("SetTaskParent failed: %v", err)
+	}
+	if task, _ := service.GetTask(hero); task.ParentTaskID != nil {
+		t.Errorf("expected Hero section to be a top-level task, got %v", task.ParentTaskID)
+	}
+
+	if err := service.SetTaskParent(hero, &copyID); err != nil {
+		t.Fatalf("SetTaskParent failed: %v", err)
+	}
+	if err := service.DeleteTask(launch); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	tasks, err := service.ListProjectTasks(website.ID)
+	if err != nil {
+		t.Fatalf("ListProjectTasks failed: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("expected deleting a task to delete its subtasks, got %+v", tasks)
+	}
+}
+
+func TestTaskDependencies(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	website := &Project{Name: "Website", Status: "todo"}
+	mobile := &Project{Name: "Mobile", Status: "todo"}
+	for _, p := range []*Project{website, mobile} {
+		if err := service.CreateProject(p); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+	}
+	design, _ := service.CreateTask(website.ID, "Design", "", nil)
+	build, _ := service.CreateTask(website.ID, "Build", "", nil)
+	launch, _ := service.CreateTask(website.ID, "Launch", "", nil)
+	beta, _ := service.CreateTask(mobile.ID, "Ship beta", "", nil)
+
+	if err := service.SetTaskBlockers(build, []int{design}); err != nil {
+		t.Fatalf("SetTaskBlockers failed: %v", err)
+	}
+	if err := service.SetTaskBlockers(launch, []int{build, design}); err != nil {
+		t.Fatalf("SetTaskBlockers failed: %v", err)
+	}
+	task, err := service.GetTask(launch)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if !reflect.DeepEqual(task.BlockedBy, []int{design, build}) {
+		t.Errorf("expected Launch to be blocked by Design and Build, got %v", task.BlockedBy)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		task     int
+		blockers []int
+		want     error
+	}{
+		{"itself", design, []int{design}, ErrInvalid},
+		{"direct cycle", design, []int{build}, ErrInvalid},
+		{"indirect cycle", design, []int{launch}, ErrInvalid},
+		{"other project", design, []int{beta}, ErrInvalid},
+		{"missing blocker", design, []int{999}, ErrNotFound},
+		{"missing task", 999, []int{design}, ErrNotFound},
+	} {
+		if err := service.SetTaskBlockers(tt.task, tt.blockers); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// A rejected blocker leaves the others as they were.
+	if err := service.SetTaskBlockers(launch, []int{design, beta}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+	tasks, err := service.ListProjectTasks(website.ID)
+	if err != nil {
+		t.Fatalf("ListProjectTasks failed: %v", err)
+	}
+	blocked := make(map[int]bool)
+	for _, task := range tasks {
+		blocked[task.ID] = IsBlocked(task, tasks)
+		if task.ID == launch && len(task.BlockedBy) != 2 {
+			t.Errorf("expected Launch to keep its blockers, got %v", task.BlockedBy)
+		}
+	}
+	if blocked[design] || !blocked[build] || !blocked[launch] {
+		t.Errorf("expected Build and Launch to be blocked, got %v", blocked)
+	}
+
+	now := time.Now()
+	if err := service.UpdateTask(design, "Design", "", &now, nil); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	tasks, _ = service.ListProjectTasks(website.ID)
+	for _, task := range tasks {
+		if want := task.ID == launch; IsBlocked(task, tasks) != want {
+			t.Errorf("task %d: expected blocked %v once Design is done", task.ID, want)
+		}
+	}
+
+	if err := service.SetTaskBlockers(launch, nil); err != nil {
+		t.Fatalf("SetTaskBlockers failed: %v", err)
+	}
+	if task, _ := service.GetTask(launch); task.BlockedBy != nil {
+		t.Errorf("expected Launch to have no blockers, got %v", task.BlockedBy)
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"", "", false},
+		{"never", "", false},
+		{"Daily", "daily", false},
+		{"weekly", "weekly", false},
+		{"weekly on Thursday, mon", "weekly mon,thu", false},
+		{"weekly sun mon mon", "weekly mon,sun", false},
+		{"monthly", "monthly", false},
+		{"every 14d", "every 14d", false},
+		{"every 1 day", "every 1d", false},
+		{"every 0d", "", true},
+		{"weekly mo", "", true},
+		{"daily twice", "", true},
+		{"yearly", "", true},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRecurrence(%q): unexpected error %v", tt.in, err)
+			continue
+		}
+		if got := r.String(); got != tt.want {
+			t.Errorf("ParseRecurrence(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	// Wednesday.
+	completed := time.Date(2025, 1, 29, 15, 30, 0, 0, time.Local)
+	date := func(y int, m time.Month, d int) *time.Time {
+		t := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+		return &t
+	}
+	tests := []struct {
+		rule string
+		due  *time.Time
+		want *time.Time
+	}{
+		{"daily", nil, date(2025, 1, 30)},
+		{"daily", date(2025, 1, 20), date(2025, 1, 30)},
+		{"daily", date(2025, 2, 3), date(2025, 2, 4)},
+		{"weekly", date(2025, 1, 27), date(2025, 2, 3)},
+		{"weekly mon,thu", date(2025, 1, 27), date(2025, 1, 30)},
+		{"weekly mon", nil, date(2025, 2, 3)},
+		{"monthly", date(2025, 1, 31), date(2025, 2, 28)},
+		{"monthly", date(2024, 12, 15), date(2025, 2, 15)},
+		{"every 10d", date(2025, 1, 1), date(2025, 2, 8)},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q) failed: %v", tt.rule, err)
+		}
+		if got := r.Next(tt.due, completed); !got.Equal(*tt.want) {
+			t.Errorf("%s from %v: got %s, want %s", tt.rule, tt.due, got.Format(DueDateLayout), tt.want.Format(DueDateLayout))
+		}
+	}
+}
+
+func TestRecurringTasks(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	project := &Project{Name: "Ops", Status: "todo"}
+	if err := service.CreateProject(project); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	due := time.Date(2025, 1, 27, 0, 0, 0, 0, time.Local)
+	id, err := service.CreateTask(project.ID, "Rotate API keys", "See the runbook", &due)
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	weekly, _ := ParseRecurrence("weekly mon")
+	if err := service.SetTaskRecurrence(id, weekly); err != nil {
+		t.Fatalf("SetTaskRecurrence failed: %v", err)
+	}
+	if err := service.SetTaskPriority(id, PriorityHigh); err != nil {
+		t.Fatalf("SetTaskPriority failed: %v", err)
+	}
+	if err := service.SetTaskTags(id, []string{"security"}); err != nil {
+		t.Fatalf("SetTaskTags failed: %v", err)
+	}
+
+	// Editing a pending task does not repeat it.
+	if err := service.UpdateTask(id, "Rotate API keys", "", nil, &due); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if tasks, _ := service.ListProjectTasks(project.ID); len(tasks) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(tasks))
+	}
+
+	completed := time.Date(2025, 1, 28, 9, 0, 0, 0, time.Local)
+	if err := service.UpdateTask(id, "Rotate API keys", "See the runbook", &completed, &due); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	tasks, err := service.ListProjectTasks(project.ID)
+	if err != nil {
+		t.Fatalf("ListProjectTasks failed: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected the next occurrence, got %+v", tasks)
+	}
+	next := tasks[0]
+	if next.ID == id {
+		next = tasks[1]
+	}
+	if next.CompletedAt != nil || next.Title != "Rotate API keys" || next.Desc != "See the runbook" ||
+		next.Priority != PriorityHigh || !reflect.DeepEqual(next.Tags, []string{"security"}) {
+		t.Errorf("expected a pending copy of the task, got %+v", next)
+	}
+	if next.DueAt == nil || next.DueAt.Local().Format(DueDateLayout) != "2025-02-03" {
+		t.Errorf("expected the next occurrence on Monday 2025-02-03, got %v", next.DueAt)
+	}
+	if next.Recurrence.String() != "weekly mon" {
+		t.Errorf("expected the next occurrence to repeat, got %q", next.Recurrence)
+	}
+
+	// The rule moved to the next occurrence, so completing the task again
+	// does not add another one.
+	if err := service.UpdateTask(id, "Rotate API keys", "", nil, &due); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if err := service.UpdateTask(id, "Rotate API keys", "", &completed, &due); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if tasks, _ := service.ListProjectTasks(project.ID); len(tasks) != 2 {
+		t.Errorf("expected 2 tasks, got %d", len(tasks))
+	}
+}
+
+func TestTimers(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	project := &Project{Name: "Client site", Status: "todo"}
+	if err := service.CreateProject(project); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	design, _ := service.CreateTask(project.ID, "Design", "", nil)
+	build, _ := service.CreateTask(project.ID, "Build", "", nil)
+
+	if running, err := service.RunningTimer(); err != nil || running != nil {
+		t.Fatalf("expected no running timer, got %+v, %v", running, err)
+	}
+	if _, err := service.StopTimer(time.Now()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound stopping with no timer, got %v", err)
+	}
+	if _, err := service.StartTimer(999, time.Now()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound timing a missing task, got %v", err)
+	}
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	if stopped, err := service.StartTimer(design, start); err != nil || stopped != nil {
+		t.Fatalf("StartTimer failed: %+v, %v", stopped, err)
+	}
+	// Starting the same task again keeps its timer running.
+	if stopped, err := service.StartTimer(design, start.Add(10*time.Minute)); err != nil || stopped != nil {
+		t.Fatalf("StartTimer failed: %+v, %v", stopped, err)
+	}
+	running, err := service.RunningTimer()
+	if err != nil {
+		t.Fatalf("RunningTimer failed: %v", err)
+	}
+	if running == nil || running.TaskID != design || running.TaskTitle != "Design" ||
+		running.ProjectID != project.ID || running.ProjectName != "Client site" || !running.StartedAt.Equal(start) {
+		t.Fatalf("expected the design timer running since %v, got %+v", start, running)
+	}
+
+	// Only one timer runs at a time: starting another task stops it.
+	stopped, err := service.StartTimer(build, start.Add(90*time.Minute))
+	if err != nil {
+		t.Fatalf("StartTimer failed: %v", err)
+	}
+	if stopped == nil || stopped.TaskID != design || stopped.Duration(time.Now()) != 90*time.Minute {
+		t.Errorf("expected the design timer stopped after 90m, got %+v", stopped)
+	}
+	stopped, err = service.StopTimer(start.Add(2 * time.Hour))
+	if err != nil {
+		t.Fatalf("StopTimer failed: %v", err)
+	}
+	if stopped.TaskID != build || stopped.Duration(time.Now()) != 30*time.Minute {
+		t.Errorf("expected the build timer stopped after 30m, got %+v", stopped)
+	}
+	if running, _ := service.RunningTimer(); running != nil {
+		t.Errorf("expected no running timer, got %+v", running)
+	}
+
+	if _, err := service.StartTimer(design, start.Add(3*time.Hour)); err != nil {
+		t.Fatalf("StartTimer failed: %v", err)
+	}
+	entries, err := service.ListTimeEntries(project.ID)
+	if err != nil {
+		t.Fatalf("ListTimeEntries failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 time entries, got %+v", entries)
+	}
+	spent := TimeSpent(entries, start.Add(3*time.Hour+15*time.Minute))
+	if spent[design] != 105*time.Minute || spent[build] != 30*time.Minute {
+		t.Errorf("expected 1h 45m on design and 30m on build, got %v", spent)
+	}
+	if other, _ := service.ListTimeEntries(project.ID + 1); len(other) != 0 {
+		t.Errorf("expected no entries for another project, got %+v", other)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                "0m",
+		45 * time.Minute: "45m",
+		time.Hour:        "1h 00m",
+		2*time.Hour + 5*time.Minute + 40*time.Second: "2h 06m",
+		30 * time.Hour: "30h 00m",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestMilestones(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	website := &Project{Name: "Website", Status: "todo"}
+	garden := &Project{Name: "Garden", Status: "todo"}
+	for _, p := range []*Project{website, garden} {
+		if err := service.CreateProject(p); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+	}
+
+	target := time.Date(2025, 3, 14, 0, 0, 0, 0, time.Local)
+	beta := &Milestone{ProjectID: website.ID, Name: "Beta"}
+	launch := &Milestone{ProjectID: website.ID, Name: "Launch", TargetDate: &target, Desc: "Public release"}
+	planting := &Milestone{ProjectID: garden.ID, Name: "Planting"}
+	for _, m := range []*Milestone{beta, launch, planting} {
+		if err := service.CreateMilestone(m); err != nil {
+			t.Fatalf("CreateMilestone failed: %v", err)
+		}
+	}
+
+	copyID, _ := service.CreateTask(website.ID, "Write copy", "", nil)
+	footerID, _ := service.CreateTask(website.ID, "Fix footer", "", nil)
+	if err := service.SetTaskMilestone(copyID, &launch.ID); err != nil {
+		t.Fatalf("SetTaskMilestone failed: %v", err)
+	}
+	if err := service.SetTaskMilestone(footerID, &launch.ID); err != nil {
+		t.Fatalf("SetTaskMilestone failed: %v", err)
+	}
+	if err := service.SetTaskMilestone(copyID, &planting.ID); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for another project's milestone, got %v", err)
+	}
+	missing := 999
+	if err := service.SetTaskMilestone(copyID, &missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing milestone, got %v", err)
+	}
+
+	now := time.Now()
+	if err := service.UpdateTask(copyID, "Write copy", "", &now, nil); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	task, err := service.GetTask(copyID)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.MilestoneID == nil || *task.MilestoneID != launch.ID {
+		t.Errorf("expected the task in the launch milestone, got %v", task.MilestoneID)
+	}
+
+	milestones, err := service.ListProjectMilestones(website.ID)
+	if err != nil {
+		t.Fatalf("ListProjectMilestones failed: %v", err)
+	}
+	if len(milestones) != 2 || milestones[0].Name != "Launch" || milestones[1].Name != "Beta" {
+		t.Fatalf("expected dated milestones first, got %+v", milestones)
+	}
+	if milestones[0].Tasks != 2 || milestones[0].Done != 1 || milestones[1].Tasks != 0 {
+		t.Errorf("expected launch at 1/2 and beta empty, got %+v", milestones)
+	}
+
+	launch.Name = "v1.0"
+	launch.TargetDate = nil
+	if err := service.UpdateMilestone(launch); err != nil {
+		t.Fatalf("UpdateMilestone failed: %v", err)
+	}
+	got, err := service.GetMilestone(launch.ID)
+	if err != nil {
+		t.Fatalf("GetMilestone failed: %v", err)
+	}
+	if got.Name != "v1.0" || got.TargetDate != nil || got.Desc != "Public release" {
+		t.Errorf("unexpected milestone after update: %+v", got)
+	}
+
+	if err := service.DeleteMilestone(launch.ID); err != nil {
+		t.Fatalf("DeleteMilestone failed: %v", err)
+	}
+	if _, err := service.GetMilestone(launch.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if task, _ := service.GetTask(footerID); task.MilestoneID != nil {
+		t.Errorf("expected the task to leave the deleted milestone, got %v", *task.MilestoneID)
+	}
+	if err := service.DeleteMilestone(launch.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	p := &Project{Name: "Website", Status: "todo"}
+	if err := service.CreateProject(p); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	var ids []int
+	for _, title := range []string{"A", "B", "C", "D"} {
+		id, err := service.CreateTask(p.ID, title, "", nil)
+		if err != nil {
+			t.Fatalf("CreateTask failed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	titles := func() string {
+		t.Helper()
+		tasks, err := service.ListProjectTasks(p.ID)
+		if err != nil {
+			t.Fatalf("ListProjectTasks failed: %v", err)
+		}
+		var out string
+		for i, task := range tasks {
+			if task.Position != i {
+				t.Errorf("expected %s at position %d, got %d", task.Title, i, task.Position)
+			}
+			out += task.Title
+		}
+		return out
+	}
+	if got := titles(); got != "ABCD" {
+		t.Errorf("expected tasks in the order they were added, got %s", got)
+	}
+
+	if err := service.MoveTask(ids[3], 0); err != nil {
+		t.Fatalf("MoveTask failed: %v", err)
+	}
+	if got := titles(); got != "DABC" {
+		t.Errorf("expected D moved to the top, got %s", got)
+	}
+	if err := service.MoveTask(ids[0], 2); err != nil {
+		t.Fatalf("MoveTask failed: %v", err)
+	}
+	if got := titles(); got != "DBAC" {
+		t.Errorf("expected A moved down one place, got %s", got)
+	}
+	if err := service.MoveTask(ids[3], 99); err != nil {
+		t.Fatalf("MoveTask failed: %v", err)
+	}
+	if got := titles(); got != "BACD" {
+		t.Errorf("expected D moved to the end, got %s", got)
+	}
+	if err := service.MoveTask(99, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing task, got %v", err)
+	}
+
+	// Deleting a task closes the gap it leaves.
+	if err := service.DeleteTask(ids[0]); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if got := titles(); got != "BCD" {
+		t.Errorf("expected A deleted, got %s", got)
+	}
+	id, err := service.CreateTask(p.ID, "E", "", nil)
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if got := titles(); got != "BCDE" {
+		t.Errorf("expected E added at the end, got %s", got)
+	}
+
+	// Priority still comes first.
+	if err := service.SetTaskPriority(id, PriorityHigh); err != nil {
+		t.Fatalf("SetTaskPriority failed: %v", err)
+	}
+	tasks, err := service.ListProjectTasks(p.ID)
+	if err != nil {
+		t.Fatalf("ListProjectTasks failed: %v", err)
+	}
+	if tasks[0].Title != "E" || tasks[0].Position != 3 {
+		t.Errorf("expected the high priority task first, got %+v", tasks[0])
+	}
+}
+
+func TestTaskStates(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	states, err := service.TaskStates()
+	if err != nil {
+		t.Fatalf("TaskStates failed: %v", err)
+	}
+	if want := []string{"todo", "in progress", "blocked", "in review", StateDone}; !reflect.DeepEqual(states, want) {
+		t.Errorf("expected the default states %v, got %v", want, states)
+	}
+
+	p := &Project{Name: "Website", Status: "todo"}
+	if err := service.CreateProject(p); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	id, err := service.CreateTask(p.ID, "Write copy", "", nil)
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	task, _ := service.GetTask(id)
+	if task.State != "todo" {
+		t.Errorf("expected a new task to be todo, got %q", task.State)
+	}
+
+	start := time.Date(2025, 3, 14, 9, 0, 0, 0, time.UTC)
+	if err := service.SetTaskState(id, "in review", start); err != nil {
+		t.Fatalf("SetTaskState failed: %v", err)
+	}
+	if err := service.SetTaskState(id, "shipped", start); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for an unknown state, got %v", err)
+	}
+	if err := service.SetTaskState(99, "todo", start); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing task, got %v", err)
+	}
+
+	// Done follows completed_at both ways.
+	done := start.Add(2 * time.Hour)
+	if err := service.SetTaskState(id, StateDone, done); err != nil {
+		t.Fatalf("SetTaskState failed: %v", err)
+	}
+	task, _ = service.GetTask(id)
+	if task.State != StateDone || task.CompletedAt == nil || !task.CompletedAt.Equal(done) {
+		t.Errorf("expected the task completed at %v, got %+v", done, task)
+	}
+	if err := service.UpdateTask(id, task.Title, task.Desc, nil, nil); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	task, _ = service.GetTask(id)
+	if task.State != "todo" || task.CompletedAt != nil {
+		t.Errorf("expected reopening to move the task back to todo, got %+v", task)
+	}
+
+	history, err := service.TaskStateHistory(id)
+	if err != nil {
+		t.Fatalf("TaskStateHistory failed: %v", err)
+	}
+	var entered []string
+	for _, c := range history {
+		entered = append(entered, c.State)
+	}
+	if want := []string{"todo", "in review", StateDone, "todo"}; !reflect.DeepEqual(entered, want) {
+		t.Errorf("expected history %v, got %v", want, entered)
+	}
+	if !history[1].EnteredAt.Equal(start) {
+		t.Errorf("expected in review entered at %v, got %v", start, history[1].EnteredAt)
+	}
+
+	// The set of states is configurable.
+	if err := service.AddTaskState("deployed"); err != nil {
+		t.Fatalf("AddTaskState failed: %v", err)
+	}
+	if err := service.AddTaskState(strings.Repeat("é", 30)); err != nil {
+		t.Errorf("expected a 30 character state to be accepted, got %v", err)
+	}
+	if err := service.RemoveTaskState(strings.Repeat("é", 30)); err != nil {
+		t.Errorf("RemoveTaskState failed: %v", err)
+	}
+	for _, name := range []string{"deployed", StateDone, " ", strings.Repeat("x", 31)} {
+		if err := service.AddTaskState(name); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected ErrInvalid adding %q, got %v", name, err)
+		}
+	}
+	if err := service.RemoveTaskState("todo"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid removing a state in use, got %v", err)
+	}
+	if err := service.RemoveTaskState("nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound removing a missing state, got %v", err)
+	}
+	if err := service.RemoveTaskState("blocked"); err != nil {
+		t.Fatalf("RemoveTaskState failed: %v", err)
+	}
+	states, _ = service.TaskStates()
+	if want := []string{"todo", "in progress", "in review", "deployed", StateDone}; !reflect.DeepEqual(states, want) {
+		t.Errorf("expected %v, got %v", want, states)
+	}
+}
+
+func TestSubprojects(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	client := &Project{Name: "Client A", Status: "in progress"}
+	website := &Project{Name: "Website", Status: "todo"}
+	api := &Project{Name: "API", Status: "todo"}
+	for _, p := range []*Project{client, website, api} {
+		if err := service.CreateProject(p); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+	}
+	if err := service.SetProjectParent(website.ID, &client.ID); err != nil {
+		t.Fatalf("SetProjectParent failed: %v", err)
+	}
+	if err := service.SetProjectParent(api.ID, &website.ID); err != nil {
+		t.Fatalf("SetProjectParent failed: %v", err)
+	}
+
+	kickoff, _ := service.CreateTask(client.ID, "Kickoff", "", nil)
+	service.CreateTask(website.ID, "Landing page", "", nil)
+	endpoints, _ := service.CreateTask(api.ID, "Endpoints", "", nil)
+	now := time.Now()
+	for _, id := range []int{kickoff, endpoints} {
+		task, _ := service.GetTask(id)
+		if err := service.UpdateTask(id, task.Title, task.Desc, &now, nil); err != nil {
+			t.Fatalf("UpdateTask failed: %v", err)
+		}
+	}
+
+	p, err := service.GetProject(client.ID)
+	if err != nil {
+		t.Fatalf("GetProject failed: %v", err)
+	}
+	if p.ParentID != nil || p.Tasks != 3 || p.Done != 2 {
+		t.Errorf("expected a top-level project with 2 of 3 tasks done, got %+v", p)
+	}
+	if p, err := service.GetProject(website.ID); err != nil || p.Tasks != 2 || p.Done != 1 {
+		t.Errorf("expected Website to count only its own subtree, got %+v, %v", p, err)
+	}
+	projects, err := service.ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	for _, p := range projects {
+		if p.ID == website.ID && (p.ParentID == nil || *p.ParentID != client.ID || p.Tasks != 2 || p.Done != 1) {
+			t.Errorf("expected Website under Client A with 1 of 2 tasks done, got %+v", p)
+		}
+	}
+
+	for _, tt := range []struct {
+		project, parent int
+	}{
+		{client.ID, client.ID},
+		{client.ID, api.ID},
+	} {
+		if err := service.SetProjectParent(tt.project, &tt.parent); !errors.Is(err, ErrInvalid) {
+			t.Errorf("SetProjectParent(%d, %d): expected ErrInvalid, got %v", tt.project, tt.parent, err)
+		}
+	}
+	missing := 999
+	if err := service.SetProjectParent(client.ID, &missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing parent, got %v", err)
+	}
+	if err := service.SetProjectParent(missing, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing project, got %v", err)
+	}
+
+	if err := service.DeleteProject(website.ID); err != nil {
+		t.Fatalf("DeleteProject failed: %v", err)
+	}
+	p, err = service.GetProject(api.ID)
+	if err != nil {
+		t.Fatalf("GetProject failed: %v", err)
+	}
+	if p.ParentID == nil || *p.ParentID != client.ID {
+		t.Errorf("expected API to move up to Client A, got %v", p.ParentID)
+	}
+
+	if err := service.SetProjectParent(api.ID, nil); err != nil {
+		t.Fatalf("SetProjectParent failed: %v", err)
+	}
+	if p, _ := service.GetProject(api.ID); p.ParentID != nil {
+		t.Errorf("expected API to be a top-level project, got %v", p.ParentID)
+	}
+}
+
+func TestFilterProjects(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	for _, p := range []*Project{
+		{Name: "Website", Status: "in progress"},
+		{Name: "Old site", Status: "archived"},
+		{Name: "Launch", Status: "completed"},
+		{Name: "Garden", Status: "todo"},
+	} {
+		if err := service.CreateProject(p); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+	}
+	if err := service.SetProjectTags(2, []string{"acme"}); err != nil {
+		t.Fatalf("SetProjectTags failed: %v", err)
+	}
+	if err := service.SetProjectTags(1, []string{"acme"}); err != nil {
+		t.Fatalf("SetProjectTags failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		filter ProjectFilter
+		want   []string
+	}{
+		{ProjectFilter{}, []string{"Website", "Old site", "Launch", "Garden"}},
+		{ProjectFilter{Statuses: []string{"todo", "in progress"}}, []string{"Website", "Garden"}},
+		{ProjectFilter{Statuses: []string{"archived"}}, []string{"Old site"}},
+		{ProjectFilter{ExcludeStatuses: []string{"archived", "completed"}}, []string{"Website", "Garden"}},
+		{ProjectFilter{ExcludeStatuses: []string{"archived"}, Tag: "acme"}, []string{"Website"}},
+	} {
+		projects, err := service.FilterProjects(tt.filter)
+		if err != nil {
+			t.Fatalf("FilterProjects failed: %v", err)
+		}
+		var names []string
+		for _, p := range projects {
+			names = append(names, p.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("FilterProjects(%+v) = %v, want %v", tt.filter, names, tt.want)
+		}
+	}
+}
+
+func TestLinks(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	p := &Project{Name: "Website", Status: "todo"}
+	if err := service.CreateProject(p); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	taskID, err := service.CreateTask(p.ID, "Write copy", "", nil)
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	doc := &Link{ProjectID: &p.ID, Label: " Design doc ", Target: "https://example.com/design"}
+	if err := service.AddLink(doc); err != nil {
+		t.Fatalf("AddLink failed: %v", err)
+	}
+	if doc.ID == 0 || doc.Label != "Design doc" {
+		t.Errorf("expected an ID and a trimmed label, got %+v", doc)
+	}
+	notes := &Link{TaskID: &taskID, Target: "notes/copy.md"}
+	if err := service.AddLink(notes); err != nil {
+		t.Fatalf("AddLink failed: %v", err)
+	}
+	if !strings.HasPrefix(notes.Target, "/") || !strings.HasSuffix(notes.Target, "/notes/copy.md") {
+		t.Errorf("expected the path made absolute, got %q", notes.Target)
+	}
+	if notes.Label != notes.Target {
+		t.Errorf("expected the label to default to the target, got %q", notes.Label)
+	}
+	if got := notes.URL(); got != "file://"+notes.Target {
+		t.Errorf("expected a file URL, got %q", got)
+	}
+	if got := doc.URL(); got != doc.Target {
+		t.Errorf("expected the URL unchanged, got %q", got)
+	}
+
+	missing := 99
+	for _, l := range []*Link{
+		{ProjectID: &p.ID, Target: " "},
+		{Target: "https://example.com"},
+		{ProjectID: &p.ID, TaskID: &taskID, Target: "https://example.com"},
+	} {
+		if err := service.AddLink(l); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected ErrInvalid for %+v, got %v", l, err)
+		}
+	}
+	if err := service.AddLink(&Link{TaskID: &missing, Target: "https://example.com"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing task, got %v", err)
+	}
+
+	links, err := service.ListProjectLinks(p.ID)
+	if err != nil {
+		t.Fatalf("ListProjectLinks failed: %v", err)
+	}
+	if len(links) != 2 || links[0].ID != doc.ID || links[1].ID != notes.ID || *links[1].TaskID != taskID {
+		t.Errorf("expected the project's link then the task's, got %+v", links)
+	}
+	links, _ = service.ListTaskLinks(taskID)
+	if len(links) != 1 || links[0].ID != notes.ID {
+		t.Errorf("expected the task's link, got %+v", links)
+	}
+
+	if err := service.DeleteLink(doc.ID); err != nil {
+		t.Fatalf("DeleteLink failed: %v", err)
+	}
+	if err := service.DeleteLink(doc.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+	if err := service.DeleteTask(taskID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if links, _ := service.ListProjectLinks(p.ID); len(links) != 0 {
+		t.Errorf("expected the task's links deleted with it, got %+v", links)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	p := &Project{Name: "Website", Status: "todo"}
+	if err := service.CreateProject(p); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	taskID, err := service.CreateTask(p.ID, "Fix login", "", nil)
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	logID, err := service.CreateLog(p.ID, "Outage", "")
+	if err != nil {
+		t.Fatalf("CreateLog failed: %v", err)
+	}
+
+	shot := &Attachment{TaskID: &taskID, Name: "/tmp/shots/login.png"}
+	png := []byte("\x89PNG\r\n\x1a\n fake image")
+	if err := service.AddAttachment(shot, png); err != nil {
+		t.Fatalf("AddAttachment failed: %v", err)
+	}
+	if shot.ID == 0 || shot.Name != "login.png" || shot.MIMEType != "image/png" || shot.Size != int64(len(png)) {
+		t.Errorf("unexpected attachment: %+v", shot)
+	}
+	if sum := sha256.Sum256(png); shot.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the SHA-256 hash of the content, got %q", shot.SHA256)
+	}
+	trace := &Attachment{LogID: &logID, Name: "trace"}
+	if err := service.AddAttachment(trace, []byte("panic: nil map")); err != nil {
+		t.Fatalf("AddAttachment failed: %v", err)
+	}
+	if !strings.HasPrefix(trace.MIMEType, "text/plain") {
+		t.Errorf("expected the content sniffed as text, got %q", trace.MIMEType)
+	}
+
+	missing := 99
+	for _, a := range []*Attachment{
+		{Name: "x.txt"},
+		{TaskID: &taskID, LogID: &logID, Name: "x.txt"},
+		{TaskID: &taskID, Name: " "},
+	} {
+		if err := service.AddAttachment(a, []byte("x")); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected ErrInvalid for %+v, got %v", a, err)
+		}
+	}
+	if err := service.AddAttachment(&Attachment{LogID: &missing, Name: "x.txt"}, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing log, got %v", err)
+	}
+	service.SetMaxAttachmentSize(4)
+	err = service.AddAttachment(&Attachment{TaskID: &taskID, Name: "big.txt"}, []byte("too big"))
+	if !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), "over the 4 B limit") {
+		t.Errorf("expected the size cap to reject the file, got %v", err)
+	}
+	service.SetMaxAttachmentSize(DefaultMaxAttachmentSize)
+
+	data, err := service.ReadAttachment(shot.ID)
+	if err != nil || string(data) != string(png) {
+		t.Errorf("expected the stored content, got %q, %v", data, err)
+	}
+	if _, err := db.Exec("UPDATE attachments SET data = ? WHERE id = ?", []byte("tampered"), shot.ID); err != nil {
+		t.Fatalf("failed to tamper with the attachment: %v", err)
+	}
+	if _, err := service.ReadAttachment(shot.ID); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("expected a hash mismatch, got %v", err)
+	}
+
+	attachments, err := service.ListTaskAttachments(taskID)
+	if err != nil || len(attachments) != 1 || attachments[0].ID != shot.ID {
+		t.Errorf("expected the screenshot on the task, got %+v, %v", attachments, err)
+	}
+	attachments, _ = service.ListLogAttachments(logID)
+	if len(attachments) != 1 || attachments[0].ID != trace.ID {
+		t.Errorf("expected the trace on the log, got %+v", attachments)
+	}
+	if err := service.DeleteAttachment(shot.ID); err != nil {
+		t.Fatalf("DeleteAttachment failed: %v", err)
+	}
+	if _, err := service.GetAttachment(shot.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after deleting, got %v", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{"1024": 1024, "512KB": 512 << 10, "5mb": 5 << 20, "1.5 GB": 3 << 29, "0": 0} {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "big", "-1", "5TB", "MB"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("expected an error for %q", in)
+		}
+	}
+	for n, want := range map[int64]string{12: "12 B", 1536: "1.5 KB", 5 << 20: "5.0 MB"} {
+		if got := FormatSize(n); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestLogTasks(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	website := &Project{Name: "Website", Status: "todo"}
+	mobile := &Project{Name: "Mobile", Status: "todo"}
+	for _, p := range []*Project{website, mobile} {
+		if err := service.CreateProject(p); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+	}
+	design, _ := service.CreateTask(website.ID, "Design", "", nil)
+	build, _ := service.CreateTask(website.ID, "Build", "", nil)
+	beta, _ := service.CreateTask(mobile.ID, "Ship beta", "", nil)
+	monday, _ := service.CreateLog(website.ID, "Monday", "Worked on the build")
+	tuesday, _ := service.CreateLog(website.ID, "Tuesday", "Finished the design")
+
+	if err := service.SetLogTasks(monday, []int{build, design, build}); err != nil {
+		t.Fatalf("SetLogTasks failed: %v", err)
+	}
+	if err := service.SetLogTasks(tuesday, []int{design}); err != nil {
+		t.Fatalf("SetLogTasks failed: %v", err)
+	}
+	log, err := service.GetLog(monday)
+	if err != nil {
+		t.Fatalf("GetLog failed: %v", err)
+	}
+	if !reflect.DeepEqual(log.TaskIDs, []int{design, build}) {
+		t.Errorf("expected Monday to link Design and Build, got %v", log.TaskIDs)
+	}
+
+	logs, err := service.ListTaskLogs(design)
+	if err != nil {
+		t.Fatalf("ListTaskLogs failed: %v", err)
+	}
+	if len(logs) != 2 || logs[0].ID != monday || logs[1].ID != tuesday {
+		t.Fatalf("expected Monday and Tuesday to mention Design, got %+v", logs)
+	}
+	if !reflect.DeepEqual(logs[0].TaskIDs, []int{design, build}) {
+		t.Errorf("expected backlinks to carry the log's tasks, got %v", logs[0].TaskIDs)
+	}
+	if logs, err := service.ListTaskLogs(beta); err != nil || len(logs) != 0 {
+		t.Errorf("expected no logs to mention Ship beta, got %v, %v", logs, err)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		log   int
+		tasks []int
+		want  error
+	}{
+		{"other project", monday, []int{beta}, ErrInvalid},
+		{"missing task", monday, []int{999}, ErrNotFound},
+		{"missing log", 999, []int{design}, ErrNotFound},
+	} {
+		if err := service.SetLogTasks(tt.log, tt.tasks); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// A rejected task leaves the others as they were, and an empty list
+	// clears them.
+	logs, err = service.ListProjectLogs(website.ID)
+	if err != nil {
+		t.Fatalf("ListProjectLogs failed: %v", err)
+	}
+	if len(logs) != 2 || !reflect.DeepEqual(logs[0].TaskIDs, []int{design, build}) {
+		t.Errorf("expected Monday to keep its tasks, got %+v", logs)
+	}
+	if err := service.SetLogTasks(monday, nil); err != nil {
+		t.Fatalf("SetLogTasks failed: %v", err)
+	}
+	if log, _ := service.GetLog(monday); len(log.TaskIDs) != 0 {
+		t.Errorf("expected Monday to link no tasks, got %v", log.TaskIDs)
+	}
+}
+
+func TestRefs(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	website := &Project{Name: "Website", Status: "todo"}
+	garden := &Project{Name: "Garden", Status: "todo"}
+	for _, p := range []*Project{website, garden} {
+		if err := service.CreateProject(p); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+	}
+	copyID, _ := service.CreateTask(website.ID, "Write copy", "", nil)
+	seedsID, _ := service.CreateTask(garden.ID, "Buy seeds", "", nil)
+	now := time.Now()
+	if err := service.UpdateTask(copyID, "Write copy", "", &now, nil); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	launch := &Milestone{ProjectID: website.ID, Name: "Launch"}
+	planting := &Milestone{ProjectID: garden.ID, Name: "Planting"}
+	for _, m := range []*Milestone{launch, planting} {
+		if err := service.CreateMilestone(m); err != nil {
+			t.Fatalf("CreateMilestone failed: %v", err)
+		}
+	}
+
+	tasks, err := service.ListTaskRefs(0)
+	if err != nil {
+		t.Fatalf("ListTaskRefs failed: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != copyID || tasks[0].ProjectName != "Website" || tasks[0].CompletedAt == nil {
+		t.Errorf("expected Write copy to come first, completed, in Website, got %+v", tasks)
+	}
+	if tasks, _ := service.ListTaskRefs(garden.ID); len(tasks) != 1 || tasks[0].ID != seedsID || tasks[0].CompletedAt != nil {
+		t.Errorf("expected only Buy seeds in Garden, got %+v", tasks)
+	}
+
+	milestones, err := service.ListMilestoneRefs(0)
+	if err != nil {
+		t.Fatalf("ListMilestoneRefs failed: %v", err)
+	}
+	if len(milestones) != 2 || milestones[1].Name != "Planting" || milestones[1].ProjectName != "Garden" {
+		t.Errorf("expected Launch and Planting, got %+v", milestones)
+	}
+	if milestones, _ := service.ListMilestoneRefs(website.ID); len(milestones) != 1 || milestones[0].ID != launch.ID {
+		t.Errorf("expected only Launch in Website, got %+v", milestones)
+	}
+}
+
+func TestFilterTasksAndListLogs(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	service := NewService(db)
+
+	website := &Project{Name: "Website", Status: "todo"}
+	garden := &Project{Name: "Garden", Status: "todo"}
+	for _, p := range []*Project{website, garden} {
+		if err := service.CreateProject(p); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+	}
+	seeds, _ := service.CreateTask(garden.ID, "Buy seeds", "", nil)
+	copyID, _ := service.CreateTask(website.ID, "Write copy", "", nil)
+	footer, _ := service.CreateTask(website.ID, "Fix footer", "", nil)
+	now := time.Now()
+	if err := service.UpdateTask(footer, "Fix footer", "", &now, nil); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	for _, id := range []int{seeds, copyID} {
+		if err := service.AddTaskTag(id, "Urgent"); err != nil {
+			t.Fatalf("AddTaskTag failed: %v", err)
+		}
+	}
+
+	ids := func(f TaskFilter) []int {
+		tasks, err := service.FilterTasks(f)
+		if err != nil {
+			t.Fatalf("FilterTasks failed: %v", err)
+		}
+		var ids []int
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+	pending, done := false, true
+	for _, tt := range []struct {
+		name   string
+		filter TaskFilter
+		want   []int
+	}{
+		{"all", TaskFilter{}, []int{copyID, footer, seeds}},
+		{"project", TaskFilter{ProjectID: garden.ID}, []int{seeds}},
+		{"pending", TaskFilter{Completed: &pending}, []int{copyID, seeds}},
+		{"done", TaskFilter{Completed: &done}, []int{footer}},
+		{"tag", TaskFilter{Tag: "urgent"}, []int{copyID, seeds}},
+		{"tag and project", TaskFilter{ProjectID: website.ID, Tag: "urgent"}, []int{copyID}},
+	} {
+		if got := ids(tt.filter); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	service.CreateLog(garden.ID, "Planted", "")
+	service.CreateLog(website.ID, "Launched", "")
+	logs, err := service.ListLogs()
+	if err != nil {
+		t.Fatalf("ListLogs failed: %v", err)
+	}
+	if len(logs) != 2 || logs[0].Title != "Launched" || logs[1].Title != "Planted" {
+		t.Errorf("expected the logs of every project by project, got %+v", logs)
+	}
+}
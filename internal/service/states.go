@@ -0,0 +1,208 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// StateDone is the state of completed tasks. It always comes last and
+// follows CompletedAt: a task is in it exactly when it is completed.
+const StateDone = "done"
+
+// StateChange records a task entering a workflow state.
+type StateChange struct {
+	State     string    `json:"state"`
+	EnteredAt time.Time `json:"entered_at"`
+}
+
+// TaskStates returns the workflow states in order, ending with StateDone.
+func (s *Service) TaskStates() ([]string, error) {
+	rows, err := s.db.Query("SELECT name FROM task_states ORDER BY position, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		states = append(states, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return append(states, StateDone), nil
+}
+
+// AddTaskState adds a workflow state, just before StateDone.
+func (s *Service) AddTaskState(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 30 {
+		return fmt.Errorf("%w state: a name of 1 to 30 characters is required", ErrInvalid)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	exists, err := stateExists(tx, name)
+	if err != nil {
+		return err
+	}
+	if exists || name == StateDone {
+		return fmt.Errorf("%w state: %q already exists", ErrInvalid, name)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO task_states (name, position) SELECT ?, COALESCE(MAX(position) + 1, 0) FROM task_states
+	`, name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveTaskState removes a workflow state that no task is in. StateDone and
+// the only state before it cannot be removed.
+func (s *Service) RemoveTaskState(name string) error {
+	if name == StateDone {
+		return fmt.Errorf("%w state: %q cannot be removed", ErrInvalid, name)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var tasks, states int
+	err = tx.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM tasks WHERE state = ?), (SELECT COUNT(*) FROM task_states)
+	`, name).Scan(&tasks, &states)
+	if err != nil {
+		return err
+	}
+	exists, err := stateExists(tx, name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !exists:
+		return fmt.Errorf("state %w", ErrNotFound)
+	case tasks > 0:
+		return fmt.Errorf("%w state: %d tasks are %s", ErrInvalid, tasks, name)
+	case states == 1:
+		return fmt.Errorf("%w state: %q is the only state before %s", ErrInvalid, name, StateDone)
+	}
+	if _, err := tx.Exec("DELETE FROM task_states WHERE name = ?", name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetTaskState moves a task to a workflow state at the given time. Moving it
+// to StateDone completes it, creating the next occurrence of a recurring
+// task, and moving it out of StateDone reopens it.
+func (s *Service) SetTaskState(taskID int, state string, at time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current string
+	var recurrence Recurrence
+	err = tx.QueryRow("SELECT state, recurrence FROM tasks WHERE id = ?", taskID).Scan(&current, &recurrence)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("task %w", ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	if state != StateDone {
+		exists, err := stateExists(tx, state)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w state: unknown state %q", ErrInvalid, state)
+		}
+	}
+	if state == current {
+		return nil
+	}
+
+	var completedAt *time.Time
+	if state == StateDone {
+		completedAt = &at
+	}
+	if _, err := tx.Exec(`
+		UPDATE tasks SET state = ?, completed_at = ?, date_updated = CURRENT_TIMESTAMP WHERE id = ?
+	`, state, completedAt, taskID); err != nil {
+		return err
+	}
+	if err := enterState(tx, taskID, state, at); err != nil {
+		return err
+	}
+	if state == StateDone && !recurrence.IsZero() {
+		if err := createNextOccurrence(tx, taskID, at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// TaskStateHistory returns the states a task has entered, in the order it
+// entered them.
+func (s *Service) TaskStateHistory(taskID int) ([]StateChange, error) {
+	var exists bool
+	if err := s.db.QueryRow("SELECT COUNT(*) > 0 FROM tasks WHERE id = ?", taskID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("task %w", ErrNotFound)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT state, entered_at FROM task_state_changes WHERE task_id = ? ORDER BY id
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []StateChange
+	for rows.Next() {
+		var c StateChange
+		if err := rows.Scan(&c.State, &c.EnteredAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+func stateExists(tx *sql.Tx, name string) (bool, error) {
+	var exists bool
+	err := tx.QueryRow("SELECT COUNT(*) > 0 FROM task_states WHERE name = ?", name).Scan(&exists)
+	return exists, err
+}
+
+// firstState returns the state new and reopened tasks start in.
+func firstState(tx *sql.Tx) (string, error) {
+	var name string
+	err := tx.QueryRow("SELECT name FROM task_states ORDER BY position, name LIMIT 1").Scan(&name)
+	return name, err
+}
+
+// enterState records a task entering a state.
+func enterState(tx *sql.Tx, taskID int, state string, at time.Time) error {
+	_, err := tx.Exec("INSERT INTO task_state_changes (task_id, state, entered_at) VALUES (?, ?, ?)", taskID, state, at)
+	return err
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SetProjectParent makes a project a subproject of parentID, or a top-level
+// project when parentID is nil. The parent cannot be the project itself or
+// one of its subprojects.
+func (s *Service) SetProjectParent(projectID int, parentID *int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := projectExists(tx, projectID, "project"); err != nil {
+		return err
+	}
+
+	if parentID != nil {
+		if err := projectExists(tx, *parentID, "parent project"); err != nil {
+			return err
+		}
+
+		// Walk up from the new parent: meeting the project means it would
+		// end up nested under itself.
+		var loops int
+		err = tx.QueryRow(`
+			WITH RECURSIVE ancestors(id) AS (
+				SELECT ?
+				UNION
+				SELECT p.parent_id FROM projects p JOIN ancestors a ON p.id = a.id
+				WHERE p.parent_id IS NOT NULL
+			)
+			SELECT COUNT(*) FROM ancestors WHERE id = ?
+		`, *parentID, projectID).Scan(&loops)
+		if err != nil {
+			return err
+		}
+		if loops > 0 {
+			return fmt.Errorf("%w parent: project %d is project %d or one of its subprojects", ErrInvalid, *parentID, projectID)
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE projects SET parent_id = ?, date_updated = CURRENT_TIMESTAMP WHERE id = ?
+	`, parentID, projectID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// projectExists returns ErrNotFound, naming the project as kind, when the
+// project does not exist.
+func projectExists(tx *sql.Tx, id int, kind string) error {
+	var exists bool
+	if err := tx.QueryRow("SELECT COUNT(*) > 0 FROM projects WHERE id = ?", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%s %w", kind, ErrNotFound)
+	}
+	return nil
+}
+
+type taskCount struct {
+	tasks, done int
+}
+
+// countProjectTasks counts the tasks of the projects matching where together
+// with those of their subprojects, and how many of them are completed. Only
+// the subtrees of the matching projects are walked.
+func (s *Service) countProjectTasks(where string, args ...any) (map[int]taskCount, error) {
+	rows, err := s.db.Query(`
+		WITH RECURSIVE tree(root, id) AS (
+			SELECT id, id FROM projects `+where+`
+			UNION ALL
+			SELECT tree.root, p.id FROM projects p JOIN tree ON p.parent_id = tree.id
+		)
+		SELECT tree.root, COUNT(t.id), COUNT(t.completed_at)
+		FROM tree JOIN tasks t ON t.project_id = tree.id
+		GROUP BY tree.root
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int]taskCount)
+	for rows.Next() {
+		var id int
+		var c taskCount
+		if err := rows.Scan(&id, &c.tasks, &c.done); err != nil {
+			return nil, err
+		}
+		counts[id] = c
+	}
+	return counts, rows.Err()
+}
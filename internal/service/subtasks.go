@@ -0,0 +1,55 @@
+package service
+
+import "fmt"
+
+// SetTaskParent makes a task a subtask of parentID, or a top-level task when
+// parentID is nil. The parent must be in the same project, and cannot be the
+// task itself or one of its subtasks.
+func (s *Service) SetTaskParent(taskID int, parentID *int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	projectID, err := taskProject(tx, taskID, "task")
+	if err != nil {
+		return err
+	}
+
+	if parentID != nil {
+		parentProjectID, err := taskProject(tx, *parentID, "parent task")
+		if err != nil {
+			return err
+		}
+		if parentProjectID != projectID {
+			return fmt.Errorf("%w parent: task %d is in another project", ErrInvalid, *parentID)
+		}
+
+		// Walk up from the new parent: meeting the task means it would end
+		// up nested under itself.
+		var loops int
+		err = tx.QueryRow(`
+			WITH RECURSIVE ancestors(id) AS (
+				SELECT ?
+				UNION
+				SELECT t.parent_task_id FROM tasks t JOIN ancestors a ON t.id = a.id
+				WHERE t.parent_task_id IS NOT NULL
+			)
+			SELECT COUNT(*) FROM ancestors WHERE id = ?
+		`, *parentID, taskID).Scan(&loops)
+		if err != nil {
+			return err
+		}
+		if loops > 0 {
+			return fmt.Errorf("%w parent: task %d is task %d or one of its subtasks", ErrInvalid, *parentID, taskID)
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE tasks SET parent_task_id = ?, date_updated = CURRENT_TIMESTAMP WHERE id = ?
+	`, parentID, taskID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTagLength mirrors the CHECK constraint on tags.name.
+const MaxTagLength = 30
+
+// Tag is a label shared by projects and tasks. Names are unique regardless
+// of case.
+type Tag struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Projects int    `json:"projects"`
+	Tasks    int    `json:"tasks"`
+}
+
+// tagLinks describes a join table between tags and the items they label.
+type tagLinks struct {
+	table  string // join table
+	column string // column referencing the item
+	items  string // table holding the items
+	kind   string // item name used in errors
+}
+
+var (
+	projectTagLinks = tagLinks{table: "project_tags", column: "project_id", items: "projects", kind: "project"}
+	taskTagLinks    = tagLinks{table: "task_tags", column: "task_id", items: "tasks", kind: "task"}
+)
+
+// NormalizeTag trims name, and a leading #, and checks that it can be used as
+// a tag: tags are single words of at most MaxTagLength characters.
+func NormalizeTag(name string) (string, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "#")
+	if name == "" {
+		return "", fmt.Errorf("tag name is required")
+	}
+	if utf8.RuneCountInString(name) > MaxTagLength {
+		return "", fmt.Errorf("tag %q must be at most %d characters", name, MaxTagLength)
+	}
+	if strings.ContainsFunc(name, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) {
+		return "", fmt.Errorf("tag %q must not contain spaces or commas", name)
+	}
+	return name, nil
+}
+
+// ParseTags splits a list of tags separated by commas or spaces, dropping
+// duplicates.
+func ParseTags(s string) ([]string, error) {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) {
+		tag, err := NormalizeTag(field)
+		if err != nil {
+			return nil, err
+		}
+		if key := strings.ToLower(tag); !seen[key] {
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// HasTag reports whether tags holds tag, regardless of case.
+func HasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTags returns every tag in use with the number of projects and tasks
+// labelled with it.
+func (s *Service) ListTags() ([]Tag, error) {
+	rows, err := s.db.Query(`
+		SELECT t.id, t.name,
+			(SELECT COUNT(*) FROM project_tags WHERE tag_id = t.id),
+			(SELECT COUNT(*) FROM task_tags WHERE tag_id = t.id)
+		FROM tags t
+		WHERE t.id IN (SELECT tag_id FROM project_tags) OR t.id IN (SELECT tag_id FROM task_tags)
+		ORDER BY t.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var t Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.Projects, &t.Tasks); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// ListProjectsByTag returns the projects labelled with tag, or holding a task
+// labelled with it.
+func (s *Service) ListProjectsByTag(tag string) ([]Project, error) {
+	return s.FilterProjects(ProjectFilter{Tag: tag})
+}
+
+// AddProjectTag labels a project with tag, creating the tag if needed.
+func (s *Service) AddProjectTag(projectID int, tag string) error {
+	return s.addTag(projectTagLinks, projectID, tag)
+}
+
+// RemoveProjectTag removes tag from a project.
+func (s *Service) RemoveProjectTag(projectID int, tag string) error {
+	return s.removeTag(projectTagLinks, projectID, tag)
+}
+
+// SetProjectTags replaces the tags of a project.
+func (s *Service) SetProjectTags(projectID int, tags []string) error {
+	return s.setTags(projectTagLinks, projectID, tags)
+}
+
+// AddTaskTag labels a task with tag, creating the tag if needed.
+func (s *Service) AddTaskTag(taskID int, tag string) error {
+	return s.addTag(taskTagLinks, taskID, tag)
+}
+
+// RemoveTaskTag removes tag from a task.
+func (s *Service) RemoveTaskTag(taskID int, tag string) error {
+	return s.removeTag(taskTagLinks, taskID, tag)
+}
+
+// SetTaskTags replaces the tags of a task.
+func (s *Service) SetTaskTags(taskID int, tags []string) error {
+	return s.setTags(taskTagLinks, taskID, tags)
+}
+
+// RenameTag renames a tag everywhere it is used.
+func (s *Service) RenameTag(oldName, newName string) error {
+	newName, err := NormalizeTag(newName)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Tags left behind by deleted projects and tasks must not block the name.
+	if err := pruneTags(tx); err != nil {
+		return err
+	}
+
+	var id int
+	err = tx.QueryRow("SELECT id FROM tags WHERE name = ?", strings.TrimPrefix(strings.TrimSpace(oldName), "#")).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("tag %w", ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	var taken int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM tags WHERE name = ? AND id != ?", newName, id).Scan(&taken); err != nil {
+		return err
+	}
+	if taken > 0 {
+		return fmt.Errorf("tag %q already exists", newName)
+	}
+
+	if _, err := tx.Exec("UPDATE tags SET name = ? WHERE id = ?", newName, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Service) addTag(links tagLinks, itemID int, tag string) error {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := links.checkItem(tx, itemID); err != nil {
+		return err
+	}
+	if err := links.link(tx, itemID, tag); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Service) removeTag(links tagLinks, itemID int, tag string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := links.checkItem(tx, itemID); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`
+		DELETE FROM `+links.table+`
+		WHERE `+links.column+` = ? AND tag_id = (SELECT id FROM tags WHERE name = ?)
+	`, itemID, strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("tag %w", ErrNotFound)
+	}
+	if err := pruneTags(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Service) setTags(links tagLinks, itemID int, tags []string) error {
+	normalized := make([]string, len(tags))
+	for i, tag := range tags {
+		var err error
+		if normalized[i], err = NormalizeTag(tag); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := links.checkItem(tx, itemID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM "+links.table+" WHERE "+links.column+" = ?", itemID); err != nil {
+		return err
+	}
+	for _, tag := range normalized {
+		if err := links.link(tx, itemID, tag); err != nil {
+			return err
+		}
+	}
+	if err := pruneTags(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (l tagLinks) checkItem(tx *sql.Tx, itemID int) error {
+	var n int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM "+l.items+" WHERE id = ?", itemID).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %w", l.kind, ErrNotFound)
+	}
+	return nil
+}
+
+// link creates tag unless a tag with that name already exists, then attaches
+// it to the item.
+func (l tagLinks) link(tx *sql.Tx, itemID int, tag string) error {
+	if _, err := tx.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", tag); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		INSERT OR IGNORE INTO `+l.table+` (`+l.column+`, tag_id)
+		SELECT ?, id FROM tags WHERE name = ?
+	`, itemID, tag)
+	return err
+}
+
+// pruneTags deletes tags that label nothing, so removing a tag from its last
+// project or task removes it from the tag list too.
+func pruneTags(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		DELETE FROM tags
+		WHERE id NOT IN (SELECT tag_id FROM project_tags)
+		AND id NOT IN (SELECT tag_id FROM task_tags)
+	`)
+	return err
+}
+
+// loadTags returns the sorted tag names of the items matched by where, keyed
+// by item ID. where may refer to the join table as l.
+func (s *Service) loadTags(links tagLinks, where string, args ...any) (map[int][]string, error) {
+	rows, err := s.db.Query(`
+		SELECT l.`+links.column+`, t.name
+		FROM `+links.table+` l JOIN tags t ON t.id = l.tag_id
+		`+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		tags[id] = append(tags[id], name)
+	}
+	for _, names := range tags {
+		sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+	}
+	return tags, rows.Err()
+}
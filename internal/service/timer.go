@@ -0,0 +1,158 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TimeEntry is a stretch of time spent on a task. EndedAt is nil while its
+// timer is running.
+type TimeEntry struct {
+	ID          int        `json:"id"`
+	TaskID      int        `json:"task_id"`
+	TaskTitle   string     `json:"task_title"`
+	ProjectID   int        `json:"project_id"`
+	ProjectName string     `json:"project_name"`
+	StartedAt   time.Time  `json:"started_at"`
+	EndedAt     *time.Time `json:"ended_at"`
+}
+
+// Duration returns the time spent, counting a running timer up to now.
+func (e TimeEntry) Duration(now time.Time) time.Duration {
+	end := now
+	if e.EndedAt != nil {
+		end = *e.EndedAt
+	}
+	return max(end.Sub(e.StartedAt), 0)
+}
+
+// TimeSpent adds up entries by task ID, counting running timers up to now.
+func TimeSpent(entries []TimeEntry, now time.Time) map[int]time.Duration {
+	spent := make(map[int]time.Duration)
+	for _, e := range entries {
+		spent[e.TaskID] += e.Duration(now)
+	}
+	return spent
+}
+
+// FormatDuration writes a duration in hours and minutes, as in "2h 05m".
+func FormatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
+const timeEntryColumns = `
+	SELECT e.id, e.task_id, t.title, t.project_id, p.name, e.started_at, e.ended_at
+	FROM time_entries e
+	JOIN tasks t ON t.id = e.task_id
+	JOIN projects p ON p.id = t.project_id
+`
+
+// StartTimer starts timing a task at the given time. Only one timer runs at
+// a time, so a timer running on another task is stopped first and returned.
+// Starting the timer of the task already being timed changes nothing.
+func (s *Service) StartTimer(taskID int, at time.Time) (stopped *TimeEntry, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := taskProject(tx, taskID, "task"); err != nil {
+		return nil, err
+	}
+	running, err := scanTimeEntry(tx.QueryRow(timeEntryColumns + "WHERE e.ended_at IS NULL"))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if running != nil {
+		if running.TaskID == taskID {
+			return nil, nil
+		}
+		if stopped, err = stopTimeEntry(tx, running, at); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec("INSERT INTO time_entries (task_id, started_at) VALUES (?, ?)", taskID, at); err != nil {
+		return nil, err
+	}
+	return stopped, tx.Commit()
+}
+
+// StopTimer stops the running timer at the given time and returns its entry.
+func (s *Service) StopTimer(at time.Time) (*TimeEntry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	running, err := scanTimeEntry(tx.QueryRow(timeEntryColumns + "WHERE e.ended_at IS NULL"))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("running timer %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stopped, err := stopTimeEntry(tx, running, at)
+	if err != nil {
+		return nil, err
+	}
+	return stopped, tx.Commit()
+}
+
+// RunningTimer returns the entry of the running timer, or nil when no timer
+// is running.
+func (s *Service) RunningTimer() (*TimeEntry, error) {
+	running, err := scanTimeEntry(s.db.QueryRow(timeEntryColumns + "WHERE e.ended_at IS NULL"))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return running, err
+}
+
+// ListTimeEntries returns the time entries of a project's tasks, oldest
+// first.
+func (s *Service) ListTimeEntries(projectID int) ([]TimeEntry, error) {
+	rows, err := s.db.Query(timeEntryColumns+"WHERE t.project_id = ? ORDER BY e.started_at, e.id", projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []TimeEntry
+	for rows.Next() {
+		e, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+func scanTimeEntry(row interface{ Scan(...any) error }) (*TimeEntry, error) {
+	var e TimeEntry
+	if err := row.Scan(&e.ID, &e.TaskID, &e.TaskTitle, &e.ProjectID, &e.ProjectName, &e.StartedAt, &e.EndedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// stopTimeEntry ends a running entry at the given time, or when it started
+// if that is later.
+func stopTimeEntry(tx *sql.Tx, e *TimeEntry, at time.Time) (*TimeEntry, error) {
+	if at.Before(e.StartedAt) {
+		at = e.StartedAt
+	}
+	if _, err := tx.Exec("UPDATE time_entries SET ended_at = ? WHERE id = ?", at, e.ID); err != nil {
+		return nil, err
+	}
+	e.EndedAt = &at
+	return e, nil
+}
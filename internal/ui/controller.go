@@ -2,6 +2,7 @@ package ui
 
 import (
 	"errors"
+	"slices"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -36,6 +37,12 @@ const (
 	fullscreenLogEditView
 	updateLogView
 	deleteLogView
+	tagFilterView
+	blockersView
+	createMilestoneView
+	taskMilestoneView
+	addLinkView
+	openLinkView
 )
 
 // detailTab represents the active tab in the detail view.
@@ -89,9 +96,20 @@ type CoreModel struct {
 	selectedProject *service.Project
 	selectedTask    *service.Task
 	selectedLog     *service.Log
-	projects        []service.Project
+	projects        []service.Project // rows of the project tree
+	allProjects     []service.Project // as loaded, with collapsed subprojects
+	projectDepth    map[int]int
+	collapsed       map[int]bool // projects whose subprojects are hidden
 	tasks           []service.Task
 	logs            []service.Log
+	milestones      []service.Milestone
+	links           []service.Link      // of the selected project and its tasks
+	timeEntries     []service.TimeEntry // of the selected project
+	runningTimer    *service.TimeEntry
+	states          []string // workflow states, ending with service.StateDone
+	tagFilter       string
+	archive         bool // the project list shows archived projects only
+	hideCompleted   bool // the project list leaves out completed projects
 	err             error
 }
 
@@ -101,39 +119,182 @@ type ProjectFormData struct {
 	Summary string
 	Desc    string
 	Status  string
+	Tags    []string
+	// ParentID is the project the project belongs to, or nil for none.
+	ParentID *int
+}
+
+// MilestoneFormData represents the data structure for milestone forms
+type MilestoneFormData struct {
+	Name       string
+	TargetDate *time.Time
+	Desc       string
+}
+
+// LinkFormData represents the data structure for link forms
+type LinkFormData struct {
+	Label  string
+	Target string
 }
 
 // TaskFormData represents the data structure for task forms
 type TaskFormData struct {
 	Title string
 	Desc  string
+	DueAt *time.Time
 }
 
 // LogFormData represents the data structure for log forms
 type LogFormData struct {
-	Title string
-	Desc  string
+	Title   string
+	Desc    string
+	TaskIDs []int
 }
 
 // NewCoreModel creates a new business logic model
 func NewCoreModel(svc Service) (*CoreModel, error) {
-	projects, err := svc.ListProjects()
+	m := &CoreModel{
+		service: svc,
+		state:   listView,
+	}
+	if err := m.RefreshProjects(); err != nil {
+		return nil, err
+	}
+
+	running, err := svc.RunningTimer()
 	if err != nil {
 		return nil, err
 	}
+	m.runningTimer = running
 
-	return &CoreModel{
-		service:  svc,
-		state:    listView,
-		projects: projects,
-	}, nil
+	states, err := svc.TaskStates()
+	if err != nil {
+		return nil, err
+	}
+	m.states = states
+	return m, nil
 }
 
-// GetProjects returns the current projects list
+// GetProjects returns the current projects list, in tree order
 func (m *CoreModel) GetProjects() []service.Project {
 	return m.projects
 }
 
+// setProjects stores the loaded projects and lays them out as a tree.
+func (m *CoreModel) setProjects(projects []service.Project) {
+	m.allProjects = projects
+	m.layoutProjects()
+}
+
+// layoutProjects lists each project followed by its subprojects, leaving out
+// the subprojects of collapsed projects. Projects whose parent is not loaded,
+// such as when a tag filter hides it, are listed at the top level.
+func (m *CoreModel) layoutProjects() {
+	loaded := make(map[int]bool, len(m.allProjects))
+	for _, p := range m.allProjects {
+		loaded[p.ID] = true
+	}
+	var roots []service.Project
+	for _, p := range m.allProjects {
+		if p.ParentID == nil || !loaded[*p.ParentID] {
+			roots = append(roots, p)
+		}
+	}
+
+	m.projects = nil
+	m.projectDepth = make(map[int]int, len(m.allProjects))
+	var walk func(projects []service.Project, depth int)
+	walk = func(projects []service.Project, depth int) {
+		for _, p := range projects {
+			m.projects = append(m.projects, p)
+			m.projectDepth[p.ID] = depth
+			if !m.collapsed[p.ID] {
+				walk(m.Subprojects(p.ID), depth+1)
+			}
+		}
+	}
+	walk(roots, 0)
+}
+
+// Subprojects returns the loaded projects directly under a project.
+func (m *CoreModel) Subprojects(id int) []service.Project {
+	var children []service.Project
+	for _, p := range m.allProjects {
+		if p.ParentID != nil && *p.ParentID == id {
+			children = append(children, p)
+		}
+	}
+	return children
+}
+
+// ProjectDepth returns how deep a project is nested in the project tree.
+func (m *CoreModel) ProjectDepth(id int) int {
+	return m.projectDepth[id]
+}
+
+// IsProjectCollapsed reports whether a project's subprojects are hidden.
+func (m *CoreModel) IsProjectCollapsed(id int) bool {
+	return m.collapsed[id]
+}
+
+// ToggleProjectCollapsed hides or shows the subprojects of a project.
+func (m *CoreModel) ToggleProjectCollapsed(id int) {
+	if m.collapsed == nil {
+		m.collapsed = make(map[int]bool)
+	}
+	if m.collapsed[id] {
+		delete(m.collapsed, id)
+	} else {
+		m.collapsed[id] = true
+	}
+	m.layoutProjects()
+}
+
+// RevealProject expands the ancestors of a project so it is listed.
+func (m *CoreModel) RevealProject(id int) {
+	parents := make(map[int]*int, len(m.allProjects))
+	for _, p := range m.allProjects {
+		parents[p.ID] = p.ParentID
+	}
+	for parent := parents[id]; parent != nil; parent = parents[*parent] {
+		delete(m.collapsed, *parent)
+	}
+	m.layoutProjects()
+}
+
+// sameID reports whether two optional IDs are equal.
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// ParentCandidates returns the loaded projects a project can be moved under:
+// every project but itself and its subprojects. An id of 0 stands for a new
+// project.
+func (m *CoreModel) ParentCandidates(id int) []service.Project {
+	excluded := map[int]bool{}
+	if id != 0 {
+		excluded[id] = true
+		queue := []int{id}
+		for len(queue) > 0 {
+			for _, p := range m.Subprojects(queue[0]) {
+				excluded[p.ID] = true
+				queue = append(queue, p.ID)
+			}
+			queue = queue[1:]
+		}
+	}
+	var candidates []service.Project
+	for _, p := range m.allProjects {
+		if !excluded[p.ID] {
+			candidates = append(candidates, p)
+		}
+	}
+	return candidates
+}
+
 // GetSelectedProject returns the currently selected project
 func (m *CoreModel) GetSelectedProject() *service.Project {
 	return m.selectedProject
@@ -149,9 +310,41 @@ func (m *CoreModel) GetSelectedLog() *service.Log {
 	return m.selectedLog
 }
 
-// GetTasks returns tasks for the selected project
+// GetTasks returns tasks for the selected project. With a tag filter set,
+// only tasks carrying the tag are returned, unless the project carries it.
 func (m *CoreModel) GetTasks() []service.Task {
-	return m.tasks
+	if m.tagFilter == "" || m.selectedProject == nil || service.HasTag(m.selectedProject.Tags, m.tagFilter) {
+		return m.tasks
+	}
+	var tasks []service.Task
+	for _, t := range m.tasks {
+		if service.HasTag(t.Tags, m.tagFilter) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// GetTagFilter returns the tag narrowing the project and task lists, if any
+func (m *CoreModel) GetTagFilter() string {
+	return m.tagFilter
+}
+
+// SetTagFilter narrows the project list to projects tagged with tag or
+// holding tasks tagged with it. An empty tag clears the filter.
+func (m *CoreModel) SetTagFilter(tag string) CoreCommand {
+	previous := m.tagFilter
+	m.tagFilter = tag
+	if err := m.RefreshProjects(); err != nil {
+		m.tagFilter = previous
+		return CoreShowError
+	}
+	return CoreRefreshProjects
+}
+
+// ListTags returns the tags in use
+func (m *CoreModel) ListTags() ([]service.Tag, error) {
+	return m.service.ListTags()
 }
 
 // GetLogs returns logs for the selected project
@@ -169,14 +362,86 @@ func (m *CoreModel) GetState() viewState {
 	return m.state
 }
 
+// projectFilter returns the filter of the project list: the archived projects
+// in the archive, and the other projects, less completed ones when they are
+// hidden, outside it.
+func (m *CoreModel) projectFilter() service.ProjectFilter {
+	f := service.ProjectFilter{Tag: m.tagFilter}
+	switch {
+	case m.archive:
+		f.Statuses = []string{"archived"}
+	case m.hideCompleted:
+		f.ExcludeStatuses = []string{"archived", "completed"}
+	default:
+		f.ExcludeStatuses = []string{"archived"}
+	}
+	return f
+}
+
+// IsArchive reports whether the project list shows the archive
+func (m *CoreModel) IsArchive() bool {
+	return m.archive
+}
+
+// ToggleArchive switches the project list between the archived projects and
+// the others
+func (m *CoreModel) ToggleArchive() CoreCommand {
+	m.archive = !m.archive
+	if err := m.RefreshProjects(); err != nil {
+		m.archive = !m.archive
+		return CoreShowError
+	}
+	return CoreRefreshProjects
+}
+
+// HidesCompleted reports whether completed projects are left out of the
+// project list
+func (m *CoreModel) HidesCompleted() bool {
+	return m.hideCompleted
+}
+
+// ToggleHideCompleted hides or shows completed projects in the project list
+func (m *CoreModel) ToggleHideCompleted() CoreCommand {
+	m.hideCompleted = !m.hideCompleted
+	if err := m.RefreshProjects(); err != nil {
+		m.hideCompleted = !m.hideCompleted
+		return CoreShowError
+	}
+	return CoreRefreshProjects
+}
+
+// ArchiveProject archives the project at index, or brings an archived one
+// back as in progress. The project leaves the list it is in.
+func (m *CoreModel) ArchiveProject(index int) CoreCommand {
+	if index < 0 || index >= len(m.projects) {
+		m.err = errors.New("invalid project index")
+		return CoreShowError
+	}
+
+	p := m.projects[index]
+	if p.Status == "archived" {
+		p.Status = "in progress"
+	} else {
+		p.Status = "archived"
+	}
+	if err := m.service.UpdateProject(&p); err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	if err := m.RefreshProjects(); err != nil {
+		return CoreShowError
+	}
+	return CoreRefreshProjects
+}
+
 // RefreshProjects reloads the projects list
 func (m *CoreModel) RefreshProjects() error {
-	projects, err := m.service.ListProjects()
+	projects, err := m.service.FilterProjects(m.projectFilter())
 	if err != nil {
 		m.err = err
 		return err
 	}
-	m.projects = projects
+	m.setProjects(projects)
 	m.err = nil
 	return nil
 }
@@ -207,6 +472,27 @@ func (m *CoreModel) SelectProject(index int) CoreCommand {
 	}
 	m.logs = logs
 
+	milestones, err := m.service.ListProjectMilestones(project.ID)
+	if err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	m.milestones = milestones
+
+	links, err := m.service.ListProjectLinks(project.ID)
+	if err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	m.links = links
+
+	entries, err := m.service.ListTimeEntries(project.ID)
+	if err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	m.timeEntries = entries
+
 	return NoCoreCmd
 }
 
@@ -289,6 +575,18 @@ func (m *CoreModel) CreateProject(data ProjectFormData) CoreCommand {
 		m.err = err
 		return CoreShowError
 	}
+	if len(data.Tags) > 0 {
+		if err := m.service.SetProjectTags(p.ID, data.Tags); err != nil {
+			m.err = err
+			return CoreShowError
+		}
+	}
+	if data.ParentID != nil {
+		if err := m.service.SetProjectParent(p.ID, data.ParentID); err != nil {
+			m.err = err
+			return CoreShowError
+		}
+	}
 
 	m.state = listView
 	return CoreRefreshProjects
@@ -311,6 +609,18 @@ func (m *CoreModel) UpdateProject(data ProjectFormData) CoreCommand {
 		m.err = err
 		return CoreShowError
 	}
+	if err := m.service.SetProjectTags(p.ID, data.Tags); err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	p.Tags = data.Tags
+	if !sameID(p.ParentID, data.ParentID) {
+		if err := m.service.SetProjectParent(p.ID, data.ParentID); err != nil {
+			m.err = err
+			return CoreShowError
+		}
+		p.ParentID = data.ParentID
+	}
 
 	m.selectedProject = &p
 	m.state = projectView
@@ -324,7 +634,7 @@ func (m *CoreModel) CreateTask(data TaskFormData) CoreCommand {
 		return CoreShowError
 	}
 
-	if err := m.service.CreateTask(m.selectedProject.ID, data.Title, data.Desc); err != nil {
+	if _, err := m.service.CreateTask(m.selectedProject.ID, data.Title, data.Desc, data.DueAt); err != nil {
 		m.err = err
 		return CoreShowError
 	}
@@ -340,10 +650,17 @@ func (m *CoreModel) CreateLog(data LogFormData) CoreCommand {
 		return CoreShowError
 	}
 
-	if err := m.service.CreateLog(m.selectedProject.ID, data.Title, data.Desc); err != nil {
+	id, err := m.service.CreateLog(m.selectedProject.ID, data.Title, data.Desc)
+	if err != nil {
 		m.err = err
 		return CoreShowError
 	}
+	if len(data.TaskIDs) > 0 {
+		if err := m.service.SetLogTasks(id, data.TaskIDs); err != nil {
+			m.err = err
+			return CoreShowError
+		}
+	}
 
 	m.state = projectView
 	return CoreRefreshLogsView
@@ -360,10 +677,15 @@ func (m *CoreModel) UpdateLog(data LogFormData) CoreCommand {
 		m.err = err
 		return CoreShowError
 	}
+	if err := m.service.SetLogTasks(m.selectedLog.ID, data.TaskIDs); err != nil {
+		m.err = err
+		return CoreShowError
+	}
 
 	// Update the log in memory
 	m.selectedLog.Title = data.Title
 	m.selectedLog.Desc = data.Desc
+	m.selectedLog.TaskIDs = data.TaskIDs
 
 	// Update the log in the logs slice
 	for i, log := range m.logs {
@@ -454,7 +776,7 @@ func (m *CoreModel) ToggleTaskCompletion(taskID int, completedAt *time.Time) Cor
 		return CoreShowError
 	}
 
-	if err := m.service.UpdateTask(taskID, taskToUpdate.Title, taskToUpdate.Desc, completedAt); err != nil {
+	if err := m.service.UpdateTask(taskID, taskToUpdate.Title, taskToUpdate.Desc, completedAt, taskToUpdate.DueAt); err != nil {
 		m.err = err
 		return CoreShowError
 	}
@@ -470,6 +792,279 @@ func (m *CoreModel) ToggleTaskCompletion(taskID int, completedAt *time.Time) Cor
 	return CoreRefreshTasksView
 }
 
+// BumpTaskPriority raises a task's priority by delta levels, or lowers it when
+// delta is negative, and reloads the tasks so the list is sorted again.
+func (m *CoreModel) BumpTaskPriority(taskID int, delta int) CoreCommand {
+	if m.selectedProject == nil {
+		m.err = errors.New("no project selected")
+		return CoreShowError
+	}
+
+	var task *service.Task
+	for i := range m.tasks {
+		if m.tasks[i].ID == taskID {
+			task = &m.tasks[i]
+			break
+		}
+	}
+	if task == nil {
+		m.err = errors.New("task not found")
+		return CoreShowError
+	}
+
+	priority := task.Priority.Bump(delta)
+	if priority == task.Priority {
+		return NoCoreCmd
+	}
+	if err := m.service.SetTaskPriority(taskID, priority); err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	return m.reloadTasks()
+}
+
+// GetStates returns the workflow states tasks cycle through
+func (m *CoreModel) GetStates() []string {
+	return m.states
+}
+
+// CycleTaskState moves a task delta states along the workflow, wrapping
+// around so that a done task starts over. Moving a task to done completes it.
+func (m *CoreModel) CycleTaskState(taskID, delta int) CoreCommand {
+	if m.selectedProject == nil {
+		m.err = errors.New("no project selected")
+		return CoreShowError
+	}
+	if len(m.states) == 0 {
+		return NoCoreCmd
+	}
+
+	var task *service.Task
+	for i := range m.tasks {
+		if m.tasks[i].ID == taskID {
+			task = &m.tasks[i]
+			break
+		}
+	}
+	if task == nil {
+		m.err = errors.New("task not found")
+		return CoreShowError
+	}
+
+	i := max(slices.Index(m.states, task.State), 0)
+	n := len(m.states)
+	state := m.states[((i+delta)%n+n)%n]
+	if err := m.service.SetTaskState(taskID, state, time.Now()); err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	return m.reloadTasks()
+}
+
+// SetTaskParent makes a task a subtask of parentID, or a top-level task when
+// parentID is nil.
+func (m *CoreModel) SetTaskParent(taskID int, parentID *int) CoreCommand {
+	if m.selectedProject == nil {
+		m.err = errors.New("no project selected")
+		return CoreShowError
+	}
+	if err := m.service.SetTaskParent(taskID, parentID); err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	return m.reloadTasks()
+}
+
+// MoveTask moves a task to position in the selected project's manual order
+// and reloads the tasks so the list shows the new order.
+func (m *CoreModel) MoveTask(taskID, position int) CoreCommand {
+	if m.selectedProject == nil {
+		m.err = errors.New("no project selected")
+		return CoreShowError
+	}
+	if err := m.service.MoveTask(taskID, position); err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	return m.reloadTasks()
+}
+
+// SetTaskBlockers replaces the tasks blocking a task.
+func (m *CoreModel) SetTaskBlockers(taskID int, blockerIDs []int) CoreCommand {
+	if m.selectedProject == nil {
+		m.err = errors.New("no project selected")
+		return CoreShowError
+	}
+	if err := m.service.SetTaskBlockers(taskID, blockerIDs); err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	return m.reloadTasks()
+}
+
+// GetMilestones returns the milestones of the selected project
+func (m *CoreModel) GetMilestones() []service.Milestone {
+	return m.milestones
+}
+
+// CreateMilestone adds a milestone to the selected project.
+func (m *CoreModel) CreateMilestone(data MilestoneFormData) CoreCommand {
+	if m.selectedProject == nil {
+		m.err = errors.New("no project selected")
+		return CoreShowError
+	}
+	milestone := &service.Milestone{
+		ProjectID:  m.selectedProject.ID,
+		Name:       data.Name,
+		TargetDate: data.TargetDate,
+		Desc:       data.Desc,
+	}
+	if err := m.service.CreateMilestone(milestone); err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	milestones, err := m.service.ListProjectMilestones(m.selectedProject.ID)
+	if err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	m.milestones = milestones
+	m.state = projectView
+	return CoreRefreshProjectView
+}
+
+// SetTaskMilestone assigns a task to a milestone, or takes it off its
+// milestone when milestoneID is nil.
+func (m *CoreModel) SetTaskMilestone(taskID int, milestoneID *int) CoreCommand {
+	if m.selectedProject == nil {
+		m.err = errors.New("no project selected")
+		return CoreShowError
+	}
+	if err := m.service.SetTaskMilestone(taskID, milestoneID); err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	return m.reloadTasks()
+}
+
+// ProjectLinks returns the links on the selected project itself.
+func (m *CoreModel) ProjectLinks() []service.Link {
+	var links []service.Link
+	for _, l := range m.links {
+		if l.ProjectID != nil {
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// TaskLinks returns the links on a task of the selected project.
+func (m *CoreModel) TaskLinks(taskID int) []service.Link {
+	var links []service.Link
+	for _, l := range m.links {
+		if l.TaskID != nil && *l.TaskID == taskID {
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// AddLink adds a link to a task of the selected project, or to the project
+// itself when taskID is 0.
+func (m *CoreModel) AddLink(taskID int, data LinkFormData) CoreCommand {
+	if m.selectedProject == nil {
+		m.err = errors.New("no project selected")
+		return CoreShowError
+	}
+	link := &service.Link{Label: data.Label, Target: data.Target}
+	if taskID != 0 {
+		link.TaskID = &taskID
+	} else {
+		link.ProjectID = &m.selectedProject.ID
+	}
+	if err := m.service.AddLink(link); err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	links, err := m.service.ListProjectLinks(m.selectedProject.ID)
+	if err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	m.links = links
+	m.state = projectView
+	return CoreRefreshProjectView
+}
+
+// GetRunningTimer returns the entry of the running timer, or nil when no
+// timer is running.
+func (m *CoreModel) GetRunningTimer() *service.TimeEntry {
+	return m.runningTimer
+}
+
+// TimeSpent returns the time spent on each task of the selected project,
+// counting the running timer up to now.
+func (m *CoreModel) TimeSpent(now time.Time) map[int]time.Duration {
+	return service.TimeSpent(m.timeEntries, now)
+}
+
+// ToggleTimer starts timing a task, stopping any other running timer, or
+// stops the task's timer when it is the one running.
+func (m *CoreModel) ToggleTimer(taskID int) CoreCommand {
+	now := time.Now()
+	var err error
+	if m.runningTimer != nil && m.runningTimer.TaskID == taskID {
+		_, err = m.service.StopTimer(now)
+	} else {
+		_, err = m.service.StartTimer(taskID, now)
+	}
+	if err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	return m.reloadTimers()
+}
+
+// reloadTimers fetches the running timer and the time entries of the
+// selected project again.
+func (m *CoreModel) reloadTimers() CoreCommand {
+	running, err := m.service.RunningTimer()
+	if err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	m.runningTimer = running
+
+	if m.selectedProject != nil {
+		entries, err := m.service.ListTimeEntries(m.selectedProject.ID)
+		if err != nil {
+			m.err = err
+			return CoreShowError
+		}
+		m.timeEntries = entries
+	}
+	return CoreRefreshTasksView
+}
+
+// dropTimerOfProject forgets the running timer if it was timing a task of a
+// deleted project, as the timer went with it.
+func (m *CoreModel) dropTimerOfProject(projectID int) {
+	if m.runningTimer != nil && m.runningTimer.ProjectID == projectID {
+		m.runningTimer = nil
+	}
+}
+
+// reloadTasks fetches the tasks of the selected project again.
+func (m *CoreModel) reloadTasks() CoreCommand {
+	tasks, err := m.service.ListProjectTasks(m.selectedProject.ID)
+	if err != nil {
+		m.err = err
+		return CoreShowError
+	}
+	m.tasks = tasks
+	return CoreRefreshTasksView
+}
+
 // SelectTask selects a task by index
 func (m *CoreModel) SelectTask(index int) CoreCommand {
 	if index < 0 || index >= len(m.tasks) {
@@ -512,6 +1107,7 @@ func (m *CoreModel) DeleteProject(index int) CoreCommand {
 		m.err = err
 		return CoreShowError
 	}
+	m.dropTimerOfProject(project.ID)
 	m.state = listView
 	return CoreRefreshProjects
 }
@@ -531,6 +1127,7 @@ func (m *CoreModel) ConfirmDeleteSelected(confirmed bool) CoreCommand {
 		m.err = err
 		return CoreShowError
 	}
+	m.dropTimerOfProject(m.selectedProject.ID)
 
 	m.state = listView
 	m.selectedProject = nil
@@ -549,6 +1146,10 @@ func (m *CoreModel) DeleteTask(taskID int) CoreCommand {
 		m.err = err
 		return CoreShowError
 	}
+	// Deleting a task deletes its time entries, and its timer if it runs.
+	if cmd := m.reloadTimers(); cmd == CoreShowError {
+		return cmd
+	}
 
 	// Refresh tasks for the current project
 	tasks, err := m.service.ListProjectTasks(m.selectedProject.ID)
@@ -566,4 +1167,3 @@ func (m *CoreModel) RefreshProjectViewCmd() tea.Cmd {
 		return CoreRefreshProjectView
 	}
 }
-
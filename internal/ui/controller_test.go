@@ -2,6 +2,8 @@ package ui
 
 import (
 	"errors"
+	"slices"
+	"sort"
 	"testing"
 	"time"
 
@@ -11,10 +13,14 @@ import (
 
 // MockService is a mock implementation of the Service interface for testing.
 type MockService struct {
-	projects []service.Project
-	tasks    []service.Task
-	logs     []service.Log
-	err      error
+	projects    []service.Project
+	tasks       []service.Task
+	logs        []service.Log
+	timeEntries []service.TimeEntry
+	milestones  []service.Milestone
+	links       []service.Link
+	states      []string
+	err         error
 }
 
 func (m *MockService) ListProjects() ([]service.Project, error) {
@@ -59,11 +65,43 @@ func (m *MockService) UpdateProject(p *service.Project) error {
 	return errors.New("project not found")
 }
 
+func (m *MockService) SetProjectParent(projectID int, parentID *int) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, p := range m.projects {
+		if p.ID == projectID {
+			m.projects[i].ParentID = parentID
+			return nil
+		}
+	}
+	return errors.New("project not found")
+}
+
+// ListProjectTasks returns the tasks in the order the service lists them:
+// by priority, then by due date with undated ones last, then by position.
 func (m *MockService) ListProjectTasks(projectID int) ([]service.Task, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
-	return m.tasks, nil
+	tasks := slices.Clone(m.tasks)
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if (a.DueAt == nil) != (b.DueAt == nil) {
+			return a.DueAt != nil
+		}
+		if a.DueAt != nil && !a.DueAt.Equal(*b.DueAt) {
+			return a.DueAt.Before(*b.DueAt)
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.ID < b.ID
+	})
+	return tasks, nil
 }
 
 func (m *MockService) ListProjectLogs(projectID int) ([]service.Log, error) {
@@ -73,21 +111,24 @@ func (m *MockService) ListProjectLogs(projectID int) ([]service.Log, error) {
 	return m.logs, nil
 }
 
-func (m *MockService) CreateTask(projectID int, title, desc string) error {
+func (m *MockService) CreateTask(projectID int, title, desc string, dueAt *time.Time) (int, error) {
 	if m.err != nil {
-		return m.err
+		return 0, m.err
 	}
 	task := service.Task{
 		ID:        len(m.tasks) + 1,
 		ProjectID: projectID,
 		Title:     title,
 		Desc:      desc,
+		DueAt:     dueAt,
+		State:     "todo",
+		Position:  len(m.tasks),
 	}
 	m.tasks = append(m.tasks, task)
-	return nil
+	return task.ID, nil
 }
 
-func (m *MockService) UpdateTask(id int, title, desc string, completedAt *time.Time) error {
+func (m *MockService) UpdateTask(id int, title, desc string, completedAt, dueAt *time.Time) error {
 	if m.err != nil {
 		return m.err
 	}
@@ -96,6 +137,12 @@ func (m *MockService) UpdateTask(id int, title, desc string, completedAt *time.T
 			m.tasks[i].Title = title
 			m.tasks[i].Desc = desc
 			m.tasks[i].CompletedAt = completedAt
+			m.tasks[i].DueAt = dueAt
+			if completedAt != nil {
+				m.tasks[i].State = service.StateDone
+			} else if m.tasks[i].State == service.StateDone {
+				m.tasks[i].State = "todo"
+			}
 			return nil
 		}
 	}
@@ -115,9 +162,9 @@ func (m *MockService) DeleteTask(id int) error {
 	return errors.New("task not found")
 }
 
-func (m *MockService) CreateLog(projectID int, title, desc string) error {
+func (m *MockService) CreateLog(projectID int, title, desc string) (int, error) {
 	if m.err != nil {
-		return m.err
+		return 0, m.err
 	}
 	log := service.Log{
 		ID:        len(m.logs) + 1,
@@ -126,7 +173,7 @@ func (m *MockService) CreateLog(projectID int, title, desc string) error {
 		Desc:      desc,
 	}
 	m.logs = append(m.logs, log)
-	return nil
+	return log.ID, nil
 }
 
 func (m *MockService) UpdateLog(id int, title, desc string) error {
@@ -156,6 +203,319 @@ func (m *MockService) DeleteLog(id int) error {
 	return errors.New("log not found")
 }
 
+func (m *MockService) SetLogTasks(logID int, taskIDs []int) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, l := range m.logs {
+		if l.ID == logID {
+			m.logs[i].TaskIDs = taskIDs
+			return nil
+		}
+	}
+	return errors.New("log not found")
+}
+
+func (m *MockService) ListTags() ([]service.Tag, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	index := make(map[string]int)
+	var tags []service.Tag
+	count := func(name string) *service.Tag {
+		if _, ok := index[name]; !ok {
+			index[name] = len(tags)
+			tags = append(tags, service.Tag{ID: len(tags) + 1, Name: name})
+		}
+		return &tags[index[name]]
+	}
+	for _, p := range m.projects {
+		for _, name := range p.Tags {
+			count(name).Projects++
+		}
+	}
+	for _, t := range m.tasks {
+		for _, name := range t.Tags {
+			count(name).Tasks++
+		}
+	}
+	return tags, nil
+}
+
+func (m *MockService) FilterProjects(f service.ProjectFilter) ([]service.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var projects []service.Project
+	for _, p := range m.projects {
+		if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, p.Status) || slices.Contains(f.ExcludeStatuses, p.Status) {
+			continue
+		}
+		tagged := f.Tag == "" || service.HasTag(p.Tags, f.Tag)
+		for _, t := range m.tasks {
+			tagged = tagged || (t.ProjectID == p.ID && service.HasTag(t.Tags, f.Tag))
+		}
+		if tagged {
+			projects = append(projects, p)
+		}
+	}
+	return projects, nil
+}
+
+func (m *MockService) SetProjectTags(projectID int, tags []string) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, p := range m.projects {
+		if p.ID == projectID {
+			m.projects[i].Tags = tags
+			return nil
+		}
+	}
+	return errors.New("project not found")
+}
+
+func (m *MockService) SetTaskPriority(taskID int, p service.Priority) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, t := range m.tasks {
+		if t.ID == taskID {
+			m.tasks[i].Priority = p
+			return nil
+		}
+	}
+	return errors.New("task not found")
+}
+
+func (m *MockService) SetTaskParent(taskID int, parentID *int) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, t := range m.tasks {
+		if t.ID == taskID {
+			m.tasks[i].ParentTaskID = parentID
+			return nil
+		}
+	}
+	return errors.New("task not found")
+}
+
+func (m *MockService) MoveTask(taskID, position int) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, t := range m.tasks {
+		if t.ID == taskID {
+			tasks := append(m.tasks[:i:i], m.tasks[i+1:]...)
+			position = max(0, min(position, len(tasks)))
+			m.tasks = append(tasks[:position:position], append([]service.Task{t}, tasks[position:]...)...)
+			for j := range m.tasks {
+				m.tasks[j].Position = j
+			}
+			return nil
+		}
+	}
+	return errors.New("task not found")
+}
+
+func (m *MockService) TaskStates() ([]string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.states == nil {
+		return []string{"todo", "in progress", "blocked", "in review", service.StateDone}, nil
+	}
+	return m.states, nil
+}
+
+func (m *MockService) SetTaskState(taskID int, state string, at time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, t := range m.tasks {
+		if t.ID == taskID {
+			m.tasks[i].State = state
+			m.tasks[i].CompletedAt = nil
+			if state == service.StateDone {
+				m.tasks[i].CompletedAt = &at
+			}
+			return nil
+		}
+	}
+	return errors.New("task not found")
+}
+
+func (m *MockService) SetTaskBlockers(taskID int, blockerIDs []int) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, t := range m.tasks {
+		if t.ID == taskID {
+			m.tasks[i].BlockedBy = blockerIDs
+			return nil
+		}
+	}
+	return errors.New("task not found")
+}
+
+func (m *MockService) SetTaskRecurrence(taskID int, r service.Recurrence) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, t := range m.tasks {
+		if t.ID == taskID {
+			m.tasks[i].Recurrence = r
+			return nil
+		}
+	}
+	return errors.New("task not found")
+}
+
+func (m *MockService) StartTimer(taskID int, at time.Time) (*service.TimeEntry, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	running, _ := m.RunningTimer()
+	if running != nil && running.TaskID == taskID {
+		return nil, nil
+	}
+	var stopped *service.TimeEntry
+	if running != nil {
+		stopped, _ = m.StopTimer(at)
+	}
+	for _, t := range m.tasks {
+		if t.ID == taskID {
+			e := service.TimeEntry{ID: len(m.timeEntries) + 1, TaskID: taskID, TaskTitle: t.Title, ProjectID: t.ProjectID, StartedAt: at}
+			for _, p := range m.projects {
+				if p.ID == t.ProjectID {
+					e.ProjectName = p.Name
+				}
+			}
+			m.timeEntries = append(m.timeEntries, e)
+			return stopped, nil
+		}
+	}
+	return nil, errors.New("task not found")
+}
+
+func (m *MockService) StopTimer(at time.Time) (*service.TimeEntry, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for i, e := range m.timeEntries {
+		if e.EndedAt == nil {
+			m.timeEntries[i].EndedAt = &at
+			stopped := m.timeEntries[i]
+			return &stopped, nil
+		}
+	}
+	return nil, errors.New("no timer running")
+}
+
+func (m *MockService) RunningTimer() (*service.TimeEntry, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, e := range m.timeEntries {
+		if e.EndedAt == nil {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockService) ListTimeEntries(projectID int) ([]service.TimeEntry, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var entries []service.TimeEntry
+	for _, e := range m.timeEntries {
+		if e.ProjectID == projectID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockService) ListProjectMilestones(projectID int) ([]service.Milestone, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var milestones []service.Milestone
+	for _, ms := range m.milestones {
+		if ms.ProjectID == projectID {
+			milestones = append(milestones, ms)
+		}
+	}
+	return milestones, nil
+}
+
+func (m *MockService) CreateMilestone(ms *service.Milestone) error {
+	if m.err != nil {
+		return m.err
+	}
+	ms.ID = len(m.milestones) + 1
+	m.milestones = append(m.milestones, *ms)
+	return nil
+}
+
+func (m *MockService) SetTaskMilestone(taskID int, milestoneID *int) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, t := range m.tasks {
+		if t.ID == taskID {
+			m.tasks[i].MilestoneID = milestoneID
+			return nil
+		}
+	}
+	return errors.New("task not found")
+}
+
+func (m *MockService) AddLink(l *service.Link) error {
+	if m.err != nil {
+		return m.err
+	}
+	if l.Label == "" {
+		l.Label = l.Target
+	}
+	l.ID = len(m.links) + 1
+	m.links = append(m.links, *l)
+	return nil
+}
+
+func (m *MockService) ListProjectLinks(projectID int) ([]service.Link, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var links []service.Link
+	for _, l := range m.links {
+		if l.ProjectID != nil && *l.ProjectID == projectID {
+			links = append(links, l)
+		}
+		if l.TaskID != nil && slices.ContainsFunc(m.tasks, func(t service.Task) bool {
+			return t.ID == *l.TaskID && t.ProjectID == projectID
+		}) {
+			links = append(links, l)
+		}
+	}
+	return links, nil
+}
+
+func (m *MockService) SetTaskTags(taskID int, tags []string) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, t := range m.tasks {
+		if t.ID == taskID {
+			m.tasks[i].Tags = tags
+			return nil
+		}
+	}
+	return errors.New("task not found")
+}
+
 func TestNewCoreModel(t *testing.T) {
 	mockService := &MockService{
 		projects: []service.Project{{ID: 1, Name: "Test Project"}},
@@ -601,7 +961,7 @@ func TestEditTask(t *testing.T) {
 	updatedTitle := "Updated Title"
 	updatedDesc := "Updated Desc"
 
-	cmd := coreModel.service.UpdateTask(coreModel.GetSelectedTask().ID, updatedTitle, updatedDesc, coreModel.GetSelectedTask().CompletedAt)
+	cmd := coreModel.service.UpdateTask(coreModel.GetSelectedTask().ID, updatedTitle, updatedDesc, coreModel.GetSelectedTask().CompletedAt, nil)
 
 	if cmd != nil { // UpdateTask returns an error, not a CoreCommand
 		t.Errorf("expected no error, got %v", cmd)
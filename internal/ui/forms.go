@@ -2,7 +2,9 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -14,87 +16,272 @@ import (
 
 var theme *huh.Theme = huh.ThemeDracula()
 
-func updateProjectForm(p service.Project) *huh.Form {
+func updateProjectForm(p service.Project, parents []service.Project) *huh.Form {
+	tags := strings.Join(p.Tags, ", ")
+	var parentID int
+	if p.ParentID != nil {
+		parentID = *p.ParentID
+	}
+	fields := []huh.Field{
+		huh.NewInput().
+			Title("Project Name").
+			Key("name").
+			Value(&p.Name).
+			Placeholder("My Awesome Project"),
+		huh.NewText().
+			Title("Project Summary").
+			Key("summary").
+			CharLimit(255).
+			Value(&p.Summary).
+			Placeholder("A short description of what this project is about..."),
+		huh.NewText().
+			Title("Detailed Description").
+			Key("desc").
+			Value(&p.Desc).
+			CharLimit(0).
+			Placeholder("Provide detailed information about your project..."),
+		huh.NewSelect[string]().
+			Title("Project Status").
+			Key("status").
+			Options(
+				huh.NewOption("◯ Todo", "todo"),
+				huh.NewOption("◐ In Progress", "in progress"),
+				huh.NewOption("● Completed", "completed"),
+				huh.NewOption("▣ Archived", "archived"),
+			).
+			Value(&p.Status),
+		huh.NewInput().
+			Title("Tags").
+			Key("tags").
+			Value(&tags).
+			Placeholder("client-acme, web").
+			Validate(validateTags),
+	}
+	if len(parents) > 0 {
+		fields = append(fields, projectParentSelect(parents, &parentID))
+	}
 	return huh.NewForm(
-		huh.NewGroup(
-			huh.NewInput().
-				Title("Project Name").
-				Key("name").
-				Value(&p.Name).
-				Placeholder("My Awesome Project"),
-			huh.NewText().
-				Title("Project Summary").
-				Key("summary").
-				CharLimit(255).
-				Value(&p.Summary).
-				Placeholder("A short description of what this project is about..."),
-			huh.NewText().
-				Title("Detailed Description").
-				Key("desc").
-				Value(&p.Desc).
-				CharLimit(0).
-				Placeholder("Provide detailed information about your project..."),
-			huh.NewSelect[string]().
-				Title("Project Status").
-				Key("status").
-				Options(
-					huh.NewOption("◯ Todo", "todo"),
-					huh.NewOption("◐ In Progress", "in progress"),
-					huh.NewOption("● Completed", "completed"),
-					huh.NewOption("▣ Archived", "archived"),
-				).
-				Value(&p.Status),
-		).Title("Update Project").
+		huh.NewGroup(fields...).Title("Update Project").
 			Description("Modify your project details"),
 	).WithTheme(theme)
 }
 
-func createProjectForm() *huh.Form {
+func createProjectForm(parents []service.Project) *huh.Form {
 	defaultValue := "todo"
+	var parentID int
+	fields := []huh.Field{
+		huh.NewInput().
+			Title("Project Name").
+			Key("name").
+			Placeholder("My New Project").
+			Validate(func(str string) error {
+				if len(str) == 0 {
+					return fmt.Errorf("project name is required")
+				}
+				return nil
+			}),
+		huh.NewText().
+			Title("Project Summary").
+			Key("summary").
+			CharLimit(255).
+			Placeholder("What is this project about in one sentence?"),
+		huh.NewText().
+			Title("Description (Optional)").
+			Key("desc").
+			CharLimit(0).
+			Placeholder("Provide comprehensive details about your project goals, requirements, and scope..."),
+		huh.NewSelect[string]().
+			Title("Status").
+			Key("status").
+			Options(
+				huh.NewOption("◯ Todo", "todo"),
+				huh.NewOption("◐ In Progress", "in progress"),
+				huh.NewOption("● Completed", "completed"),
+				huh.NewOption("▣ Archived", "archived"),
+			).
+			Value(&defaultValue),
+		huh.NewInput().
+			Title("Tags (Optional)").
+			Key("tags").
+			Placeholder("client-acme, web").
+			Validate(validateTags),
+	}
+	if len(parents) > 0 {
+		fields = append(fields, projectParentSelect(parents, &parentID))
+	}
+	return huh.NewForm(
+		huh.NewGroup(fields...).Title("Create New Project").
+			Description("Set up your new project with essential details"),
+	).WithTheme(theme)
+}
+
+// projectParentSelect picks the project, such as an area, a project belongs
+// to. The option for no parent has the value 0.
+func projectParentSelect(parents []service.Project, value *int) huh.Field {
+	options := []huh.Option[int]{huh.NewOption("No parent", 0)}
+	for _, p := range parents {
+		options = append(options, huh.NewOption(p.Name, p.ID))
+	}
+	return huh.NewSelect[int]().
+		Title("Parent Project").
+		Key("parent").
+		Options(options...).
+		Value(value)
+}
+
+func validateTags(s string) error {
+	_, err := service.ParseTags(s)
+	return err
+}
+
+// tagFilterForm picks the tag to narrow the project and task lists by.
+func tagFilterForm(tags []service.Tag, current string) *huh.Form {
+	options := []huh.Option[string]{huh.NewOption("All projects", "")}
+	for _, t := range tags {
+		label := fmt.Sprintf("#%s (%d projects, %d tasks)", t.Name, t.Projects, t.Tasks)
+		options = append(options, huh.NewOption(label, t.Name))
+	}
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Filter by tag").
+				Key("tag").
+				Options(options...).
+				Value(&current),
+		),
+	).WithTheme(theme)
+}
+
+// blockersForm picks the tasks that block task from candidates.
+func blockersForm(task service.Task, candidates []service.Task) *huh.Form {
+	options := make([]huh.Option[int], len(candidates))
+	for i, t := range candidates {
+		label := t.Title
+		if t.CompletedAt != nil {
+			label += " (done)"
+		}
+		options[i] = huh.NewOption(label, t.ID)
+	}
+	blockedBy := slices.Clone(task.BlockedBy)
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title(fmt.Sprintf("%s is blocked by", task.Title)).
+				Key("blockers").
+				Options(options...).
+				Value(&blockedBy),
+		),
+	).WithTheme(theme)
+}
+
+func createMilestoneForm() *huh.Form {
 	return huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
-				Title("Project Name").
+				Title("Milestone Name").
 				Key("name").
-				Placeholder("My New Project").
+				Placeholder("v1.0").
 				Validate(func(str string) error {
-					if len(str) == 0 {
-						return fmt.Errorf("project name is required")
+					if strings.TrimSpace(str) == "" {
+						return fmt.Errorf("milestone name is required")
 					}
 					return nil
 				}),
-			huh.NewText().
-				Title("Project Summary").
-				Key("summary").
-				CharLimit(255).
-				Placeholder("What is this project about in one sentence?"),
+			huh.NewInput().
+				Title("Target Date (Optional)").
+				Key("target").
+				Placeholder("2025-03-14, tomorrow, 2w").
+				Validate(func(str string) error {
+					_, err := service.ParseDueDate(str, time.Now())
+					return err
+				}),
 			huh.NewText().
 				Title("Description (Optional)").
 				Key("desc").
 				CharLimit(0).
-				Placeholder("Provide comprehensive details about your project goals, requirements, and scope..."),
-			huh.NewSelect[string]().
-				Title("Status").
-				Key("status").
-				Options(
-					huh.NewOption("◯ Todo", "todo"),
-					huh.NewOption("◐ In Progress", "in progress"),
-					huh.NewOption("● Completed", "completed"),
-					huh.NewOption("▣ Archived", "archived"),
-				).
-				Value(&defaultValue),
-		).Title("Create New Project").
-			Description("Set up your new project with essential details"),
+				Placeholder("What ships with this milestone?"),
+		).Title("Create New Milestone"),
+	).WithTheme(theme)
+}
+
+// taskMilestoneForm picks the milestone task is assigned to. The option for
+// no milestone has the value 0.
+func taskMilestoneForm(task service.Task, milestones []service.Milestone) *huh.Form {
+	options := []huh.Option[int]{huh.NewOption("No milestone", 0)}
+	for _, ms := range milestones {
+		label := ms.Name
+		if ms.TargetDate != nil {
+			label += " (" + ms.TargetDate.Local().Format(service.DueDateLayout) + ")"
+		}
+		options = append(options, huh.NewOption(label, ms.ID))
+	}
+	var current int
+	if task.MilestoneID != nil {
+		current = *task.MilestoneID
+	}
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title(fmt.Sprintf("Milestone of %s", task.Title)).
+				Key("milestone").
+				Options(options...).
+				Value(&current),
+		),
+	).WithTheme(theme)
+}
+
+// addLinkForm asks for a link to add to the project or task named owner.
+func addLinkForm(owner string) *huh.Form {
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("URL or File Path").
+				Key("target").
+				Placeholder("https://github.com/org/repo/pull/42, ~/docs/design.md").
+				Validate(func(str string) error {
+					if strings.TrimSpace(str) == "" {
+						return fmt.Errorf("a URL or file path is required")
+					}
+					return nil
+				}),
+			huh.NewInput().
+				Title("Label (Optional)").
+				Key("label").
+				Placeholder("Design doc"),
+		).Title(fmt.Sprintf("Add Link to %s", owner)),
+	).WithTheme(theme)
+}
+
+// openLinkForm picks which of links to open, by index.
+func openLinkForm(links []service.Link) *huh.Form {
+	options := make([]huh.Option[int], len(links))
+	for i, l := range links {
+		options[i] = huh.NewOption(l.Label, i)
+	}
+	var selected int
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title("Open Link").
+				Key("link").
+				Options(options...).
+				Value(&selected),
+		),
 	).WithTheme(theme)
 }
 
 // TaskEditForm represents the form for editing a task.
 type TaskEditForm struct {
-	titleInput textinput.Model
-	descInput  textarea.Model
-	focusIndex int
-	completed  bool
-	aborted    bool
+	titleInput  textinput.Model
+	tagsInput   textinput.Model
+	dueInput    textinput.Model
+	repeatInput textinput.Model
+	priority    service.Priority
+	descInput   textarea.Model
+	focusIndex  int
+	err         error
+	completed   bool
+	aborted     bool
 }
 
 func newTaskEditForm(task service.Task) *TaskEditForm {
@@ -103,6 +290,26 @@ func newTaskEditForm(task service.Task) *TaskEditForm {
 	titleInput.Focus()
 	titleInput.Width = 50
 
+	tagsInput := textinput.New()
+	tagsInput.Prompt = "# "
+	tagsInput.Placeholder = "tags"
+	tagsInput.SetValue(strings.Join(task.Tags, ", "))
+	tagsInput.Width = 50
+
+	dueInput := textinput.New()
+	dueInput.Prompt = "Due: "
+	dueInput.Placeholder = "YYYY-MM-DD, today, tomorrow, 3d, 2w"
+	if task.DueAt != nil {
+		dueInput.SetValue(task.DueAt.Local().Format(service.DueDateLayout))
+	}
+	dueInput.Width = 50
+
+	repeatInput := textinput.New()
+	repeatInput.Prompt = "Repeat: "
+	repeatInput.Placeholder = "daily, weekly mon,thu, monthly, every 14d"
+	repeatInput.SetValue(task.Recurrence.String())
+	repeatInput.Width = 50
+
 	descInput := textarea.New()
 	descInput.SetValue(task.Desc)
 	descInput.SetHeight(5)
@@ -111,9 +318,13 @@ func newTaskEditForm(task service.Task) *TaskEditForm {
     descInput.CharLimit = 0
 
 	return &TaskEditForm{
-		titleInput: titleInput,
-		descInput:  descInput,
-		focusIndex: 0,
+		titleInput:  titleInput,
+		tagsInput:   tagsInput,
+		dueInput:    dueInput,
+		repeatInput: repeatInput,
+		priority:    task.Priority,
+		descInput:   descInput,
+		focusIndex:  0,
 	}
 }
 
@@ -121,6 +332,14 @@ func (f *TaskEditForm) Init() tea.Cmd {
 	return textinput.Blink
 }
 
+// taskEditFields is the number of fields in the task edit form: title, tags,
+// due date, recurrence, priority and description, in focus order.
+const taskEditFields = 6
+
+// taskPriorityField is the focus index of the priority field, which is
+// changed with the arrow keys rather than typed.
+const taskPriorityField = 4
+
 func (f *TaskEditForm) Update(msg tea.Msg) (*TaskEditForm, tea.Cmd) {
 	var cmds []tea.Cmd
 
@@ -131,41 +350,86 @@ func (f *TaskEditForm) Update(msg tea.Msg) (*TaskEditForm, tea.Cmd) {
 			f.aborted = true
 			return f, nil
 		case "enter":
-			if f.focusIndex == 0 {
-				f.focusIndex = 1
-				f.titleInput.Blur()
-				f.descInput.Focus()
+			if f.focusIndex < taskEditFields-1 {
+				f.setFocus(f.focusIndex + 1)
 				return f, textinput.Blink
-			} else {
-				f.completed = true
-				return f, nil
 			}
-		case "tab":
-			if f.focusIndex == 0 {
-				f.focusIndex = 1
-				f.titleInput.Blur()
-				f.descInput.Focus()
-			} else {
-				f.focusIndex = 0
-				f.descInput.Blur()
-				f.titleInput.Focus()
+			if _, err := f.GetTags(); err != nil {
+				f.err = err
+				f.setFocus(1)
+				return f, textinput.Blink
+			}
+			if _, err := f.GetDueAt(); err != nil {
+				f.err = err
+				f.setFocus(2)
+				return f, textinput.Blink
+			}
+			if _, err := f.GetRecurrence(); err != nil {
+				f.err = err
+				f.setFocus(3)
+				return f, textinput.Blink
 			}
+			f.completed = true
+			return f, nil
+		case "tab":
+			f.setFocus((f.focusIndex + 1) % taskEditFields)
 			return f, textinput.Blink
 		}
+		if f.focusIndex == taskPriorityField {
+			switch msg.String() {
+			case "left", "h", "-":
+				f.priority = f.priority.Bump(-1)
+			case "right", "l", "+", "=":
+				f.priority = f.priority.Bump(1)
+			}
+			return f, nil
+		}
 	}
 
 	var cmd tea.Cmd
-	if f.focusIndex == 0 {
+	switch f.focusIndex {
+	case 0:
 		f.titleInput, cmd = f.titleInput.Update(msg)
-		cmds = append(cmds, cmd)
-	} else {
+	case 1:
+		f.tagsInput, cmd = f.tagsInput.Update(msg)
+		f.err = nil
+	case 2:
+		f.dueInput, cmd = f.dueInput.Update(msg)
+		f.err = nil
+	case 3:
+		f.repeatInput, cmd = f.repeatInput.Update(msg)
+		f.err = nil
+	case taskPriorityField:
+	default:
 		f.descInput, cmd = f.descInput.Update(msg)
-		cmds = append(cmds, cmd)
 	}
+	cmds = append(cmds, cmd)
 
 	return f, tea.Batch(cmds...)
 }
 
+func (f *TaskEditForm) setFocus(index int) {
+	f.focusIndex = index
+	f.titleInput.Blur()
+	f.tagsInput.Blur()
+	f.dueInput.Blur()
+	f.repeatInput.Blur()
+	f.descInput.Blur()
+	switch index {
+	case 0:
+		f.titleInput.Focus()
+	case 1:
+		f.tagsInput.Focus()
+	case 2:
+		f.dueInput.Focus()
+	case 3:
+		f.repeatInput.Focus()
+	case taskPriorityField:
+	default:
+		f.descInput.Focus()
+	}
+}
+
 func (f *TaskEditForm) View() string {
 	var s strings.Builder
 
@@ -173,6 +437,18 @@ func (f *TaskEditForm) View() string {
 	s.WriteString("\n")
 	s.WriteString(f.titleInput.View())
 	s.WriteString("\n")
+	s.WriteString(f.tagsInput.View())
+	s.WriteString("\n")
+	s.WriteString(f.dueInput.View())
+	s.WriteString("\n")
+	s.WriteString(f.repeatInput.View())
+	s.WriteString("\n")
+	s.WriteString(f.priorityView())
+	s.WriteString("\n")
+	if f.err != nil {
+		s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("197")).Render(f.err.Error()))
+		s.WriteString("\n")
+	}
 
 	s.WriteString("\n")
 	s.WriteString(f.descInput.View())
@@ -192,6 +468,37 @@ func (f *TaskEditForm) GetDesc() string {
 	return f.descInput.Value()
 }
 
+// GetTags returns the tags entered, separated by commas or spaces.
+func (f *TaskEditForm) GetTags() ([]string, error) {
+	return service.ParseTags(f.tagsInput.Value())
+}
+
+// priorityView shows the priority field as "Priority: ‹ high ›", with the
+// arrows only while it has focus.
+func (f *TaskEditForm) priorityView() string {
+	value := priorityLabel(f.priority)
+	if f.focusIndex == taskPriorityField {
+		return "Priority: " + lipgloss.NewStyle().Foreground(lipgloss.Color("#EE6FF8")).Render("‹ "+value+" ›")
+	}
+	return "Priority: " + value
+}
+
+// GetPriority returns the priority chosen.
+func (f *TaskEditForm) GetPriority() service.Priority {
+	return f.priority
+}
+
+// GetDueAt returns the due date entered, or nil when it was left empty.
+func (f *TaskEditForm) GetDueAt() (*time.Time, error) {
+	return service.ParseDueDate(f.dueInput.Value(), time.Now())
+}
+
+// GetRecurrence returns the rule the task repeats by, which is the zero
+// Recurrence when the field was left empty.
+func (f *TaskEditForm) GetRecurrence() (service.Recurrence, error) {
+	return service.ParseRecurrence(f.repeatInput.Value())
+}
+
 func (f *TaskEditForm) IsCompleted() bool {
 	return f.completed
 }
@@ -200,10 +507,18 @@ func (f *TaskEditForm) IsAborted() bool {
 	return f.aborted
 }
 
-// LogEditForm represents the form for editing a log entry.
+// logTaskRows is the number of tasks the log edit form's task picker shows
+// at once.
+const logTaskRows = 5
+
+// LogEditForm represents the form for editing a log entry. Below the body it
+// picks the tasks of the project the log is about.
 type LogEditForm struct {
 	titleInput textinput.Model
 	textarea   textarea.Model
+	tasks      []service.Task
+	picked     map[int]bool
+	taskCursor int
 
 	width     int
 	height    int
@@ -212,35 +527,64 @@ type LogEditForm struct {
 	aborted   bool
 }
 
-func newLogEditForm(width, height int) *LogEditForm {
+func newLogEditForm(width, height int, tasks []service.Task) *LogEditForm {
 	titleInput := textinput.New()
 	titleInput.Placeholder = "Log Title"
 	titleInput.Focus()
-	titleInput.Width = width - 6
 
 	ta := textarea.New()
 	ta.Placeholder = "Describe your log entry here [markdown support]..."
-	ta.SetWidth(width - 6)
-	ta.SetHeight(height - 6)
 	ta.ShowLineNumbers = false
 	ta.CharLimit = 0
 
-	return &LogEditForm{
+	f := &LogEditForm{
 		titleInput: titleInput,
 		textarea:   ta,
-		width:      width,
-		height:     height,
+		tasks:      tasks,
+		picked:     make(map[int]bool),
 		focus:      0,
 	}
+	f.setSize(width, height)
+	return f
 }
 
-func newLogEditFormWithData(width, height int, title, desc string) *LogEditForm {
-	form := newLogEditForm(width, height)
-	form.titleInput.SetValue(title)
-	form.textarea.SetValue(desc)
+func newLogEditFormWithData(width, height int, tasks []service.Task, log service.Log) *LogEditForm {
+	form := newLogEditForm(width, height, tasks)
+	form.titleInput.SetValue(log.Title)
+	form.textarea.SetValue(log.Desc)
+	for _, id := range log.TaskIDs {
+		form.picked[id] = true
+	}
 	return form
 }
 
+// setSize fits the form to width and height, leaving room under the body
+// for the task picker.
+func (f *LogEditForm) setSize(width, height int) {
+	f.width = width
+	f.height = height
+	f.titleInput.Width = width - 6
+	f.textarea.SetWidth(width - 6)
+	f.textarea.SetHeight(height - 6 - f.pickerHeight())
+}
+
+// pickerHeight is the number of lines the task picker takes up, counting the
+// blank line above it.
+func (f *LogEditForm) pickerHeight() int {
+	if len(f.tasks) == 0 {
+		return 0
+	}
+	return min(len(f.tasks), logTaskRows) + 2
+}
+
+// fields is the number of fields tab moves between.
+func (f *LogEditForm) fields() int {
+	if len(f.tasks) == 0 {
+		return 2
+	}
+	return 3
+}
+
 func (f *LogEditForm) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -256,21 +600,34 @@ func (f *LogEditForm) Update(msg tea.Msg) (*LogEditForm, tea.Cmd) {
 			f.completed = true
 			return f, nil
 		case "tab":
-			f.focus = (f.focus + 1) % 2
-			if f.focus == 0 {
+			f.focus = (f.focus + 1) % f.fields()
+			f.titleInput.Blur()
+			f.textarea.Blur()
+			switch f.focus {
+			case 0:
 				f.titleInput.Focus()
-				f.textarea.Blur()
-			} else {
-				f.titleInput.Blur()
+			case 1:
 				f.textarea.Focus()
 			}
 			return f, nil
 		}
+		if f.focus == 2 {
+			switch msg.String() {
+			case "up", "k":
+				f.taskCursor = max(f.taskCursor-1, 0)
+			case "down", "j":
+				f.taskCursor = min(f.taskCursor+1, len(f.tasks)-1)
+			case " ", "x", "enter":
+				id := f.tasks[f.taskCursor].ID
+				f.picked[id] = !f.picked[id]
+			}
+			return f, nil
+		}
 	}
 
 	if f.focus == 0 {
 		f.titleInput, cmd = f.titleInput.Update(msg)
-	} else {
+	} else if f.focus == 1 {
 		f.textarea, cmd = f.textarea.Update(msg)
 	}
 	cmds = append(cmds, cmd)
@@ -279,22 +636,67 @@ func (f *LogEditForm) Update(msg tea.Msg) (*LogEditForm, tea.Cmd) {
 }
 
 func (f *LogEditForm) View() string {
-	help := subStyle.Render("esc: save & return • tab: next field ")
-	return lipgloss.JoinVertical(
-		lipgloss.Left,
-		"",
-		f.titleInput.View(),
-		"",
-		f.textarea.View(),
-		"",
-		help,
-	)
+	help := "esc: save & return • tab: next field "
+	if f.focus == 2 {
+		help = "esc: save & return • tab: next field • space: link task "
+	}
+	parts := []string{"", f.titleInput.View(), "", f.textarea.View()}
+	if len(f.tasks) > 0 {
+		parts = append(parts, "", f.taskPickerView())
+	}
+	parts = append(parts, "", subStyle.Render(help))
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+// taskPickerView lists a window of the project's tasks around the cursor,
+// checking the ones the log is linked to.
+func (f *LogEditForm) taskPickerView() string {
+	var s strings.Builder
+	label := fmt.Sprintf("Tasks (%d linked)", len(f.TaskIDs()))
+	if f.focus == 2 {
+		s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#EE6FF8")).Render(label))
+	} else {
+		s.WriteString(subStyle.Render(label))
+	}
+
+	start := max(f.taskCursor-logTaskRows+1, 0)
+	end := min(start+logTaskRows, len(f.tasks))
+	for i := start; i < end; i++ {
+		t := f.tasks[i]
+		check := "[ ]"
+		if f.picked[t.ID] {
+			check = "[x]"
+		}
+		line := check + " " + t.Title
+		if t.CompletedAt != nil {
+			line += " (done)"
+		}
+		if f.focus == 2 && i == f.taskCursor {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("#EE6FF8")).Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		s.WriteString("\n" + line)
+	}
+	return s.String()
 }
 
 func (f *LogEditForm) GetContent() (string, string) {
 	return f.titleInput.Value(), f.textarea.Value()
 }
 
+// TaskIDs returns the IDs of the tasks picked for the log, in the order the
+// tasks are listed.
+func (f *LogEditForm) TaskIDs() []int {
+	var ids []int
+	for _, t := range f.tasks {
+		if f.picked[t.ID] {
+			ids = append(ids, t.ID)
+		}
+	}
+	return ids
+}
+
 func (f *LogEditForm) IsCompleted() bool {
 	return f.completed
 }
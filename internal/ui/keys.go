@@ -22,8 +22,50 @@ type ProjectKeyMap struct {
 	ToggleCompleted key.Binding
 	CreateObject    key.Binding
 	CreateTask      key.Binding
+	FilterTag       key.Binding
+	RaisePriority   key.Binding
+	LowerPriority   key.Binding
+	Indent          key.Binding
+	Outdent         key.Binding
+	ToggleSubtasks  key.Binding
+	SetBlockers     key.Binding
+	ToggleTimer     key.Binding
+	CreateMilestone key.Binding
+	SetMilestone    key.Binding
+	GroupMilestones key.Binding
+	MoveUp          key.Binding
+	MoveDown        key.Binding
+	NextState       key.Binding
+	PrevState       key.Binding
+	AddLink         key.Binding
+	OpenLink        key.Binding
 }
 
+// toggleSubprojectsKey collapses or expands a project in the project list.
+var toggleSubprojectsKey = key.NewBinding(
+	key.WithKeys("z"),
+	key.WithHelp("z", "collapse/expand subprojects"),
+)
+
+// archiveProjectKey archives the selected project, or unarchives it in the
+// archive.
+var archiveProjectKey = key.NewBinding(
+	key.WithKeys("a"),
+	key.WithHelp("a", "archive/unarchive"),
+)
+
+// showArchiveKey switches the project list to the archive and back.
+var showArchiveKey = key.NewBinding(
+	key.WithKeys("A"),
+	key.WithHelp("A", "show/leave archive"),
+)
+
+// hideCompletedKey hides or shows completed projects in the project list.
+var hideCompletedKey = key.NewBinding(
+	key.WithKeys("c"),
+	key.WithHelp("c", "hide/show completed"),
+)
+
 // ShortHelp returns a slice of keybindings for the short help view.
 func (k ProjectKeyMap) ShortHelp() []key.Binding {
 	return []key.Binding{
@@ -37,12 +79,14 @@ func (k ProjectKeyMap) FullHelp() [][]key.Binding {
 		// navigation
 		{
 			k.TabLeft, k.TabRight, k.GotoDetails, k.GotoTasks,
-			k.GotoLogs, k.CursorUp, k.CursorDown, k.Back,
+			k.GotoLogs, k.CursorUp, k.CursorDown, k.FilterTag, k.Back,
 		},
 		// actions
 		{
-			k.SelectObject, k.CreateObject, k.UpdateProject, k.CreateTask, k.CreateLog, k.Edit,
-			k.ToggleDone, k.ToggleCompleted, k.DeleteObject,
+			k.SelectObject, k.CreateObject, k.UpdateProject, k.CreateTask, k.CreateLog, k.CreateMilestone, k.Edit,
+			k.ToggleDone, k.NextState, k.PrevState, k.ToggleCompleted, k.RaisePriority, k.LowerPriority, k.MoveUp, k.MoveDown,
+			k.Indent, k.Outdent, k.ToggleSubtasks, k.SetBlockers, k.SetMilestone, k.GroupMilestones,
+			k.ToggleTimer, k.AddLink, k.OpenLink, k.DeleteObject,
 		},
 		// help
 		{k.Help},
@@ -123,4 +167,72 @@ var projectKeys = ProjectKeyMap{
 		key.WithKeys("c"),
 		key.WithHelp("c", "toggle completed"),
 	),
+	FilterTag: key.NewBinding(
+		key.WithKeys("#"),
+		key.WithHelp("#", "filter by tag"),
+	),
+	RaisePriority: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "raise priority"),
+	),
+	LowerPriority: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "lower priority"),
+	),
+	Indent: key.NewBinding(
+		key.WithKeys(">"),
+		key.WithHelp(">", "make subtask"),
+	),
+	Outdent: key.NewBinding(
+		key.WithKeys("<"),
+		key.WithHelp("<", "move out of parent"),
+	),
+	ToggleSubtasks: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "collapse/expand subtasks"),
+	),
+	SetBlockers: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "set blocked by"),
+	),
+	ToggleTimer: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "start/stop timer"),
+	),
+	CreateMilestone: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "create milestone"),
+	),
+	SetMilestone: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "set milestone"),
+	),
+	GroupMilestones: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "group by milestone"),
+	),
+	MoveUp: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "move task up"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "move task down"),
+	),
+	NextState: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next state"),
+	),
+	PrevState: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous state"),
+	),
+	AddLink: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "add link"),
+	),
+	OpenLink: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open link"),
+	),
 }
@@ -52,4 +52,74 @@ var (
 		Light: "#B2B2B2",
 		Dark:  "#6A6A6A",
 	})
+
+	tagChipStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#282A36")).
+			Padding(0, 1)
+
+	dueStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	dueTodayStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB86C"))
+	overdueStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
+
+	blockedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	timerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B"))
+
+	milestoneStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#8BE9FD"))
+	progressStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B"))
+
+	linkStyle      = lipgloss.NewStyle().Underline(true).Foreground(lipgloss.Color("#8BE9FD"))
+	linkErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
+
+	moveErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
 )
+
+// stateColors color the default workflow states. States added later use
+// defaultStateColor.
+var stateColors = map[string]lipgloss.Color{
+	"in progress": lipgloss.Color("#FFB86C"),
+	"blocked":     lipgloss.Color("#FF5555"),
+	"in review":   lipgloss.Color("#BD93F9"),
+}
+
+const defaultStateColor = lipgloss.Color("#8BE9FD")
+
+// stateStyle returns the style a workflow state is shown in.
+func stateStyle(state string) lipgloss.Style {
+	color, ok := stateColors[state]
+	if !ok {
+		color = defaultStateColor
+	}
+	return lipgloss.NewStyle().Foreground(color)
+}
+
+// blockedMarker is shown after the titles of tasks waiting on other tasks.
+const blockedMarker = "🔒"
+
+// timerMarker is shown after the title of the task being timed and in the
+// timer status line.
+const timerMarker = "⏱"
+
+// milestoneMarker is shown before milestone names.
+const milestoneMarker = "◆"
+
+// recurringMarker is shown after the titles of pending tasks that repeat.
+const recurringMarker = "↻"
+
+// priorityMarkers are shown after the titles of pending tasks, indexed by
+// priority. Tasks without a priority get no marker.
+var priorityMarkers = []string{"", "↓", "!", "!!", "!!!"}
+
+var priorityStyles = []lipgloss.Style{
+	lipgloss.NewStyle(),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("#F1FA8C")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB86C")).Bold(true),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Bold(true),
+}
+
+// tagColors are the chip backgrounds. A tag's color is picked from its name,
+// so it is the same wherever the tag is shown.
+var tagColors = []lipgloss.Color{
+	"#8BE9FD", "#50FA7B", "#FFB86C", "#FF79C6", "#BD93F9", "#F1FA8C", "#FF6E6E", "#A4FFFF",
+}
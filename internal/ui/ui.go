@@ -1,7 +1,12 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -23,14 +28,36 @@ type Service interface {
 	DeleteProject(id int) error
 	CreateProject(*service.Project) error
 	UpdateProject(*service.Project) error
+	SetProjectParent(projectID int, parentID *int) error
 	ListProjectTasks(projectID int) ([]service.Task, error)
-	CreateTask(projectID int, title, desc string) error
-	UpdateTask(id int, title, desc string, completedAt *time.Time) error
+	CreateTask(projectID int, title, desc string, dueAt *time.Time) (int, error)
+	UpdateTask(id int, title, desc string, completedAt, dueAt *time.Time) error
 	DeleteTask(id int) error
 	ListProjectLogs(projectID int) ([]service.Log, error)
-	CreateLog(projectID int, title, desc string) error
+	CreateLog(projectID int, title, desc string) (int, error)
 	UpdateLog(id int, title, desc string) error
 	DeleteLog(id int) error
+	SetLogTasks(logID int, taskIDs []int) error
+	ListTags() ([]service.Tag, error)
+	FilterProjects(f service.ProjectFilter) ([]service.Project, error)
+	SetProjectTags(projectID int, tags []string) error
+	SetTaskTags(taskID int, tags []string) error
+	SetTaskPriority(taskID int, p service.Priority) error
+	SetTaskParent(taskID int, parentID *int) error
+	MoveTask(taskID, position int) error
+	TaskStates() ([]string, error)
+	SetTaskState(taskID int, state string, at time.Time) error
+	SetTaskBlockers(taskID int, blockerIDs []int) error
+	SetTaskRecurrence(taskID int, r service.Recurrence) error
+	StartTimer(taskID int, at time.Time) (*service.TimeEntry, error)
+	StopTimer(at time.Time) (*service.TimeEntry, error)
+	RunningTimer() (*service.TimeEntry, error)
+	ListTimeEntries(projectID int) ([]service.TimeEntry, error)
+	ListProjectMilestones(projectID int) ([]service.Milestone, error)
+	CreateMilestone(*service.Milestone) error
+	SetTaskMilestone(taskID int, milestoneID *int) error
+	AddLink(*service.Link) error
+	ListProjectLinks(projectID int) ([]service.Link, error)
 }
 
 // Model represents the state of the UI.
@@ -53,9 +80,34 @@ type Model struct {
 	quickTaskInput    textinput.Model
 	quickInputActive  bool
 	showCompleted     bool
+	collapsed         map[int]bool // tasks whose subtasks are hidden
+	groupByMilestone  bool
 	logViewport       viewport.Model
 	glamourRenderer   *glamour.TermRenderer
 	logEditForm       *LogEditForm
+	workspace         string
+	// tagFilterReturn is the view to go back to when the tag filter form closes.
+	tagFilterReturn viewState
+	// blockersTaskID is the task whose blockers the blockers form edits.
+	blockersTaskID int
+	// milestoneTaskID is the task whose milestone the milestone form picks.
+	milestoneTaskID int
+	// linkTaskID is the task the link form adds to, or 0 for the project.
+	linkTaskID int
+	// openLinks are the links the open link form picks from.
+	openLinks []service.Link
+	// opener opens the target of a link outside the UI.
+	opener func(target string) error
+	// openErr is why the last link failed to open. It shows until the next key.
+	openErr error
+	// moveErr is why the last move up or down left the task in place. It
+	// shows until the next key.
+	moveErr error
+	// hyperlinks renders links as OSC 8 terminal hyperlinks.
+	hyperlinks bool
+	// timerGen counts timer starts and stops, so the ticks of a stopped timer
+	// do not keep redrawing alongside those of the next one.
+	timerGen int
 
 	// State for the custom delete confirmation dialog
 	deleteConfirmCursor int // 0 = cancel, 1 = delete
@@ -74,16 +126,12 @@ func NewModel(svc Service) (*Model, error) {
 	quickInput.Placeholder = "Add task"
 	quickInput.Width = 40
 
-	projects := coreModel.GetProjects()
-	items := make([]list.Item, len(projects))
-	for i, p := range projects {
-		items[i] = p
-	}
-
-	delegate := list.NewDefaultDelegate()
-	projectList := list.New(items, delegate, 40, 20)
+	projectList := list.New(projectItems(coreModel), list.NewDefaultDelegate(), 40, 20)
 	projectList.Title = "Addae"
 	projectList.SetShowHelp(true)
+	projectList.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{toggleSubprojectsKey, archiveProjectKey, showArchiveKey, hideCompletedKey}
+	}
 
 	// Initialize viewport for log pager
 	const width = 78
@@ -118,18 +166,427 @@ func NewModel(svc Service) (*Model, error) {
 		logViewport:       vp,
 		glamourRenderer:   renderer,
 		logViewFocus:      focusList,
+		opener:            openWithCommand,
+		hyperlinks:        supportsHyperlinks(),
 	}, nil
 }
 
+// SetOpener replaces the function that opens links, which runs the command
+// in $ADDAE_OPENER, or xdg-open, by default.
+func (m *Model) SetOpener(opener func(target string) error) {
+	m.opener = opener
+}
+
+// defaultOpener opens links when $ADDAE_OPENER is not set.
+const defaultOpener = "xdg-open"
+
+// openWithCommand opens target with the command in $ADDAE_OPENER, or
+// defaultOpener, and waits for it to exit.
+func openWithCommand(target string) error {
+	opener := strings.Fields(os.Getenv("ADDAE_OPENER"))
+	if len(opener) == 0 {
+		opener = []string{defaultOpener}
+	}
+	cmd := exec.Command(opener[0], append(opener[1:], target)...)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("opener %q failed: %w", opener[0], err)
+	}
+	return nil
+}
+
+// linkOpenedMsg reports that the opener exited, with its error if it failed.
+type linkOpenedMsg struct{ err error }
+
+// openLink runs the opener on l in the background.
+func (m *Model) openLink(l service.Link) tea.Cmd {
+	opener := m.opener
+	return func() tea.Msg {
+		return linkOpenedMsg{err: opener(l.Target)}
+	}
+}
+
+// linkTask returns the task that links are added to and opened from, or nil
+// when they belong to the selected project. It is false on the logs tab and
+// on the tasks tab with no task selected.
+func (m *Model) linkTask() (*service.Task, bool) {
+	switch m.activeTab {
+	case projectDetailTab:
+		return nil, m.CoreModel.GetSelectedProject() != nil
+	case tasksTab:
+		task := m.getVisualTask(m.selectedTaskIndex)
+		return task, task != nil
+	}
+	return nil, false
+}
+
+// openAddLinkForm shows the form adding a link to the selected task, or to
+// the project on the details tab.
+func (m *Model) openAddLinkForm() tea.Cmd {
+	task, ok := m.linkTask()
+	if !ok {
+		return nil
+	}
+	m.linkTaskID = 0
+	owner := m.CoreModel.GetSelectedProject().Name
+	if task != nil {
+		m.linkTaskID = task.ID
+		owner = task.Title
+	}
+	m.CoreModel.state = addLinkView
+	m.form = addLinkForm(owner)
+	return m.form.Init()
+}
+
+// openSelectedLinks opens the link of the selected task, or of the project
+// on the details tab. When there are several it shows a form picking one.
+func (m *Model) openSelectedLinks() tea.Cmd {
+	task, ok := m.linkTask()
+	if !ok {
+		return nil
+	}
+	links := m.CoreModel.ProjectLinks()
+	if task != nil {
+		links = m.CoreModel.TaskLinks(task.ID)
+	}
+	switch len(links) {
+	case 0:
+		return nil
+	case 1:
+		return m.openLink(links[0])
+	}
+	m.openLinks = links
+	m.CoreModel.state = openLinkView
+	m.form = openLinkForm(links)
+	return m.form.Init()
+}
+
+// SetWorkspace shows the name of the open workspace in the project list title.
+func (m *Model) SetWorkspace(name string) {
+	m.workspace = name
+	m.updateListTitle()
+}
+
+// updateListTitle shows the open workspace and the tag filter, if any, in
+// the project list title.
+func (m *Model) updateListTitle() {
+	title := "Addae"
+	if m.workspace != "" {
+		title += " · " + m.workspace
+	}
+	if m.CoreModel.IsArchive() {
+		title += " · Archive"
+	} else if m.CoreModel.HidesCompleted() {
+		title += " · hiding completed"
+	}
+	if tag := m.CoreModel.GetTagFilter(); tag != "" {
+		title += " · #" + tag
+	}
+	m.list.Title = title
+}
+
+// openTagFilter shows the form picking the tag to filter by.
+func (m *Model) openTagFilter() tea.Cmd {
+	tags, err := m.CoreModel.ListTags()
+	if err != nil {
+		m.CoreModel.err = err
+		return nil
+	}
+	m.tagFilterReturn = m.CoreModel.GetState()
+	m.CoreModel.state = tagFilterView
+	m.form = tagFilterForm(tags, m.CoreModel.GetTagFilter())
+	return m.form.Init()
+}
+
+// openBlockersForm shows the form picking the tasks that block task. Tasks
+// already waiting on it are left out, as they cannot block it in turn.
+func (m *Model) openBlockersForm(task service.Task) tea.Cmd {
+	waiting := m.waitingOn(task.ID)
+	var candidates []service.Task
+	for _, t := range m.CoreModel.GetTasks() {
+		if t.ID != task.ID && !waiting[t.ID] {
+			candidates = append(candidates, t)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	m.blockersTaskID = task.ID
+	m.CoreModel.state = blockersView
+	m.form = blockersForm(task, candidates)
+	return m.form.Init()
+}
+
+// openTaskMilestoneForm shows the form picking the milestone of task. It
+// does nothing when the project has no milestones.
+func (m *Model) openTaskMilestoneForm(task service.Task) tea.Cmd {
+	milestones := m.CoreModel.GetMilestones()
+	if len(milestones) == 0 {
+		return nil
+	}
+	m.milestoneTaskID = task.ID
+	m.CoreModel.state = taskMilestoneView
+	m.form = taskMilestoneForm(task, milestones)
+	return m.form.Init()
+}
+
+// waitingOn returns the IDs of the tasks blocked by the task with the given
+// ID, directly or through other tasks.
+func (m *Model) waitingOn(id int) map[int]bool {
+	waiting := make(map[int]bool)
+	queue := []int{id}
+	for len(queue) > 0 {
+		blocker := queue[0]
+		queue = queue[1:]
+		for _, t := range m.CoreModel.GetTasks() {
+			if !waiting[t.ID] && slices.Contains(t.BlockedBy, blocker) {
+				waiting[t.ID] = true
+				queue = append(queue, t.ID)
+			}
+		}
+	}
+	return waiting
+}
+
+// applyTagFilter narrows the project list by tag, staying on the selected
+// project when it is still listed.
+func (m *Model) applyTagFilter(tag string) CoreCommand {
+	selectedID := 0
+	if p := m.CoreModel.GetSelectedProject(); p != nil {
+		selectedID = p.ID
+	}
+	if m.CoreModel.SetTagFilter(tag) == CoreShowError {
+		return CoreShowError
+	}
+	m.updateListTitle()
+	m.refreshListItems()
+
+	m.CoreModel.GoToListView()
+	for i, p := range m.CoreModel.GetProjects() {
+		if p.ID != selectedID {
+			continue
+		}
+		m.list.Select(i)
+		m.loadProjectDetails(i)
+		if m.tagFilterReturn == projectView && m.CoreModel.SelectProject(i) != CoreShowError {
+			m.CoreModel.GoToProjectView()
+		}
+		break
+	}
+	return NoCoreCmd
+}
+
+// selectProjectByID moves the project cursor to id and loads the project,
+// reporting whether the project is listed.
+func (m *Model) selectProjectByID(id int) bool {
+	i := m.projectIndex(id)
+	if i < 0 {
+		return false
+	}
+	m.list.Select(i)
+	m.loadProjectDetails(i)
+	return true
+}
+
+// projectIndex returns the index of a project in the project list, or -1.
+func (m *Model) projectIndex(id int) int {
+	for i, p := range m.CoreModel.GetProjects() {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// switchProjectList changes which projects the project list shows with
+// toggle, staying on the selected project when it is still listed.
+func (m *Model) switchProjectList(toggle func() CoreCommand) {
+	selectedID := 0
+	if p := m.CoreModel.GetSelectedProject(); p != nil {
+		selectedID = p.ID
+	}
+	if toggle() == CoreShowError {
+		return
+	}
+	m.updateListTitle()
+	m.refreshListItems()
+	if !m.selectProjectByID(selectedID) && len(m.CoreModel.GetProjects()) > 0 {
+		m.list.Select(0)
+		m.loadProjectDetails(0)
+	}
+}
+
+// OpenTarget is a place in the UI to start at instead of the project list.
+type OpenTarget struct {
+	ProjectID int
+	// Tab is "details", "tasks" or "logs". It defaults to the tab of the
+	// requested task or log, or to details.
+	Tab    string
+	TaskID int
+	LogID  int
+}
+
+// tabNames maps the names accepted by OpenTarget.Tab to detail tabs.
+var tabNames = map[string]detailTab{
+	"details": projectDetailTab,
+	"tasks":   tasksTab,
+	"logs":    logsTab,
+}
+
+// Open selects the target project and moves to the requested tab, placing the
+// cursor on the requested task or log and showing it.
+func (m *Model) Open(target OpenTarget) error {
+	if target.TaskID != 0 && target.LogID != 0 {
+		return fmt.Errorf("cannot open a task and a log at the same time")
+	}
+
+	tabName := target.Tab
+	switch {
+	case tabName == "" && target.TaskID != 0:
+		tabName = "tasks"
+	case tabName == "" && target.LogID != 0:
+		tabName = "logs"
+	case tabName == "":
+		tabName = "details"
+	}
+	tab, ok := tabNames[tabName]
+	if !ok {
+		return fmt.Errorf("unknown tab %q, expected details, tasks or logs", tabName)
+	}
+	if target.TaskID != 0 && tab != tasksTab {
+		return fmt.Errorf("a task can only be opened on the tasks tab")
+	}
+	if target.LogID != 0 && tab != logsTab {
+		return fmt.Errorf("a log can only be opened on the logs tab")
+	}
+
+	m.CoreModel.RevealProject(target.ProjectID)
+	m.refreshListItems()
+	index := m.projectIndex(target.ProjectID)
+	if index < 0 && !m.CoreModel.IsArchive() {
+		// The project may be archived.
+		m.switchProjectList(m.CoreModel.ToggleArchive)
+		m.CoreModel.RevealProject(target.ProjectID)
+		m.refreshListItems()
+		if index = m.projectIndex(target.ProjectID); index < 0 {
+			m.switchProjectList(m.CoreModel.ToggleArchive)
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("project %d not found", target.ProjectID)
+	}
+
+	m.list.Select(index)
+	m.loadProjectDetails(index)
+	if m.CoreModel.SelectProject(index) == CoreShowError {
+		return m.CoreModel.GetError()
+	}
+	m.CoreModel.GoToProjectView()
+	m.activeTab = tab
+
+	if target.TaskID != 0 {
+		return m.openTask(target.TaskID)
+	}
+	if target.LogID != 0 {
+		return m.openLog(target.LogID)
+	}
+	return nil
+}
+
+// openTask moves the task cursor to id and shows the task, revealing
+// completed tasks when it is one of them.
+func (m *Model) openTask(id int) error {
+	var task *service.Task
+	for _, t := range m.CoreModel.GetTasks() {
+		if t.ID == id {
+			task = &t
+			break
+		}
+	}
+	if task == nil {
+		return fmt.Errorf("task %d is not in project %s", id, m.CoreModel.GetSelectedProject().Name)
+	}
+	if task.CompletedAt != nil {
+		m.showCompleted = true
+	}
+
+	if !m.selectTaskByID(id) {
+		return fmt.Errorf("task %d not found", id)
+	}
+	m.taskDetailMode = taskDetailReadonly
+	return nil
+}
+
+// selectTaskByID moves the task cursor to id, reporting whether the task is
+// in the visible part of the list.
+func (m *Model) selectTaskByID(id int) bool {
+	for i := 0; i <= m.getMaxNavigableTaskIndex(); i++ {
+		if t := m.getVisualTask(i); t != nil && t.ID == id {
+			m.selectedTaskIndex = i
+			m.CoreModel.selectedTask = t
+			return true
+		}
+	}
+	return false
+}
+
+// openLog moves the log cursor to id and shows the log.
+func (m *Model) openLog(id int) error {
+	for i, l := range m.CoreModel.GetLogs() {
+		if l.ID != id {
+			continue
+		}
+		log := m.getLogAtIndex(i)
+		m.selectedLogIndex = i
+		m.CoreModel.selectedLog = log
+		m.logDetailMode = logDetailReadonly
+		m.logViewFocus = focusList
+
+		m.setLogContent(log)
+		m.logViewport.GotoTop()
+		return nil
+	}
+	return fmt.Errorf("log %d is not in project %s", id, m.CoreModel.GetSelectedProject().Name)
+}
+
+// timerTickMsg redraws the timer status line. gen is the timerGen the tick
+// was scheduled under.
+type timerTickMsg struct{ gen int }
+
+// timerTick schedules the next redraw of the running timer, if there is one.
+func (m *Model) timerTick() tea.Cmd {
+	if m.CoreModel.GetRunningTimer() == nil {
+		return nil
+	}
+	gen := m.timerGen
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return timerTickMsg{gen} })
+}
+
 // Init initializes the UI model.
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(tea.EnterAltScreen)
+	return tea.Batch(tea.EnterAltScreen, m.timerTick())
 }
 
 // Update handles messages and updates the UI model.
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	// Keep the timer ticking whatever view or dialog is showing.
+	if tick, ok := msg.(timerTickMsg); ok {
+		if tick.gen != m.timerGen {
+			return m, nil
+		}
+		return m, m.timerTick()
+	}
+
+	switch msg := msg.(type) {
+	case linkOpenedMsg:
+		m.openErr = msg.err
+		return m, nil
+	case tea.KeyMsg:
+		m.openErr = nil
+		m.moveErr = nil
+	}
+
 	// Handle the delete confirmation dialog first if it's visible.
 	if m.deleteDialogType != noDialog {
 		return m.updateConfirmDeleteDialog(msg)
@@ -157,20 +614,13 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Re-render log content if a log is selected
 		if m.activeTab == logsTab && m.logDetailMode == logDetailReadonly {
 			if log := m.CoreModel.GetSelectedLog(); log != nil {
-				rendered, err := m.glamourRenderer.Render(log.Desc)
-				if err != nil {
-					rendered = log.Desc // fallback to plain text
-				}
-				m.logViewport.SetContent(rendered)
+				m.setLogContent(log)
 			}
 		}
 
 		// Update log edit form if active
 		if m.logEditForm != nil {
-			m.logEditForm.width = m.width
-			m.logEditForm.height = m.height
-			m.logEditForm.textarea.SetWidth(m.width - 6)
-			m.logEditForm.textarea.SetHeight(m.height - 6)
+			m.logEditForm.setSize(m.width, m.height)
 		}
 	}
 
@@ -201,6 +651,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateFullscreenLogEdit(msg)
 	case deleteLogView:
 		return m.updateFormView(msg, "deleteLog")
+	case tagFilterView:
+		return m.updateFormView(msg, "tagFilter")
+	case blockersView:
+		return m.updateFormView(msg, "blockers")
+	case createMilestoneView:
+		return m.updateFormView(msg, "createMilestone")
+	case taskMilestoneView:
+		return m.updateFormView(msg, "taskMilestone")
+	case addLinkView:
+		return m.updateFormView(msg, "addLink")
+	case openLinkView:
+		return m.updateFormView(msg, "openLink")
 	}
 
 	return m, cmd
@@ -232,8 +694,28 @@ func (m *Model) updateListView(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			case "n":
 				m.CoreModel.GoToCreateView()
-				m.form = createProjectForm()
+				m.form = createProjectForm(m.CoreModel.ParentCandidates(0))
 				return m, m.form.Init()
+			case "z":
+				if p := m.CoreModel.GetSelectedProject(); p != nil && len(m.CoreModel.Subprojects(p.ID)) > 0 {
+					m.CoreModel.ToggleProjectCollapsed(p.ID)
+					m.refreshListItems()
+					m.selectProjectByID(p.ID)
+				}
+			case "a":
+				if selected := m.list.Index(); selected >= 0 && selected < len(m.CoreModel.GetProjects()) {
+					if m.CoreModel.ArchiveProject(selected) != CoreShowError {
+						m.refreshListItems()
+					}
+				}
+			case "A":
+				m.switchProjectList(m.CoreModel.ToggleArchive)
+			case "c":
+				if !m.CoreModel.IsArchive() {
+					m.switchProjectList(m.CoreModel.ToggleHideCompleted)
+				}
+			case "#":
+				return m, m.openTagFilter()
 			case "d":
 				if selectedIndex := m.list.Index(); selectedIndex >= 0 {
 					projects := m.CoreModel.GetProjects()
@@ -252,7 +734,7 @@ func (m *Model) updateListView(msg tea.Msg) (tea.Model, tea.Cmd) {
 						selectedProject := projects[selectedIndex]
 						m.CoreModel.selectedProject = &selectedProject
 						m.CoreModel.GoToUpdateView()
-						m.form = updateProjectForm(selectedProject)
+						m.form = updateProjectForm(selectedProject, m.CoreModel.ParentCandidates(selectedProject.ID))
 						return m, m.form.Init()
 					}
 				}
@@ -286,8 +768,9 @@ func (m *Model) updateFullscreenLogEdit(msg tea.Msg) (tea.Model, tea.Cmd) {
 		title, desc := m.logEditForm.GetContent()
 		if title != "" {
 			data := LogFormData{
-				Title: title,
-				Desc:  desc,
+				Title:   title,
+				Desc:    desc,
+				TaskIDs: m.logEditForm.TaskIDs(),
 			}
 			var coreCmd CoreCommand
 			isCreating := m.GetState() != updateLogView
@@ -311,11 +794,7 @@ func (m *Model) updateFullscreenLogEdit(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 				// Re-render log content after editing
 				if log := m.CoreModel.GetSelectedLog(); log != nil {
-					rendered, err := m.glamourRenderer.Render(log.Desc)
-					if err != nil {
-						rendered = log.Desc // fallback to plain text
-					}
-					m.logViewport.SetContent(rendered)
+					m.setLogContent(log)
 				}
 				m.activeTab = logsTab
 			case CoreShowError:
@@ -342,20 +821,11 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter":
 				title := strings.TrimSpace(m.quickTaskInput.Value())
 				if title != "" {
-					m.CoreModel.service.CreateTask(m.GetSelectedProject().ID, title, "")
+					id, _ := m.CoreModel.service.CreateTask(m.GetSelectedProject().ID, title, "", nil)
 					m.refreshTasks()
 
 					// After creating, move cursor to the new task
-					var pendingTasks []service.Task
-					for _, t := range m.CoreModel.GetTasks() {
-						if t.CompletedAt == nil {
-							pendingTasks = append(pendingTasks, t)
-						}
-					}
-					m.selectedTaskIndex = len(pendingTasks) - 1
-
-					// Ensure the selected task pointer is synchronized
-					m.CoreModel.selectedTask = m.getVisualTask(m.selectedTaskIndex)
+					m.selectTaskByID(id)
 					m.quickTaskInput.SetValue("")
 				}
 				m.quickInputActive = false
@@ -380,7 +850,7 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if log := m.getLogAtIndex(m.selectedLogIndex); log != nil {
 					m.CoreModel.selectedLog = log
 					m.CoreModel.state = updateLogView
-					m.logEditForm = newLogEditFormWithData(m.width, m.height, log.Title, log.Desc)
+					m.logEditForm = newLogEditFormWithData(m.width, m.height, m.CoreModel.tasks, *log)
 					return m, m.logEditForm.Init()
 				}
 			default:
@@ -418,7 +888,7 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case key.Matches(msg, m.keys.UpdateProject):
 				m.CoreModel.GoToUpdateView()
 				if project := m.CoreModel.GetSelectedProject(); project != nil {
-					m.form = updateProjectForm(*project)
+					m.form = updateProjectForm(*project, m.CoreModel.ParentCandidates(project.ID))
 					return m, m.form.Init()
 				}
 			case key.Matches(msg, m.keys.CreateTask):
@@ -428,7 +898,7 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, textinput.Blink
 			case key.Matches(msg, m.keys.CreateLog):
 				m.CoreModel.state = fullscreenLogEditView
-				m.logEditForm = newLogEditForm(m.width, m.height)
+				m.logEditForm = newLogEditForm(m.width, m.height, m.CoreModel.tasks)
 				return m, m.logEditForm.Init()
 			case key.Matches(msg, m.keys.CreateObject) && m.activeTab == tasksTab:
 				m.quickInputActive = true
@@ -449,11 +919,11 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			case key.Matches(msg, m.keys.CreateLog):
 				m.CoreModel.state = fullscreenLogEditView
-				m.logEditForm = newLogEditForm(m.width, m.height)
+				m.logEditForm = newLogEditForm(m.width, m.height, m.CoreModel.tasks)
 				return m, m.logEditForm.Init()
 			case key.Matches(msg, m.keys.CreateObject) && m.activeTab == logsTab:
 				m.CoreModel.state = fullscreenLogEditView
-				m.logEditForm = newLogEditForm(m.width, m.height)
+				m.logEditForm = newLogEditForm(m.width, m.height, m.CoreModel.tasks)
 				return m, m.logEditForm.Init()
 			case key.Matches(msg, m.keys.GotoDetails):
 				m.activeTab = projectDetailTab
@@ -488,6 +958,16 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.CoreModel.selectedTask = nil
 				m.CoreModel.selectedLog = nil
 				m.logViewFocus = focusList
+			case key.Matches(msg, m.keys.CreateMilestone):
+				m.CoreModel.state = createMilestoneView
+				m.form = createMilestoneForm()
+				return m, m.form.Init()
+			case key.Matches(msg, m.keys.AddLink):
+				return m, m.openAddLinkForm()
+			case key.Matches(msg, m.keys.OpenLink):
+				return m, m.openSelectedLinks()
+			case key.Matches(msg, m.keys.FilterTag):
+				return m, m.openTagFilter()
 			case key.Matches(msg, m.keys.Back):
 				m.CoreModel.GoToListView()
 				return m, nil
@@ -576,14 +1056,34 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 						if task != nil {
 							title := m.taskEditForm.GetTitle()
 							desc := m.taskEditForm.GetDesc()
+							tags, _ := m.taskEditForm.GetTags()
+							dueAt, _ := m.taskEditForm.GetDueAt()
+							priority := m.taskEditForm.GetPriority()
+							recurrence, _ := m.taskEditForm.GetRecurrence()
 
-							if err := m.CoreModel.service.UpdateTask(task.ID, title, desc, task.CompletedAt); err != nil {
+							if err := m.CoreModel.service.UpdateTask(task.ID, title, desc, task.CompletedAt, dueAt); err != nil {
+								m.CoreModel.err = err
+								return m, nil
+							}
+							if err := m.CoreModel.service.SetTaskPriority(task.ID, priority); err != nil {
+								m.CoreModel.err = err
+								return m, nil
+							}
+							if err := m.CoreModel.service.SetTaskTags(task.ID, tags); err != nil {
+								m.CoreModel.err = err
+								return m, nil
+							}
+							if err := m.CoreModel.service.SetTaskRecurrence(task.ID, recurrence); err != nil {
 								m.CoreModel.err = err
 								return m, nil
 							}
 
 							task.Title = title
 							task.Desc = desc
+							task.Tags = tags
+							task.DueAt = dueAt
+							task.Priority = priority
+							task.Recurrence = recurrence
 
 							for i, t := range m.CoreModel.tasks {
 								if t.ID == task.ID {
@@ -611,11 +1111,7 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.logDetailMode = logDetailReadonly
 					// Update viewport with rendered markdown
 					if log := m.CoreModel.GetSelectedLog(); log != nil {
-						rendered, err := m.glamourRenderer.Render(log.Desc)
-						if err != nil {
-							rendered = log.Desc // fallback to plain text
-						}
-						m.logViewport.SetContent(rendered)
+						m.setLogContent(log)
 					}
 				}
 				return model, cmd
@@ -629,11 +1125,7 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 							if log := m.getLogAtIndex(m.selectedLogIndex); log != nil {
 								m.CoreModel.selectedLog = log
 								// Update viewport content
-								rendered, err := m.glamourRenderer.Render(log.Desc)
-								if err != nil {
-									rendered = log.Desc
-								}
-								m.logViewport.SetContent(rendered)
+								m.setLogContent(log)
 								m.logViewport.GotoTop()
 							}
 						}
@@ -644,11 +1136,7 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 							if log := m.getLogAtIndex(m.selectedLogIndex); log != nil {
 								m.CoreModel.selectedLog = log
 								// Update viewport content
-								rendered, err := m.glamourRenderer.Render(log.Desc)
-								if err != nil {
-									rendered = log.Desc
-								}
-								m.logViewport.SetContent(rendered)
+								m.setLogContent(log)
 								m.logViewport.GotoTop()
 							}
 						}
@@ -658,14 +1146,14 @@ func (m *Model) updateProjectViewCommon(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 					case key.Matches(msg, m.keys.CreateObject):
 						m.CoreModel.state = fullscreenLogEditView
-						m.logEditForm = newLogEditForm(m.width, m.height)
+						m.logEditForm = newLogEditForm(m.width, m.height, m.CoreModel.tasks)
 						return m, m.logEditForm.Init()
 
 					case key.Matches(msg, m.keys.Edit):
 						if log := m.getLogAtIndex(m.selectedLogIndex); log != nil {
 							m.CoreModel.selectedLog = log
 							m.CoreModel.state = updateLogView
-							m.logEditForm = newLogEditFormWithData(m.width, m.height, log.Title, log.Desc)
+							m.logEditForm = newLogEditFormWithData(m.width, m.height, m.CoreModel.tasks, *log)
 							return m, m.logEditForm.Init()
 						}
 					case key.Matches(msg, m.keys.DeleteObject):
@@ -725,6 +1213,98 @@ func (m *Model) updateTasksList(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selectedTaskIndex = maxIndex
 				}
 			}
+		case key.Matches(msg, m.keys.NextState), key.Matches(msg, m.keys.PrevState):
+			if task := m.getVisualTask(m.selectedTaskIndex); task != nil {
+				delta := 1
+				if key.Matches(msg, m.keys.PrevState) {
+					delta = -1
+				}
+				if cmd := m.CoreModel.CycleTaskState(task.ID, delta); cmd == CoreShowError {
+					return m, nil
+				}
+				// Follow the task, unless it moved into the hidden completed
+				// section.
+				if !m.selectTaskByID(task.ID) {
+					m.selectedTaskIndex = min(m.selectedTaskIndex, max(m.getMaxNavigableTaskIndex(), 0))
+				}
+			}
+		case key.Matches(msg, m.keys.RaisePriority), key.Matches(msg, m.keys.LowerPriority):
+			if task := m.getVisualTask(m.selectedTaskIndex); task != nil {
+				delta := 1
+				if key.Matches(msg, m.keys.LowerPriority) {
+					delta = -1
+				}
+				if cmd := m.CoreModel.BumpTaskPriority(task.ID, delta); cmd == CoreShowError {
+					return m, nil
+				}
+				// Keep the cursor on the task as it moves up or down the list.
+				m.selectTaskByID(task.ID)
+			}
+		case key.Matches(msg, m.keys.MoveUp), key.Matches(msg, m.keys.MoveDown):
+			if task := m.getVisualTask(m.selectedTaskIndex); task != nil {
+				other, err := m.taskNeighbour(m.selectedTaskIndex, key.Matches(msg, m.keys.MoveUp))
+				if err != nil {
+					m.moveErr = err
+					return m, nil
+				}
+				if other == nil {
+					return m, nil
+				}
+				// Taking the neighbour's position puts the task on its other side.
+				if cmd := m.CoreModel.MoveTask(task.ID, other.Position); cmd == CoreShowError {
+					return m, nil
+				}
+				m.selectTaskByID(task.ID)
+			}
+		case key.Matches(msg, m.keys.Indent), key.Matches(msg, m.keys.Outdent):
+			if task := m.getVisualTask(m.selectedTaskIndex); task != nil {
+				parentID, ok := m.moveTaskParent(m.selectedTaskIndex, key.Matches(msg, m.keys.Indent))
+				if !ok {
+					return m, nil
+				}
+				if cmd := m.CoreModel.SetTaskParent(task.ID, parentID); cmd == CoreShowError {
+					return m, nil
+				}
+				// Expand the new parent so the task stays in view.
+				if parentID != nil {
+					delete(m.collapsed, *parentID)
+				}
+				m.selectTaskByID(task.ID)
+			}
+		case key.Matches(msg, m.keys.ToggleSubtasks):
+			if task := m.getVisualTask(m.selectedTaskIndex); task != nil {
+				if m.collapsed == nil {
+					m.collapsed = make(map[int]bool)
+				}
+				if m.collapsed[task.ID] {
+					delete(m.collapsed, task.ID)
+				} else {
+					m.collapsed[task.ID] = true
+				}
+			}
+		case key.Matches(msg, m.keys.SetBlockers):
+			if task := m.getVisualTask(m.selectedTaskIndex); task != nil {
+				return m, m.openBlockersForm(*task)
+			}
+		case key.Matches(msg, m.keys.SetMilestone):
+			if task := m.getVisualTask(m.selectedTaskIndex); task != nil {
+				return m, m.openTaskMilestoneForm(*task)
+			}
+		case key.Matches(msg, m.keys.GroupMilestones):
+			selected := m.getVisualTask(m.selectedTaskIndex)
+			m.groupByMilestone = !m.groupByMilestone
+			// Keep the cursor on the task as the list is regrouped.
+			if selected != nil {
+				m.selectTaskByID(selected.ID)
+			}
+		case key.Matches(msg, m.keys.ToggleTimer):
+			if task := m.getVisualTask(m.selectedTaskIndex); task != nil {
+				if cmd := m.CoreModel.ToggleTimer(task.ID); cmd == CoreShowError {
+					return m, nil
+				}
+				m.timerGen++
+				return m, m.timerTick()
+			}
 		case key.Matches(msg, m.keys.DeleteObject):
 			if task := m.getVisualTask(m.selectedTaskIndex); task != nil {
 				m.CoreModel.selectedTask = task
@@ -789,7 +1369,7 @@ func (m *Model) updateLogsList(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if log := m.getLogAtIndex(m.selectedLogIndex); log != nil {
 				m.CoreModel.selectedLog = log
 				m.CoreModel.state = updateLogView
-				m.logEditForm = newLogEditFormWithData(m.width, m.height, log.Title, log.Desc)
+				m.logEditForm = newLogEditFormWithData(m.width, m.height, m.CoreModel.tasks, *log)
 				return m, m.logEditForm.Init()
 			}
 		case key.Matches(msg, m.keys.DeleteObject):
@@ -804,7 +1384,7 @@ func (m *Model) updateLogsList(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case key.Matches(msg, m.keys.CreateObject):
 			m.CoreModel.state = fullscreenLogEditView
-			m.logEditForm = newLogEditForm(m.width, m.height)
+			m.logEditForm = newLogEditForm(m.width, m.height, m.CoreModel.tasks)
 			return m, m.logEditForm.Init()
 		case key.Matches(msg, m.keys.Back):
 			m.CoreModel.GoToListView()
@@ -828,6 +1408,15 @@ func (m *Model) updateFormView(msg tea.Msg, formType string) (tea.Model, tea.Cmd
 		m.form = nil
 		return m, nil
 	} else if m.form.State == huh.StateCompleted {
+		if formType == "openLink" {
+			// Opening a link runs outside the UI, so it is a command rather
+			// than a change to the core model.
+			i, _ := m.form.Get("link").(int)
+			link := m.openLinks[i]
+			m.form, m.openLinks = nil, nil
+			m.CoreModel.GoToProjectView()
+			return m, m.openLink(link)
+		}
 		coreCmd := m.handleFormCompletion(formType)
 		m.form = nil
 
@@ -864,28 +1453,72 @@ func (m *Model) handleFormAbort(formType string) {
 	case "deleteLog":
 		m.CoreModel.GoToProjectView()
 		m.activeTab = logsTab
+	case "tagFilter":
+		m.CoreModel.state = m.tagFilterReturn
+	case "blockers", "taskMilestone":
+		m.CoreModel.GoToProjectView()
+		m.activeTab = tasksTab
+	case "createMilestone":
+		m.CoreModel.GoToProjectView()
+	case "addLink", "openLink":
+		m.openLinks = nil
+		m.CoreModel.GoToProjectView()
 	}
 }
 
 // handleFormCompletion handles the completion of a form.
 func (m *Model) handleFormCompletion(formType string) CoreCommand {
 	switch formType {
-	case "create":
+	case "create", "update":
+		// The form validates the tags, so they parse here.
+		tags, _ := service.ParseTags(m.form.GetString("tags"))
 		data := ProjectFormData{
 			Name:    m.form.GetString("name"),
 			Summary: m.form.GetString("summary"),
 			Desc:    m.form.GetString("desc"),
 			Status:  m.form.GetString("status"),
+			Tags:    tags,
 		}
-		return m.CoreModel.CreateProject(data)
-	case "update":
-		data := ProjectFormData{
-			Name:    m.form.GetString("name"),
-			Summary: m.form.GetString("summary"),
-			Desc:    m.form.GetString("desc"),
-			Status:  m.form.GetString("status"),
+		if id, _ := m.form.Get("parent").(int); id != 0 {
+			data.ParentID = &id
+		}
+		if formType == "create" {
+			return m.CoreModel.CreateProject(data)
 		}
 		return m.CoreModel.UpdateProject(data)
+	case "tagFilter":
+		return m.applyTagFilter(m.form.GetString("tag"))
+	case "blockers":
+		blockerIDs, _ := m.form.Get("blockers").([]int)
+		m.CoreModel.GoToProjectView()
+		m.activeTab = tasksTab
+		cmd := m.CoreModel.SetTaskBlockers(m.blockersTaskID, blockerIDs)
+		m.selectTaskByID(m.blockersTaskID)
+		return cmd
+	case "createMilestone":
+		// The form validates the target date, so it parses here.
+		target, _ := service.ParseDueDate(m.form.GetString("target"), time.Now())
+		m.activeTab = projectDetailTab
+		return m.CoreModel.CreateMilestone(MilestoneFormData{
+			Name:       strings.TrimSpace(m.form.GetString("name")),
+			TargetDate: target,
+			Desc:       m.form.GetString("desc"),
+		})
+	case "addLink":
+		return m.CoreModel.AddLink(m.linkTaskID, LinkFormData{
+			Label:  m.form.GetString("label"),
+			Target: m.form.GetString("target"),
+		})
+	case "taskMilestone":
+		var milestoneID *int
+		if id, _ := m.form.Get("milestone").(int); id != 0 {
+			milestoneID = &id
+		}
+		m.CoreModel.GoToProjectView()
+		m.activeTab = tasksTab
+		cmd := m.CoreModel.SetTaskMilestone(m.milestoneTaskID, milestoneID)
+		m.selectTaskByID(m.milestoneTaskID)
+		return cmd
 	case "delete":
 		confirmed := m.form.GetBool("confirm")
 		if confirmed {
@@ -997,14 +1630,25 @@ func (m *Model) updateConfirmDeleteDialog(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// refreshListItems refreshes the list of projects.
-func (m *Model) refreshListItems() {
-	projects := m.CoreModel.GetProjects()
+// projectItems returns the rows of the project tree as list items.
+func projectItems(core *CoreModel) []list.Item {
+	projects := core.GetProjects()
 	items := make([]list.Item, len(projects))
 	for i, p := range projects {
-		items[i] = p
+		items[i] = projectItem{
+			Project:     p,
+			depth:       core.ProjectDepth(p.ID),
+			subprojects: len(core.Subprojects(p.ID)),
+			collapsed:   core.IsProjectCollapsed(p.ID),
+		}
 	}
-	m.list.SetItems(items)
+	return items
+}
+
+// refreshListItems refreshes the list of projects.
+func (m *Model) refreshListItems() {
+	projects := m.CoreModel.GetProjects()
+	m.list.SetItems(projectItems(m.CoreModel))
 
 	if len(projects) == 0 {
 		m.CoreModel.selectedProject = nil
@@ -1039,6 +1683,20 @@ func (m *Model) refreshLogs() {
 	}
 }
 
+// setLogContent renders log into the log viewport, leaving room above it for
+// the tasks the log is linked to.
+func (m *Model) setLogContent(log *service.Log) {
+	rendered, err := m.glamourRenderer.Render(log.Desc)
+	if err != nil {
+		rendered = log.Desc // fallback to plain text
+	}
+	m.logViewport.Height = m.height - 10
+	if tasks := m.logTasks(*log); len(tasks) > 0 {
+		m.logViewport.Height -= len(tasks) + 2
+	}
+	m.logViewport.SetContent(rendered)
+}
+
 // loadProjectDetails loads the details of a project.
 func (m *Model) loadProjectDetails(index int) {
 	if index < 0 || index >= len(m.CoreModel.GetProjects()) {
@@ -1057,6 +1715,18 @@ func (m *Model) loadProjectDetails(index int) {
 		m.CoreModel.logs = logs
 	}
 
+	if milestones, err := m.CoreModel.service.ListProjectMilestones(project.ID); err == nil {
+		m.CoreModel.milestones = milestones
+	}
+
+	if links, err := m.CoreModel.service.ListProjectLinks(project.ID); err == nil {
+		m.CoreModel.links = links
+	}
+
+	if entries, err := m.CoreModel.service.ListTimeEntries(project.ID); err == nil {
+		m.CoreModel.timeEntries = entries
+	}
+
 	// Reset cursor positions for tasks and logs to prevent out-of-bounds errors
 	// when I leave a project and move to another the selectedindex still persist
 	// This can lead to cursor being out of position for some tasks and logs
@@ -1066,13 +1736,7 @@ func (m *Model) loadProjectDetails(index int) {
 
 // getMaxNavigableTaskIndex returns the maximum navigable task index.
 func (m *Model) getMaxNavigableTaskIndex() int {
-	tasks := m.CoreModel.GetTasks()
-	var pending []service.Task
-	for _, t := range tasks {
-		if t.CompletedAt == nil {
-			pending = append(pending, t)
-		}
-	}
+	pending, completed := m.taskRows()
 
 	var maxIndex int
 
@@ -1080,7 +1744,7 @@ func (m *Model) getMaxNavigableTaskIndex() int {
 		maxIndex = len(pending) - 1
 	}
 	if m.showCompleted {
-		maxIndex = len(tasks) - 1
+		maxIndex = len(pending) + len(completed) - 1
 	}
 	return maxIndex
 }
@@ -1090,31 +1754,218 @@ func (m *Model) getVisualTask(index int) *service.Task {
 	if index < 0 {
 		return nil
 	}
-	tasks := m.CoreModel.GetTasks()
-
-	var pending, completed []service.Task
-	for _, t := range tasks {
-		if t.CompletedAt == nil {
-			pending = append(pending, t)
-		} else {
-			completed = append(completed, t)
-		}
-	}
+	pending, completed := m.taskRows()
 
 	if index < len(pending) {
-		return &pending[index]
+		return &pending[index].task
 	}
 
 	if m.showCompleted {
 		completedIndex := index - len(pending)
 		if completedIndex >= 0 && completedIndex < len(completed) {
-			return &completed[completedIndex]
+			return &completed[completedIndex].task
+		}
+	}
+
+	return nil
+}
+
+// moveTaskParent returns the parent the task at index gets when indented
+// under the sibling above it, or outdented next to its own parent. ok is
+// false when the task cannot move that way.
+func (m *Model) moveTaskParent(index int, indent bool) (parentID *int, ok bool) {
+	pending, completed := m.taskRows()
+	rows := pending
+	if index >= len(pending) {
+		rows, index = completed, index-len(pending)
+	}
+	if index < 0 || index >= len(rows) {
+		return nil, false
+	}
+	row := rows[index]
+
+	if indent {
+		for i := index - 1; i >= 0 && rows[i].depth >= row.depth; i-- {
+			if rows[i].depth == row.depth {
+				id := rows[i].task.ID
+				return &id, true
+			}
+		}
+		return nil, false
+	}
+
+	if row.task.ParentTaskID == nil {
+		return nil, false
+	}
+	if parent := m.findTask(row.task.ParentTaskID); parent != nil {
+		return parent.ParentTaskID, true
+	}
+	return nil, true
+}
+
+// taskNeighbour returns the sibling above or below the task at index, which
+// the task swaps places with when moved, or nil when there is none. It fails
+// when the list orders the two by priority, due date or milestone, which
+// moving the task cannot change.
+func (m *Model) taskNeighbour(index int, up bool) (*service.Task, error) {
+	pending, completed := m.taskRows()
+	rows := pending
+	if index >= len(pending) {
+		rows, index = completed, index-len(pending)
+	}
+	if index < 0 || index >= len(rows) {
+		return nil, nil
+	}
+	row := rows[index]
+
+	step := 1
+	if up {
+		step = -1
+	}
+	for i := index + step; i >= 0 && i < len(rows) && rows[i].depth >= row.depth; i += step {
+		if rows[i].depth != row.depth {
+			continue
+		}
+		other := rows[i]
+		if other.milestone != row.milestone {
+			return nil, errors.New("tasks only move within their milestone")
+		}
+		if !sameRank(row.task, other.task) {
+			return nil, fmt.Errorf("%q has a different priority or due date, change those to reorder", other.task.Title)
+		}
+		return &rows[i].task, nil
+	}
+	return nil, nil
+}
+
+// taskRow is a line of the task list.
+type taskRow struct {
+	task      service.Task
+	depth     int  // how deep the task is nested in its section
+	subtasks  int  // direct subtasks, whether listed or not
+	done      int  // direct subtasks completed
+	collapsed bool // subtasks are hidden
+	milestone int  // milestone of the tree's root, 0 for none, when grouped
+}
+
+// taskRows lays out the task list: pending tasks, then completed ones, each
+// section as a tree whose siblings are ordered like splitTasks. A task whose
+// parent is in the other section starts a tree of its own. Subtasks of
+// collapsed tasks are left out. When grouped by milestone, trees are ordered
+// by the milestone of their root, with those outside a milestone last.
+func (m *Model) taskRows() (pending, completed []taskRow) {
+	tasks := m.CoreModel.GetTasks()
+	subtasks := make(map[int]int)
+	done := make(map[int]int)
+	for _, t := range tasks {
+		if t.ParentTaskID != nil {
+			subtasks[*t.ParentTaskID]++
+			if t.CompletedAt != nil {
+				done[*t.ParentTaskID]++
+			}
+		}
+	}
+
+	// Tasks outside a milestone sort after every milestone.
+	milestones := m.CoreModel.GetMilestones()
+	milestoneOrder := map[int]int{0: len(milestones)}
+	for i, ms := range milestones {
+		milestoneOrder[ms.ID] = i
+	}
+
+	section := func(tasks []service.Task) []taskRow {
+		listed := make(map[int]bool, len(tasks))
+		for _, t := range tasks {
+			listed[t.ID] = true
+		}
+		children := make(map[int][]service.Task)
+		var roots []service.Task
+		for _, t := range tasks {
+			if t.ParentTaskID != nil && listed[*t.ParentTaskID] {
+				children[*t.ParentTaskID] = append(children[*t.ParentTaskID], t)
+			} else {
+				roots = append(roots, t)
+			}
+		}
+
+		if m.groupByMilestone {
+			sort.SliceStable(roots, func(i, j int) bool {
+				return milestoneOrder[m.rootMilestone(roots[i])] < milestoneOrder[m.rootMilestone(roots[j])]
+			})
+		}
+
+		var rows []taskRow
+		var walk func(t service.Task, depth, milestone int)
+		walk = func(t service.Task, depth, milestone int) {
+			row := taskRow{
+				task:      t,
+				depth:     depth,
+				subtasks:  subtasks[t.ID],
+				done:      done[t.ID],
+				collapsed: m.collapsed[t.ID] && len(children[t.ID]) > 0,
+				milestone: milestone,
+			}
+			rows = append(rows, row)
+			if row.collapsed {
+				return
+			}
+			for _, child := range children[t.ID] {
+				walk(child, depth+1, milestone)
+			}
 		}
+		for _, t := range roots {
+			walk(t, 0, m.rootMilestone(t))
+		}
+		return rows
+	}
+
+	pendingTasks, completedTasks := splitTasks(tasks)
+	return section(pendingTasks), section(completedTasks)
+}
+
+// rootMilestone returns the ID of the milestone a task tree is grouped under
+// when grouping by milestone, or 0 when it is not grouped or has none.
+func (m *Model) rootMilestone(t service.Task) int {
+	if !m.groupByMilestone || t.MilestoneID == nil || m.findMilestone(*t.MilestoneID) == nil {
+		return 0
 	}
+	return *t.MilestoneID
+}
 
+// findMilestone returns the milestone of the selected project with the given
+// ID, or nil.
+func (m *Model) findMilestone(id int) *service.Milestone {
+	for i := range m.CoreModel.milestones {
+		if m.CoreModel.milestones[i].ID == id {
+			return &m.CoreModel.milestones[i]
+		}
+	}
 	return nil
 }
 
+// splitTasks separates pending tasks from completed ones, keeping the order
+// the service lists them in: by priority, then by due date with undated ones
+// last, then in their manual order.
+func splitTasks(tasks []service.Task) (pending, completed []service.Task) {
+	for _, t := range tasks {
+		if t.CompletedAt == nil {
+			pending = append(pending, t)
+		} else {
+			completed = append(completed, t)
+		}
+	}
+	return pending, completed
+}
+
+// sameRank reports whether two tasks share a priority and due date, so that
+// their manual order decides which comes first.
+func sameRank(a, b service.Task) bool {
+	if a.Priority != b.Priority || (a.DueAt == nil) != (b.DueAt == nil) {
+		return false
+	}
+	return a.DueAt == nil || a.DueAt.Equal(*b.DueAt)
+}
+
 // getLogAtIndex returns the log at the given index.
 func (m *Model) getLogAtIndex(index int) *service.Log {
 	logs := m.CoreModel.GetLogs()
@@ -1,6 +1,10 @@
 package ui
 
 import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -125,3 +129,1076 @@ func TestGetVisualTask(t *testing.T) {
 		})
 	}
 }
+
+func TestOpen(t *testing.T) {
+	now := time.Now()
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "First"}, {ID: 7, Name: "Second"}},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 7, Title: "Pending"},
+			{ID: 2, ProjectID: 7, Title: "Done", CompletedAt: &now},
+		},
+		logs: []service.Log{
+			{ID: 3, ProjectID: 7, Title: "Older"},
+			{ID: 4, ProjectID: 7, Title: "Newer", Desc: "# Notes"},
+		},
+	}
+
+	t.Run("project details", func(t *testing.T) {
+		model, _ := NewModel(mockService)
+		if err := model.Open(OpenTarget{ProjectID: 7}); err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		if model.GetState() != projectView || model.activeTab != projectDetailTab {
+			t.Errorf("expected project details, got state %v tab %v", model.GetState(), model.activeTab)
+		}
+		if model.GetSelectedProject().ID != 7 || model.list.Index() != 1 {
+			t.Errorf("expected project 7 to be selected")
+		}
+	})
+
+	t.Run("completed task", func(t *testing.T) {
+		model, _ := NewModel(mockService)
+		if err := model.Open(OpenTarget{ProjectID: 7, TaskID: 2}); err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		if model.activeTab != tasksTab || model.taskDetailMode != taskDetailReadonly {
+			t.Errorf("expected task detail on the tasks tab")
+		}
+		if !model.showCompleted || model.selectedTaskIndex != 1 || model.GetSelectedTask().ID != 2 {
+			t.Errorf("expected cursor on the completed task, got index %d", model.selectedTaskIndex)
+		}
+	})
+
+	t.Run("log", func(t *testing.T) {
+		model, _ := NewModel(mockService)
+		if err := model.Open(OpenTarget{ProjectID: 7, Tab: "logs", LogID: 4}); err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		if model.activeTab != logsTab || model.logDetailMode != logDetailReadonly {
+			t.Errorf("expected log detail on the logs tab")
+		}
+		if model.selectedLogIndex != 1 || model.GetSelectedLog().ID != 4 {
+			t.Errorf("expected cursor on log 4, got index %d", model.selectedLogIndex)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		model, _ := NewModel(mockService)
+		for _, target := range []OpenTarget{
+			{ProjectID: 99},
+			{ProjectID: 7, Tab: "notes"},
+			{ProjectID: 7, Tab: "details", TaskID: 1},
+			{ProjectID: 7, TaskID: 99},
+			{ProjectID: 7, LogID: 99},
+		} {
+			if err := model.Open(target); err == nil {
+				t.Errorf("expected error opening %+v", target)
+			}
+		}
+	})
+}
+
+func TestTagFilter(t *testing.T) {
+	mockService := &MockService{
+		projects: []service.Project{
+			{ID: 1, Name: "Website", Tags: []string{"acme"}},
+			{ID: 2, Name: "API"},
+			{ID: 3, Name: "Garden"},
+		},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 2, Title: "Acme endpoint", Tags: []string{"acme"}},
+			{ID: 2, ProjectID: 2, Title: "Other endpoint"},
+		},
+	}
+	model, _ := NewModel(mockService)
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("#")})
+	if model.GetState() != tagFilterView || model.form == nil {
+		t.Fatalf("expected the tag filter form, got state %v", model.GetState())
+	}
+
+	model.tagFilterReturn = listView
+	model.applyTagFilter("acme")
+	if model.GetState() != listView || model.list.Title != "Addae · #acme" {
+		t.Errorf("expected the filtered list, got state %v title %q", model.GetState(), model.list.Title)
+	}
+	projects := model.GetProjects()
+	if len(projects) != 2 || projects[0].ID != 1 || projects[1].ID != 2 {
+		t.Fatalf("expected the tagged project and the project with a tagged task, got %+v", projects)
+	}
+	if len(model.list.Items()) != 2 {
+		t.Errorf("expected the list to show 2 projects, got %d", len(model.list.Items()))
+	}
+
+	// Tasks are narrowed only in projects that do not carry the tag.
+	model.SelectProject(1)
+	if tasks := model.GetTasks(); len(tasks) != 1 || tasks[0].ID != 1 {
+		t.Errorf("expected only the tagged task, got %+v", tasks)
+	}
+	model.SelectProject(0)
+	if tasks := model.GetTasks(); len(tasks) != 2 {
+		t.Errorf("expected every task of a tagged project, got %+v", tasks)
+	}
+
+	model.applyTagFilter("")
+	if len(model.GetProjects()) != 3 || model.list.Title != "Addae" {
+		t.Errorf("expected the filter to be cleared")
+	}
+}
+
+func TestTaskEditFormTags(t *testing.T) {
+	form := newTaskEditForm(service.Task{Title: "Task", Tags: []string{"acme"}})
+	if got := form.tagsInput.Value(); got != "acme" {
+		t.Errorf("expected tags input to hold the task tags, got %q", got)
+	}
+
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	form, _ = form.Update(enter)
+	form.tagsInput.SetValue("acme, " + strings.Repeat("x", service.MaxTagLength+1))
+	form.setFocus(taskEditFields - 1)
+	form, _ = form.Update(enter)
+	if form.IsCompleted() || form.err == nil || form.focusIndex != 1 {
+		t.Errorf("expected an invalid tag to keep the form open on the tags field")
+	}
+
+	form.tagsInput.SetValue("acme ops")
+	form.setFocus(taskEditFields - 1)
+	form, _ = form.Update(enter)
+	tags, err := form.GetTags()
+	if !form.IsCompleted() || err != nil || len(tags) != 2 {
+		t.Errorf("expected the form to complete with 2 tags, got %v, %v", tags, err)
+	}
+}
+
+func TestTaskEditFormDueDate(t *testing.T) {
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	form := newTaskEditForm(service.Task{Title: "Task", DueAt: &due})
+	if got := form.dueInput.Value(); got != "2025-03-01" {
+		t.Errorf("expected due input to hold the due date, got %q", got)
+	}
+
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	form.dueInput.SetValue("someday")
+	form.setFocus(taskEditFields - 1)
+	form, _ = form.Update(enter)
+	if form.IsCompleted() || form.err == nil || form.focusIndex != 2 {
+		t.Errorf("expected an invalid date to keep the form open on the due field")
+	}
+
+	form.dueInput.SetValue("")
+	form.setFocus(taskEditFields - 1)
+	form, _ = form.Update(enter)
+	dueAt, err := form.GetDueAt()
+	if !form.IsCompleted() || err != nil || dueAt != nil {
+		t.Errorf("expected clearing the date to remove the due date, got %v, %v", dueAt, err)
+	}
+}
+
+func TestDueDates(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 0, 0, 0, time.Local)
+	day := func(offset int) *time.Time {
+		d := time.Date(2025, 3, 10+offset, 0, 0, 0, 0, time.Local)
+		return &d
+	}
+
+	for _, tt := range []struct {
+		offset int
+		want   string
+	}{
+		{0, "today"},
+		{3, "in 3d"},
+		{-2, "overdue 2d"},
+	} {
+		if got := dueLabel(*day(tt.offset), now); got != tt.want {
+			t.Errorf("dueLabel(%+dd) = %q, want %q", tt.offset, got, tt.want)
+		}
+	}
+
+	done := now
+	tasks := []service.Task{
+		{ID: 1, Title: "Undated"},
+		{ID: 2, Title: "Later", DueAt: day(5)},
+		{ID: 3, Title: "Done", DueAt: day(-5), CompletedAt: &done},
+		{ID: 4, Title: "Overdue", DueAt: day(-1)},
+		{ID: 5, Title: "Also undated"},
+	}
+	pending, completed := splitTasks(tasks)
+	var order []int
+	for _, task := range pending {
+		order = append(order, task.ID)
+	}
+	if !reflect.DeepEqual(order, []int{1, 2, 4, 5}) {
+		t.Errorf("expected pending tasks in the order listed, got %v", order)
+	}
+	if len(completed) != 1 || completed[0].ID != 3 {
+		t.Errorf("expected the completed task on its own, got %v", completed)
+	}
+
+	if !isOverdue(tasks[3], now) || isOverdue(tasks[2], now) || isOverdue(tasks[1], now) {
+		t.Errorf("expected only the pending task past its date to be overdue")
+	}
+}
+
+func TestPriorityBump(t *testing.T) {
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Website"}},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 1, Title: "Write copy"},
+			{ID: 2, ProjectID: 1, Title: "Fix nav"},
+		},
+	}
+	model, _ := NewModel(mockService)
+	if err := model.Open(OpenTarget{ProjectID: 1, Tab: "tasks"}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	press := func(k string) {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+	model.selectedTaskIndex = 1
+	press("+")
+	press("+")
+	if got := model.getVisualTask(0); got == nil || got.ID != 2 || got.Priority != service.PriorityMedium {
+		t.Fatalf("expected Fix nav to move to the top at medium priority, got %+v", got)
+	}
+	if model.selectedTaskIndex != 0 {
+		t.Errorf("expected the cursor to follow the task, got index %d", model.selectedTaskIndex)
+	}
+
+	for range 3 {
+		press("-")
+	}
+	if got := model.getVisualTask(1); got == nil || got.ID != 2 || got.Priority != service.PriorityNone {
+		t.Errorf("expected Fix nav back in creation order without a priority, got %+v", got)
+	}
+	if model.selectedTaskIndex != 1 {
+		t.Errorf("expected the cursor to follow the task, got index %d", model.selectedTaskIndex)
+	}
+}
+
+func TestTaskEditFormPriority(t *testing.T) {
+	form := newTaskEditForm(service.Task{Title: "Task", Priority: service.PriorityHigh})
+	form.setFocus(taskPriorityField)
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyRight})
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if got := form.GetPriority(); got != service.PriorityUrgent {
+		t.Errorf("expected priority to stop at urgent, got %v", got)
+	}
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	if got := form.GetPriority(); got != service.PriorityHigh {
+		t.Errorf("expected h to lower the priority, got %v", got)
+	}
+	if form.GetTitle() != "Task" {
+		t.Errorf("expected keys on the priority field not to reach other fields")
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	now := time.Now()
+	parent := func(id int) *int { return &id }
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Website"}},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 1, Title: "Launch"},
+			{ID: 2, ProjectID: 1, Title: "Write copy", ParentTaskID: parent(1)},
+			{ID: 3, ProjectID: 1, Title: "Pick fonts", ParentTaskID: parent(1), CompletedAt: &now},
+			{ID: 4, ProjectID: 1, Title: "Hero section", ParentTaskID: parent(2)},
+			{ID: 5, ProjectID: 1, Title: "Fix nav"},
+		},
+	}
+	model, _ := NewModel(mockService)
+	if err := model.Open(OpenTarget{ProjectID: 1, Tab: "tasks"}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	visible := func() []int {
+		var ids []int
+		for i := 0; i <= model.getMaxNavigableTaskIndex(); i++ {
+			if task := model.getVisualTask(i); task != nil {
+				ids = append(ids, task.ID)
+			}
+		}
+		return ids
+	}
+
+	pending, completed := model.taskRows()
+	if len(pending) != 4 || pending[0].subtasks != 2 || pending[0].done != 1 || pending[2].depth != 2 {
+		t.Fatalf("expected the pending tree with progress on Launch, got %+v", pending)
+	}
+	if len(completed) != 1 || completed[0].depth != 0 {
+		t.Errorf("expected the completed subtask at the top of its section, got %+v", completed)
+	}
+	if got := visible(); !reflect.DeepEqual(got, []int{1, 2, 4, 5}) {
+		t.Errorf("expected subtasks under their parents, got %v", got)
+	}
+	model.showCompleted = true
+	if got := visible(); !reflect.DeepEqual(got, []int{1, 2, 4, 5, 3}) {
+		t.Errorf("expected the completed subtask after the pending tasks, got %v", got)
+	}
+	if view := model.renderTasksListOnly(); !strings.Contains(view, "1/2") {
+		t.Errorf("expected progress on the parent, got %q", view)
+	}
+
+	press := func(k string) {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	model.selectedTaskIndex = 0
+	press("z")
+	if got := visible(); !reflect.DeepEqual(got, []int{1, 5, 3}) {
+		t.Errorf("expected collapsing Launch to hide its subtasks, got %v", got)
+	}
+	press("z")
+
+	model.selectedTaskIndex = 3
+	press(">")
+	if got := mockService.tasks[4].ParentTaskID; got == nil || *got != 1 {
+		t.Fatalf("expected Fix nav to become a subtask of Launch, got %v", got)
+	}
+	if task := model.GetSelectedTask(); task == nil || task.ID != 5 {
+		t.Errorf("expected the cursor to stay on Fix nav, got %+v", task)
+	}
+
+	model.selectedTaskIndex = 2
+	press("<")
+	if got := mockService.tasks[3].ParentTaskID; got == nil || *got != 1 {
+		t.Errorf("expected Hero section to move next to Write copy, got %v", got)
+	}
+	model.selectedTaskIndex = 0
+	press("<")
+	press(">")
+	if got := mockService.tasks[0].ParentTaskID; got != nil {
+		t.Errorf("expected Launch to stay a top-level task, got %v", *got)
+	}
+}
+
+func TestTaskDependencies(t *testing.T) {
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Website"}},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 1, Title: "Design"},
+			{ID: 2, ProjectID: 1, Title: "Build", BlockedBy: []int{1}},
+			{ID: 3, ProjectID: 1, Title: "Launch", BlockedBy: []int{2}},
+		},
+	}
+	model, _ := NewModel(mockService)
+	if err := model.Open(OpenTarget{ProjectID: 1, Tab: "tasks"}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	if got := strings.Count(model.renderTasksListOnly(), blockedMarker); got != 2 {
+		t.Errorf("expected Build and Launch to be locked, got %d locks", got)
+	}
+
+	model.selectedTaskIndex = 1
+	view := model.renderTaskReadonlyView()
+	for _, want := range []string{"Blocked by:", "[ ] Design", "Unblocks:", "[ ] Launch"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the task view, got %q", want, view)
+		}
+	}
+
+	press := func(k string) {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	// Design waits on nothing, and every other task waits on it.
+	model.selectedTaskIndex = 0
+	press("B")
+	if model.GetState() != projectView || model.form != nil {
+		t.Errorf("expected no blockers form without candidates, got state %v", model.GetState())
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeySpace})
+	if got := strings.Count(model.renderTasksListOnly(), blockedMarker); got != 1 {
+		t.Errorf("expected completing Design to unblock Build, got %d locks", got)
+	}
+
+	model.selectedTaskIndex = 1
+	press("B")
+	if model.GetState() != blockersView || model.form == nil || model.blockersTaskID != 3 {
+		t.Fatalf("expected the blockers form for Launch, got state %v", model.GetState())
+	}
+	// Build is already picked; add Design with space, then submit.
+	model.Update(tea.KeyMsg{Type: tea.KeySpace})
+	submitForm(model)
+	if model.GetState() != projectView || model.activeTab != tasksTab {
+		t.Errorf("expected to return to the tasks tab, got state %v", model.GetState())
+	}
+	if task := model.GetSelectedTask(); task == nil || task.ID != 3 {
+		t.Errorf("expected the cursor to stay on Launch, got %+v", task)
+	}
+	if got := mockService.tasks[2].BlockedBy; !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("expected Launch to be blocked by Design and Build, got %v", got)
+	}
+}
+
+// submitForm presses enter on the open form, feeding the messages of the
+// commands it returns back to the model until the form closes or moves on
+// to its next field.
+func submitForm(model *Model) {
+	msgs := []tea.Msg{tea.KeyMsg{Type: tea.KeyEnter}}
+	for len(msgs) > 0 && model.form != nil {
+		msg := msgs[0]
+		msgs = msgs[1:]
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for _, cmd := range batch {
+				if msg := runCmd(cmd); msg != nil {
+					msgs = append(msgs, msg)
+				}
+			}
+			continue
+		}
+		if _, cmd := model.Update(msg); cmd != nil {
+			if msg := runCmd(cmd); msg != nil {
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+}
+
+// runCmd returns the message of cmd, or nil when cmd is nil or takes more
+// than a moment, as cursor blinks do.
+func runCmd(cmd tea.Cmd) tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	select {
+	case msg := <-done:
+		return msg
+	case <-time.After(50 * time.Millisecond):
+		return nil
+	}
+}
+
+func TestRecurringTasks(t *testing.T) {
+	weekly, _ := service.ParseRecurrence("weekly mon,thu")
+	form := newTaskEditForm(service.Task{Title: "Rotate API keys", Recurrence: weekly})
+	if got := form.repeatInput.Value(); got != "weekly mon,thu" {
+		t.Errorf("expected repeat input to hold the rule, got %q", got)
+	}
+
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	form.repeatInput.SetValue("fortnightly")
+	form.setFocus(taskEditFields - 1)
+	form, _ = form.Update(enter)
+	if form.IsCompleted() || form.err == nil || form.focusIndex != 3 {
+		t.Errorf("expected an invalid rule to keep the form open on the repeat field")
+	}
+	form.repeatInput.SetValue("every 30d")
+	form.setFocus(taskEditFields - 1)
+	form, _ = form.Update(enter)
+	if r, err := form.GetRecurrence(); !form.IsCompleted() || err != nil || r.String() != "every 30d" {
+		t.Errorf("expected the new rule, got %v, %v", r, err)
+	}
+
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Ops"}},
+		tasks:    []service.Task{{ID: 1, ProjectID: 1, Title: "Rotate API keys", Recurrence: weekly}},
+	}
+	model, _ := NewModel(mockService)
+	if err := model.Open(OpenTarget{ProjectID: 1, TaskID: 1}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if view := model.renderTaskReadonlyView(); !strings.Contains(view, "Repeats: ↻ every week on Mon, Thu") {
+		t.Errorf("expected the rule in the task view, got %q", view)
+	}
+	if view := model.renderTasksListOnly(); !strings.Contains(view, recurringMarker) {
+		t.Errorf("expected the repeat marker in the task list, got %q", view)
+	}
+}
+
+func TestTimer(t *testing.T) {
+	started := time.Now().Add(-90 * time.Minute)
+	ended := started.Add(30 * time.Minute)
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Client site"}},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 1, Title: "Design"},
+			{ID: 2, ProjectID: 1, Title: "Build"},
+		},
+		timeEntries: []service.TimeEntry{{ID: 1, TaskID: 1, TaskTitle: "Design", ProjectID: 1, StartedAt: started, EndedAt: &ended}},
+	}
+	model, _ := NewModel(mockService)
+	if err := model.Open(OpenTarget{ProjectID: 1, Tab: "tasks"}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if model.renderTimerStatus() != "" {
+		t.Errorf("expected no status line without a running timer")
+	}
+	if view := model.renderTaskReadonlyView(); !strings.Contains(view, "Time spent: 30m") {
+		t.Errorf("expected the time spent on Design, got %q", view)
+	}
+
+	press := func(k string) tea.Cmd {
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	model.selectedTaskIndex = 1
+	if cmd := press("s"); cmd == nil {
+		t.Errorf("expected starting a timer to schedule a tick")
+	}
+	running := model.CoreModel.GetRunningTimer()
+	if running == nil || running.TaskID != 2 {
+		t.Fatalf("expected the timer running on Build, got %+v", running)
+	}
+	if status := model.renderTimerStatus(); !strings.Contains(status, "Build · Client site  0:00:0") {
+		t.Errorf("expected Build in the status line, got %q", status)
+	}
+	if !strings.Contains(model.View(), "Build · Client site") {
+		t.Errorf("expected the status line in the view")
+	}
+	if got := strings.Count(model.renderTasksListOnly(), timerMarker); got != 1 {
+		t.Errorf("expected the timer marker on Build only, got %d markers", got)
+	}
+
+	// Only the ticks of the latest timer keep going.
+	if _, cmd := model.Update(timerTickMsg{gen: model.timerGen - 1}); cmd != nil {
+		t.Errorf("expected a stale tick to stop")
+	}
+	if _, cmd := model.Update(timerTickMsg{gen: model.timerGen}); cmd == nil {
+		t.Errorf("expected a tick to schedule the next one")
+	}
+
+	// Starting another task's timer stops the running one.
+	model.selectedTaskIndex = 0
+	press("s")
+	if running := model.CoreModel.GetRunningTimer(); running == nil || running.TaskID != 1 {
+		t.Errorf("expected the timer running on Design, got %+v", running)
+	}
+	if len(mockService.timeEntries) != 3 || mockService.timeEntries[1].EndedAt == nil {
+		t.Errorf("expected the Build timer stopped, got %+v", mockService.timeEntries)
+	}
+
+	press("s")
+	if model.CoreModel.GetRunningTimer() != nil || model.renderTimerStatus() != "" {
+		t.Errorf("expected pressing s again to stop the timer")
+	}
+	if _, cmd := model.Update(timerTickMsg{gen: model.timerGen}); cmd != nil {
+		t.Errorf("expected the ticks to stop with the timer")
+	}
+	if details := model.renderProjectDetails(); !strings.Contains(details, "Time spent:") {
+		t.Errorf("expected the project's time spent in its details, got %q", details)
+	}
+}
+
+func TestMilestones(t *testing.T) {
+	now := time.Now()
+	target := now.AddDate(0, 0, 3)
+	milestone := func(id int) *int { return &id }
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Website"}},
+		milestones: []service.Milestone{
+			{ID: 1, ProjectID: 1, Name: "Launch", TargetDate: &target},
+			{ID: 2, ProjectID: 1, Name: "Beta"},
+		},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 1, Title: "Design", MilestoneID: milestone(2)},
+			{ID: 2, ProjectID: 1, Title: "Build", MilestoneID: milestone(1)},
+			{ID: 3, ProjectID: 1, Title: "Docs"},
+			{ID: 4, ProjectID: 1, Title: "Book venue", MilestoneID: milestone(1), CompletedAt: &now},
+		},
+	}
+	model, _ := NewModel(mockService)
+	if err := model.Open(OpenTarget{ProjectID: 1}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	details := model.renderProjectDetails()
+	for _, want := range []string{"Milestones:", "◆ Launch", "1/2", "in 3d", "◆ Beta", "0/1"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("expected %q in the project details, got %q", want, details)
+		}
+	}
+	if got := progressBar(1, 2, 10); strings.Count(got, "█") != 5 || strings.Count(got, "░") != 5 {
+		t.Errorf("expected a half full bar, got %q", got)
+	}
+
+	press := func(k string) {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+	press("2")
+	press("g")
+	var order []string
+	for i := 0; i <= 2; i++ {
+		order = append(order, model.getVisualTask(i).Title)
+	}
+	if !reflect.DeepEqual(order, []string{"Build", "Design", "Docs"}) {
+		t.Errorf("expected tasks grouped by milestone, got %v", order)
+	}
+	list := model.renderTasksListOnly()
+	for _, want := range []string{"◆ Launch", "◆ Beta", "No milestone"} {
+		if !strings.Contains(list, want) {
+			t.Errorf("expected the %q group in the task list, got %q", want, list)
+		}
+	}
+
+	// Assign Docs to Launch, the first milestone after "No milestone".
+	model.selectedTaskIndex = 2
+	press("m")
+	if model.GetState() != taskMilestoneView || model.milestoneTaskID != 3 {
+		t.Fatalf("expected the milestone form for Docs, got state %v", model.GetState())
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	submitForm(model)
+	if got := mockService.tasks[2].MilestoneID; got == nil || *got != 1 {
+		t.Errorf("expected Docs in Launch, got %v", got)
+	}
+	if task := model.GetSelectedTask(); task == nil || task.ID != 3 || model.selectedTaskIndex != 1 {
+		t.Errorf("expected the cursor to follow Docs into Launch, got %+v at %d", task, model.selectedTaskIndex)
+	}
+
+	press("M")
+	if model.GetState() != createMilestoneView {
+		t.Fatalf("expected the milestone form, got state %v", model.GetState())
+	}
+	press("v2")
+	for i := 0; i < 3 && model.form != nil; i++ {
+		submitForm(model)
+	}
+	if model.GetState() != projectView || len(model.CoreModel.GetMilestones()) != 3 {
+		t.Fatalf("expected a third milestone, got state %v and %+v", model.GetState(), model.CoreModel.GetMilestones())
+	}
+	if ms := mockService.milestones[2]; ms.Name != "v2" || ms.ProjectID != 1 || ms.TargetDate != nil {
+		t.Errorf("unexpected milestone: %+v", ms)
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	parent := func(id int) *int { return &id }
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Website"}},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 1, Title: "Launch", Position: 0},
+			{ID: 2, ProjectID: 1, Title: "Write copy", ParentTaskID: parent(1), Position: 1},
+			{ID: 3, ProjectID: 1, Title: "Fix nav", Position: 2},
+			{ID: 4, ProjectID: 1, Title: "Renew domain", Priority: service.PriorityHigh, Position: 3},
+			{ID: 5, ProjectID: 1, Title: "Deploy", Position: 4},
+		},
+	}
+	model, _ := NewModel(mockService)
+	if err := model.Open(OpenTarget{ProjectID: 1, Tab: "tasks"}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	visible := func() []int {
+		var ids []int
+		for i := 0; i <= model.getMaxNavigableTaskIndex(); i++ {
+			if task := model.getVisualTask(i); task != nil {
+				ids = append(ids, task.ID)
+			}
+		}
+		return ids
+	}
+	press := func(k string) {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	if got := visible(); !reflect.DeepEqual(got, []int{4, 1, 2, 3, 5}) {
+		t.Fatalf("expected tasks by priority, then position, got %v", got)
+	}
+
+	model.selectedTaskIndex = 4
+	press("K")
+	if got := visible(); !reflect.DeepEqual(got, []int{4, 1, 2, 5, 3}) {
+		t.Errorf("expected Deploy above Fix nav, got %v", got)
+	}
+	press("K")
+	if got := visible(); !reflect.DeepEqual(got, []int{4, 5, 1, 2, 3}) {
+		t.Errorf("expected Deploy to skip over the subtasks of Launch, got %v", got)
+	}
+	if model.selectedTaskIndex != 1 {
+		t.Errorf("expected the cursor to follow Deploy, got index %d", model.selectedTaskIndex)
+	}
+
+	// A higher priority task stays above, whatever its position.
+	press("K")
+	if got := visible(); !reflect.DeepEqual(got, []int{4, 5, 1, 2, 3}) {
+		t.Errorf("expected Deploy to stay below the high priority task, got %v", got)
+	}
+	if model.moveErr == nil || !strings.Contains(model.View(), "Could not move task") {
+		t.Errorf("expected the list to say why Deploy did not move, got %v", model.moveErr)
+	}
+
+	// A subtask only moves among its siblings.
+	model.selectedTaskIndex = 3
+	press("J")
+	if got := visible(); !reflect.DeepEqual(got, []int{4, 5, 1, 2, 3}) {
+		t.Errorf("expected Write copy to stay under Launch, got %v", got)
+	}
+
+	model.selectedTaskIndex = 1
+	press("J")
+	if got := visible(); !reflect.DeepEqual(got, []int{4, 1, 2, 5, 3}) {
+		t.Errorf("expected Deploy below Launch and its subtasks, got %v", got)
+	}
+	if model.moveErr != nil {
+		t.Errorf("expected the next key to clear the error, got %v", model.moveErr)
+	}
+	for i, task := range mockService.tasks {
+		if task.Position != i {
+			t.Errorf("expected dense positions, got %d at %d", task.Position, i)
+		}
+	}
+}
+
+func TestMoveCompletedTask(t *testing.T) {
+	done := time.Now()
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Website"}},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 1, Title: "Launch", CompletedAt: &done, Priority: service.PriorityHigh, Position: 0},
+			{ID: 2, ProjectID: 1, Title: "Fix nav", CompletedAt: &done, Position: 1},
+			{ID: 3, ProjectID: 1, Title: "Deploy", CompletedAt: &done, Position: 2},
+		},
+	}
+	model, _ := NewModel(mockService)
+	if err := model.Open(OpenTarget{ProjectID: 1, Tab: "tasks"}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	model.showCompleted = true
+	press := func(k string) {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	// Completed tasks are ordered by priority too.
+	model.selectedTaskIndex = 1
+	press("K")
+	if mockService.tasks[1].Position != 1 || model.moveErr == nil {
+		t.Errorf("expected Fix nav to stay below the high priority task, got %+v, %v", mockService.tasks, model.moveErr)
+	}
+	press("J")
+	if task := model.getVisualTask(2); task == nil || task.ID != 2 || model.moveErr != nil {
+		t.Errorf("expected Fix nav below Deploy, got %+v, %v", task, model.moveErr)
+	}
+}
+
+func TestTaskStates(t *testing.T) {
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Website"}},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 1, Title: "Write copy", State: "todo"},
+			{ID: 2, ProjectID: 1, Title: "Fix nav", State: "in review"},
+		},
+	}
+	model, _ := NewModel(mockService)
+	if err := model.Open(OpenTarget{ProjectID: 1, Tab: "tasks"}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	press := func(k string) {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	view := model.renderTasksListOnly()
+	if !strings.Contains(view, "[in review]") || strings.Contains(view, "[todo]") {
+		t.Errorf("expected only the task past todo to be marked, got %q", view)
+	}
+
+	model.selectedTaskIndex = 0
+	press("]")
+	if got := mockService.tasks[0].State; got != "in progress" {
+		t.Errorf("expected ] to move the task to in progress, got %q", got)
+	}
+	if view := model.renderTasksListOnly(); !strings.Contains(view, "[in progress]") {
+		t.Errorf("expected the new state in the list, got %q", view)
+	}
+	press("[")
+	press("[")
+	if got := mockService.tasks[0].State; got != service.StateDone || mockService.tasks[0].CompletedAt == nil {
+		t.Errorf("expected [ from todo to wrap around to done, got %+v", mockService.tasks[0])
+	}
+
+	// Moving past the last state before done completes the task.
+	model.selectTaskByID(2)
+	press("]")
+	if task := mockService.tasks[1]; task.State != service.StateDone || task.CompletedAt == nil {
+		t.Errorf("expected the reviewed task to be done, got %+v", task)
+	}
+	if len(model.CoreModel.GetTasks()) != 2 || model.selectedTaskIndex != 0 {
+		t.Errorf("expected the cursor to stay in the pending section, got index %d", model.selectedTaskIndex)
+	}
+
+	model.showCompleted = true
+	model.selectTaskByID(2)
+	press("]")
+	if task := mockService.tasks[1]; task.State != "todo" || task.CompletedAt != nil {
+		t.Errorf("expected ] on a done task to reopen it, got %+v", task)
+	}
+}
+
+func TestProjectTree(t *testing.T) {
+	clientA, website := 1, 3
+	mockService := &MockService{
+		projects: []service.Project{
+			{ID: 1, Name: "Client A", Tasks: 3, Done: 1},
+			{ID: 2, Name: "Garden"},
+			{ID: 3, Name: "Website", ParentID: &clientA, Tasks: 2, Done: 1},
+			{ID: 4, Name: "API", ParentID: &website},
+		},
+	}
+	model, _ := NewModel(mockService)
+
+	var ids []int
+	for _, p := range model.GetProjects() {
+		ids = append(ids, p.ID)
+	}
+	if !slices.Equal(ids, []int{1, 3, 4, 2}) {
+		t.Fatalf("expected each project followed by its subprojects, got %v", ids)
+	}
+	if item := model.list.Items()[2].(projectItem); item.Title() != "    API" {
+		t.Errorf("expected API indented under Website, got %q", item.Title())
+	}
+	if item := model.list.Items()[0].(projectItem); !strings.Contains(item.Description(), "1/3") {
+		t.Errorf("expected the rolled-up progress of Client A, got %q", item.Description())
+	}
+
+	model.loadProjectDetails(0)
+	view := model.renderProjectDetails()
+	if !strings.Contains(view, "Subprojects:") || !strings.Contains(view, "Website") || strings.Contains(view, "API") {
+		t.Errorf("expected the direct subprojects in the details, got %q", view)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	if len(model.GetProjects()) != 2 || model.list.Index() != 0 {
+		t.Fatalf("expected Client A collapsed, got %+v", model.GetProjects())
+	}
+	if item := model.list.Items()[0].(projectItem); item.Title() != "▸ Client A" {
+		t.Errorf("expected a collapsed marker, got %q", item.Title())
+	}
+
+	if err := model.Open(OpenTarget{ProjectID: 4}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if len(model.GetProjects()) != 4 || model.GetSelectedProject().ID != 4 {
+		t.Errorf("expected opening API to expand its parents, got %+v", model.GetProjects())
+	}
+
+	model.GoToListView()
+	model.list.Select(1)
+	model.loadProjectDetails(1)
+	if candidates := model.ParentCandidates(3); len(candidates) != 2 {
+		t.Errorf("expected Website to move under Client A or Garden only, got %+v", candidates)
+	}
+	if cmd := model.UpdateProject(ProjectFormData{Name: "Website", ParentID: nil}); cmd == CoreShowError {
+		t.Fatalf("UpdateProject failed: %v", model.GetError())
+	}
+	if mockService.projects[2].ParentID != nil {
+		t.Errorf("expected Website to become a top-level project, got %v", mockService.projects[2].ParentID)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	mockService := &MockService{
+		projects: []service.Project{
+			{ID: 1, Name: "Website", Status: "in progress"},
+			{ID: 2, Name: "Old site", Status: "archived"},
+			{ID: 3, Name: "Launch", Status: "completed"},
+		},
+	}
+	model, _ := NewModel(mockService)
+	key := func(k string) {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+	listed := func() []int {
+		var ids []int
+		for _, p := range model.GetProjects() {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	if ids := listed(); !slices.Equal(ids, []int{1, 3}) {
+		t.Fatalf("expected archived projects to be hidden, got %v", ids)
+	}
+	key("c")
+	if ids := listed(); !slices.Equal(ids, []int{1}) || !strings.Contains(model.list.Title, "hiding completed") {
+		t.Errorf("expected completed projects to be hidden, got %v titled %q", ids, model.list.Title)
+	}
+	key("c")
+
+	key("a")
+	if mockService.projects[0].Status != "archived" {
+		t.Errorf("expected Website to be archived, got %q", mockService.projects[0].Status)
+	}
+	if ids := listed(); !slices.Equal(ids, []int{3}) {
+		t.Errorf("expected Website to leave the list, got %v", ids)
+	}
+
+	key("A")
+	if ids := listed(); !slices.Equal(ids, []int{1, 2}) || !strings.Contains(model.list.Title, "Archive") {
+		t.Fatalf("expected the archive, got %v titled %q", ids, model.list.Title)
+	}
+	key("a")
+	if mockService.projects[0].Status != "in progress" {
+		t.Errorf("expected Website to be unarchived as in progress, got %q", mockService.projects[0].Status)
+	}
+	key("A")
+	if ids := listed(); !slices.Equal(ids, []int{1, 3}) {
+		t.Errorf("expected to leave the archive, got %v", ids)
+	}
+
+	if err := model.Open(OpenTarget{ProjectID: 2}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if !model.IsArchive() || model.GetSelectedProject().ID != 2 {
+		t.Errorf("expected opening an archived project to show the archive, got %+v", model.GetSelectedProject())
+	}
+}
+
+func TestLinks(t *testing.T) {
+	projectID, taskID := 1, 1
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Website"}},
+		tasks:    []service.Task{{ID: 1, ProjectID: 1, Title: "Write copy"}},
+		links: []service.Link{
+			{ID: 1, ProjectID: &projectID, Label: "Design doc", Target: "https://example.com/design"},
+			{ID: 2, TaskID: &taskID, Label: "Draft", Target: "/home/me/draft.md"},
+			{ID: 3, TaskID: &taskID, Label: "PR", Target: "https://example.com/pull/42"},
+		},
+	}
+	model, _ := NewModel(mockService)
+	var opened []string
+	model.SetOpener(func(target string) error {
+		opened = append(opened, target)
+		return nil
+	})
+	if err := model.Open(OpenTarget{ProjectID: 1}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	press := func(k string) tea.Cmd {
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	model.hyperlinks = true
+	details := model.renderProjectDetails()
+	for _, want := range []string{"Links:", "\x1b]8;;https://example.com/design\x1b\\", "example.com/design"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("expected %q in the project details, got %q", want, details)
+		}
+	}
+	if strings.Contains(details, "Draft") {
+		t.Errorf("expected task links left out of the project details, got %q", details)
+	}
+	model.hyperlinks = false
+	if details := model.renderProjectDetails(); strings.Contains(details, "\x1b]8;;") {
+		t.Errorf("expected no hyperlinks when the terminal lacks them, got %q", details)
+	}
+
+	// The project's only link opens straight away.
+	runCmd(press("o"))
+	if !slices.Equal(opened, []string{"https://example.com/design"}) {
+		t.Errorf("expected the design doc opened, got %v", opened)
+	}
+
+	// A task with several links asks which one to open.
+	press("2")
+	press("enter")
+	model.hyperlinks = true
+	if view := model.renderTaskReadonlyView(); !strings.Contains(view, "\x1b]8;;file:///home/me/draft.md\x1b\\") {
+		t.Errorf("expected the draft as a file hyperlink in the task view, got %q", view)
+	}
+	press("o")
+	if model.GetState() != openLinkView {
+		t.Fatalf("expected the open link form, got state %v", model.GetState())
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	submitForm(model)
+	if model.GetState() != projectView || !slices.Equal(opened[1:], []string{"https://example.com/pull/42"}) {
+		t.Errorf("expected the PR opened, got state %v and %v", model.GetState(), opened)
+	}
+
+	// An opener that fails reports why until the next key.
+	model.SetOpener(func(string) error { return errors.New("no browser") })
+	model.Update(runCmd(model.openLink(mockService.links[0])))
+	if view := model.View(); !strings.Contains(view, "Could not open link: no browser") {
+		t.Errorf("expected the opener's error in the view, got %q", view)
+	}
+	press("j")
+	if model.openErr != nil {
+		t.Errorf("expected a key to clear the error, got %v", model.openErr)
+	}
+
+	press("L")
+	if model.GetState() != addLinkView || model.linkTaskID != 1 {
+		t.Fatalf("expected the link form for the task, got state %v", model.GetState())
+	}
+	press("https://example.com/dashboard")
+	for i := 0; i < 2 && model.form != nil; i++ {
+		submitForm(model)
+	}
+	if links := model.CoreModel.TaskLinks(1); len(links) != 3 || links[2].Target != "https://example.com/dashboard" {
+		t.Errorf("expected the dashboard linked to the task, got %+v", links)
+	}
+}
+
+func TestLogTasks(t *testing.T) {
+	done := time.Now()
+	mockService := &MockService{
+		projects: []service.Project{{ID: 1, Name: "Website"}},
+		tasks: []service.Task{
+			{ID: 1, ProjectID: 1, Title: "Design", CompletedAt: &done},
+			{ID: 2, ProjectID: 1, Title: "Build"},
+		},
+		logs: []service.Log{
+			{ID: 1, ProjectID: 1, Title: "Monday", TaskIDs: []int{1}},
+			{ID: 2, ProjectID: 1, Title: "Tuesday"},
+		},
+	}
+	model, _ := NewModel(mockService)
+	model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	press := func(k string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		model.Update(msg)
+	}
+
+	// The log panel lists its tasks with their completion state, above a
+	// viewport shortened to make room for them.
+	if err := model.Open(OpenTarget{ProjectID: 1, Tab: "logs", LogID: 1}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if panel := model.renderLogDetailPanel(); !strings.Contains(panel, "Tasks:") || !strings.Contains(panel, "[x] Design") {
+		t.Errorf("expected Design checked off in the log panel, got %q", panel)
+	}
+	if model.logViewport.Height != 40-10-3 {
+		t.Errorf("expected the viewport to leave room for one task, got height %d", model.logViewport.Height)
+	}
+
+	// Linking Build from the picker in the edit form.
+	press("e")
+	if model.logEditForm == nil || !slices.Equal(model.logEditForm.TaskIDs(), []int{1}) {
+		t.Fatalf("expected the edit form to start with Design picked")
+	}
+	press("tab")
+	press("tab")
+	press("j")
+	press(" ")
+	if view := model.logEditForm.View(); !strings.Contains(view, "> [x] Build") {
+		t.Errorf("expected Build picked under the cursor, got %q", view)
+	}
+	press("esc")
+	if model.GetState() != projectView || !slices.Equal(mockService.logs[0].TaskIDs, []int{1, 2}) {
+		t.Fatalf("expected Monday linked to Design and Build, got state %v and %v", model.GetState(), mockService.logs[0].TaskIDs)
+	}
+
+	// The task view lists the logs that mention the task.
+	if err := model.Open(OpenTarget{ProjectID: 1, TaskID: 2}); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	view := model.renderTaskReadonlyView()
+	if !strings.Contains(view, "Logs:") || !strings.Contains(view, "Monday") {
+		t.Errorf("expected Monday among Build's logs, got %q", view)
+	}
+	if strings.Contains(view, "Tuesday") {
+		t.Errorf("expected Tuesday left out of Build's logs, got %q", view)
+	}
+}
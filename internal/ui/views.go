@@ -2,12 +2,375 @@ package ui
 
 import (
 	"fmt"
+	"hash/fnv"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/quamejnr/addae/internal/service"
 )
 
+// projectItem is a row of the project tree, indented under its parent, with
+// its tags as chips after the status.
+type projectItem struct {
+	service.Project
+	depth       int
+	subprojects int
+	collapsed   bool // subprojects are hidden
+}
+
+func (p projectItem) Title() string {
+	title := p.Name
+	if p.subprojects > 0 {
+		marker := "▾ "
+		if p.collapsed {
+			marker = "▸ "
+		}
+		title = marker + title
+	}
+	return strings.Repeat("  ", p.depth) + title
+}
+
+func (p projectItem) Description() string {
+	desc := p.Status
+	if p.subprojects > 0 {
+		desc += fmt.Sprintf("  %d/%d", p.Done, p.Tasks)
+	}
+	if len(p.Tags) > 0 {
+		desc += "  " + renderTagChips(p.Tags)
+	}
+	return strings.Repeat("  ", p.depth) + desc
+}
+
+// renderTagChips renders tags as colored chips.
+func renderTagChips(tags []string) string {
+	chips := make([]string, len(tags))
+	for i, tag := range tags {
+		chips[i] = tagChipStyle.Background(tagColor(tag)).Render(tag)
+	}
+	return strings.Join(chips, " ")
+}
+
+func tagColor(tag string) lipgloss.Color {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(tag)))
+	return tagColors[h.Sum32()%uint32(len(tagColors))]
+}
+
+// taskTagChips returns the chips to show after a task title in the lists.
+func taskTagChips(t service.Task) string {
+	if len(t.Tags) == 0 {
+		return ""
+	}
+	return " " + renderTagChips(t.Tags)
+}
+
+// dueLabel describes when a task is due relative to now: "today", "in 3d" or
+// "overdue 2d".
+func dueLabel(due, now time.Time) string {
+	switch days := service.DaysUntil(due, now); {
+	case days == 0:
+		return "today"
+	case days > 0:
+		return fmt.Sprintf("in %dd", days)
+	default:
+		return fmt.Sprintf("overdue %dd", -days)
+	}
+}
+
+// taskDueLabel renders the due label of a pending task, colored once it is due.
+func taskDueLabel(t service.Task, now time.Time) string {
+	if t.DueAt == nil || t.CompletedAt != nil {
+		return ""
+	}
+	style := dueStyle
+	switch days := service.DaysUntil(*t.DueAt, now); {
+	case days < 0:
+		style = overdueStyle
+	case days == 0:
+		style = dueTodayStyle
+	}
+	return " " + style.Render(dueLabel(*t.DueAt, now))
+}
+
+// findTask returns the task of the selected project with the given ID, or nil.
+func (m *Model) findTask(id *int) *service.Task {
+	if id == nil {
+		return nil
+	}
+	for i := range m.CoreModel.tasks {
+		if m.CoreModel.tasks[i].ID == *id {
+			return &m.CoreModel.tasks[i]
+		}
+	}
+	return nil
+}
+
+// taskIndent indents a subtask under its parent.
+func taskIndent(row taskRow) string {
+	return strings.Repeat("  ", row.depth)
+}
+
+// subtaskProgress renders how many of a task's subtasks are done, as in "3/5",
+// with a marker when they are collapsed.
+func subtaskProgress(row taskRow) string {
+	if row.subtasks == 0 {
+		return ""
+	}
+	progress := fmt.Sprintf("%d/%d", row.done, row.subtasks)
+	if row.collapsed {
+		progress += " ▸"
+	}
+	return " " + dueStyle.Render(progress)
+}
+
+// taskBlockedMarker renders the lock shown on tasks waiting on other tasks.
+func taskBlockedMarker(blocked bool) string {
+	if !blocked {
+		return ""
+	}
+	return " " + blockedMarker
+}
+
+// taskDependencies returns the tasks blocking task and the tasks it blocks.
+func (m *Model) taskDependencies(task service.Task) (blockers, unblocks []service.Task) {
+	for _, t := range m.CoreModel.GetTasks() {
+		if slices.Contains(task.BlockedBy, t.ID) {
+			blockers = append(blockers, t)
+		}
+		if slices.Contains(t.BlockedBy, task.ID) {
+			unblocks = append(unblocks, t)
+		}
+	}
+	return blockers, unblocks
+}
+
+// renderDependencies lists tasks under a "Blocked by:" or "Unblocks:" line,
+// checking off the completed ones.
+func renderDependencies(tasks []service.Task) string {
+	var s strings.Builder
+	for _, t := range tasks {
+		s.WriteString("\n")
+		if t.CompletedAt != nil {
+			s.WriteString(subStyle.Render("  [x] " + t.Title))
+		} else {
+			s.WriteString("  [ ] " + t.Title)
+		}
+	}
+	return s.String()
+}
+
+// logTasks returns the tasks log is linked to.
+func (m *Model) logTasks(log service.Log) []service.Task {
+	var tasks []service.Task
+	for _, t := range m.CoreModel.tasks {
+		if slices.Contains(log.TaskIDs, t.ID) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// taskLogs returns the logs linked to task.
+func (m *Model) taskLogs(task service.Task) []service.Log {
+	var logs []service.Log
+	for _, l := range m.CoreModel.GetLogs() {
+		if slices.Contains(l.TaskIDs, task.ID) {
+			logs = append(logs, l)
+		}
+	}
+	return logs
+}
+
+// renderTaskLogs lists the logs that mention a task, with the day each was
+// written.
+func renderTaskLogs(logs []service.Log) string {
+	var s strings.Builder
+	for _, l := range logs {
+		s.WriteString("\n  • " + l.Title)
+		s.WriteString(subStyle.Render(" " + l.DateCreated.Local().Format(service.DueDateLayout)))
+	}
+	return s.String()
+}
+
+// milestoneHeader returns the heading written above rows[i] when the task
+// list is grouped by milestone and the row starts a new group.
+func (m *Model) milestoneHeader(rows []taskRow, i int) string {
+	if !m.groupByMilestone || (i > 0 && rows[i-1].milestone == rows[i].milestone) {
+		return ""
+	}
+	ms := m.findMilestone(rows[i].milestone)
+	if ms == nil {
+		return milestoneStyle.Render("No milestone") + "\n"
+	}
+	done, total := m.milestoneProgress(ms.ID)
+	return milestoneStyle.Render(fmt.Sprintf("%s %s", milestoneMarker, ms.Name)) +
+		" " + dueStyle.Render(fmt.Sprintf("%d/%d", done, total)) + "\n"
+}
+
+// milestoneProgress counts the tasks assigned to a milestone and how many of
+// them are completed.
+func (m *Model) milestoneProgress(id int) (done, total int) {
+	for _, t := range m.CoreModel.GetTasks() {
+		if t.MilestoneID != nil && *t.MilestoneID == id {
+			total++
+			if t.CompletedAt != nil {
+				done++
+			}
+		}
+	}
+	return done, total
+}
+
+// progressBar draws done out of total as a bar width cells wide.
+func progressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = done * width / total
+	}
+	return progressStyle.Render(strings.Repeat("█", filled)) + dueStyle.Render(strings.Repeat("░", width-filled))
+}
+
+// renderMilestones lists the milestones of the selected project with their
+// progress and target dates.
+func (m *Model) renderMilestones() string {
+	now := time.Now()
+	var s strings.Builder
+	for _, ms := range m.CoreModel.GetMilestones() {
+		done, total := m.milestoneProgress(ms.ID)
+		s.WriteString("\n")
+		s.WriteString(fmt.Sprintf("  %s %s  %s %d/%d", milestoneMarker, ms.Name, progressBar(done, total, 20), done, total))
+		if ms.TargetDate != nil {
+			label := ms.TargetDate.Local().Format(service.DueDateLayout)
+			if total == 0 || done < total {
+				label += ", " + dueLabel(*ms.TargetDate, now)
+			}
+			s.WriteString("  " + dueStyle.Render(label))
+		}
+	}
+	return s.String()
+}
+
+// renderSubprojects lists subprojects with the progress of their tasks,
+// counting the tasks of their own subprojects too.
+func renderSubprojects(projects []service.Project) string {
+	var s strings.Builder
+	for _, p := range projects {
+		s.WriteString("\n")
+		s.WriteString(fmt.Sprintf("  %s  %s %d/%d  %s", p.Name, progressBar(p.Done, p.Tasks, 20), p.Done, p.Tasks,
+			dueStyle.Render(p.Status)))
+	}
+	return s.String()
+}
+
+// renderLinks lists links by label, followed by their target when it differs.
+// Labels are terminal hyperlinks where the terminal supports them.
+func (m *Model) renderLinks(links []service.Link) string {
+	var s strings.Builder
+	for _, l := range links {
+		label := linkStyle.Render(l.Label)
+		if m.hyperlinks {
+			label = hyperlink(l.URL(), label)
+		}
+		s.WriteString("\n  " + label)
+		if l.Label != l.Target {
+			s.WriteString("  " + dueStyle.Render(l.Target))
+		}
+	}
+	return s.String()
+}
+
+// hyperlink wraps text in an OSC 8 escape sequence linking it to url.
+func hyperlink(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// supportsHyperlinks reports whether the terminal is expected to understand
+// OSC 8 hyperlinks. The few that do not, like the Linux console, print the
+// escape sequences as text.
+func supportsHyperlinks() bool {
+	switch os.Getenv("TERM") {
+	case "", "dumb", "linux":
+		return false
+	}
+	return true
+}
+
+func countCompleted(tasks []service.Task) int {
+	var n int
+	for _, t := range tasks {
+		if t.CompletedAt != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// taskPriorityMarker renders the priority marker of a pending task.
+func taskPriorityMarker(t service.Task) string {
+	if t.CompletedAt != nil || t.Priority <= service.PriorityNone || t.Priority > service.PriorityUrgent {
+		return ""
+	}
+	return " " + priorityStyles[t.Priority].Render(priorityMarkers[t.Priority])
+}
+
+// taskRecurringMarker marks a pending task that repeats.
+func taskRecurringMarker(t service.Task) string {
+	if t.CompletedAt != nil || t.Recurrence.IsZero() {
+		return ""
+	}
+	return " " + dueStyle.Render(recurringMarker)
+}
+
+// taskStateMarker labels pending tasks that have moved past the first
+// workflow state, such as "in progress".
+func (m *Model) taskStateMarker(t service.Task) string {
+	states := m.CoreModel.GetStates()
+	if t.CompletedAt != nil || t.State == "" || (len(states) > 0 && t.State == states[0]) {
+		return ""
+	}
+	return " " + stateStyle(t.State).Render("["+t.State+"]")
+}
+
+// taskTimerMarker marks the task whose timer is running.
+func (m *Model) taskTimerMarker(t service.Task) string {
+	if running := m.CoreModel.GetRunningTimer(); running == nil || running.TaskID != t.ID {
+		return ""
+	}
+	return " " + timerStyle.Render(timerMarker)
+}
+
+// formatClock writes the time a timer has been running, as in "1:05:09".
+func formatClock(d time.Duration) string {
+	seconds := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// renderTimerStatus renders the status line of the running timer, or
+// nothing when no timer is running.
+func (m *Model) renderTimerStatus() string {
+	running := m.CoreModel.GetRunningTimer()
+	if running == nil {
+		return ""
+	}
+	return timerStyle.Render(fmt.Sprintf("%s %s · %s  %s",
+		timerMarker, running.TaskTitle, running.ProjectName, formatClock(running.Duration(time.Now()))))
+}
+
+// priorityLabel names a priority along with its marker, as in "!! high".
+func priorityLabel(p service.Priority) string {
+	if p <= service.PriorityNone || p > service.PriorityUrgent {
+		return service.PriorityNone.String()
+	}
+	return priorityStyles[p].Render(priorityMarkers[p]) + " " + p.String()
+}
+
+func isOverdue(t service.Task, now time.Time) bool {
+	return t.CompletedAt == nil && t.DueAt != nil && service.DaysUntil(*t.DueAt, now) < 0
+}
+
 func (m *Model) renderTabularView() string {
 	leftWidth := m.width/2 - 4
 	rightWidth := m.width/2 - 4
@@ -134,6 +497,10 @@ func (m *Model) renderLogDetailPanel() string {
 
 	var s strings.Builder
 	s.WriteString(detailTitleStyle.PaddingLeft(2).Render(log.Title))
+	if tasks := m.logTasks(*log); len(tasks) > 0 {
+		s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(subStyle.Render("Tasks:") + renderDependencies(tasks)))
+		s.WriteString("\n\n")
+	}
 
 	s.WriteString(m.logViewport.View())
 
@@ -155,27 +522,29 @@ func (m *Model) renderTasksSplitView() string {
 	if len(tasks) == 0 {
 		taskListContent.WriteString(emptyDetailStyle.Render("No tasks for this project."))
 	} else {
-		var pending, completed []service.Task
-		for _, t := range tasks {
-			if t.CompletedAt != nil {
-				completed = append(completed, t)
-			} else {
-				pending = append(pending, t)
-			}
-		}
+		pending, completed := m.taskRows()
 
-		for i, t := range pending {
+		now := time.Now()
+		for i, row := range pending {
+			t := row.task
+			taskListContent.WriteString(m.milestoneHeader(pending, i))
+			blocked := service.IsBlocked(t, tasks)
 			taskLine := "[ ] " + t.Title
 			if i == m.selectedTaskIndex {
 				taskLine = lipgloss.NewStyle().
 					Foreground(lipgloss.AdaptiveColor{Light: "#EE6FF8", Dark: "#EE6FF8"}).
 					Render(taskLine)
+			} else if blocked {
+				taskLine = blockedStyle.Render(taskLine)
+			} else if isOverdue(t, now) {
+				taskLine = overdueStyle.Render(taskLine)
 			}
-			taskListContent.WriteString(detailItemStyle.Render(taskLine))
+			taskListContent.WriteString(detailItemStyle.Render(taskIndent(row) + taskLine + taskBlockedMarker(blocked) +
+				m.taskStateMarker(t) + subtaskProgress(row) + taskPriorityMarker(t) + taskRecurringMarker(t) + m.taskTimerMarker(t) + taskDueLabel(t, now) + taskTagChips(t)))
 			taskListContent.WriteString("\n")
 		}
 
-		if len(completed) > 0 {
+		if completedCount := countCompleted(tasks); completedCount > 0 {
 			taskListContent.WriteString("\n")
 
 			toggle := "▶"
@@ -183,14 +552,16 @@ func (m *Model) renderTasksSplitView() string {
 				toggle = "▼"
 			}
 
-			separator := fmt.Sprintf("%s Completed (%d)", toggle, len(completed))
+			separator := fmt.Sprintf("%s Completed (%d)", toggle, completedCount)
 			taskListContent.WriteString(lipgloss.NewStyle().
 				Foreground(lipgloss.Color("240")).
 				Render(separator))
 			taskListContent.WriteString("\n")
 
 			if m.showCompleted {
-				for i, t := range completed {
+				for i, row := range completed {
+					t := row.task
+					taskListContent.WriteString(m.milestoneHeader(completed, i))
 					taskLine := "[x] " + t.Title
 					completedIndex := len(pending) + i
 					if completedIndex == m.selectedTaskIndex {
@@ -198,9 +569,9 @@ func (m *Model) renderTasksSplitView() string {
 							Foreground(lipgloss.AdaptiveColor{Light: "#EE6FF8", Dark: "#EE6FF8"}).
 							Render(taskLine)
 					}
-					taskListContent.WriteString(lipgloss.NewStyle().
+					taskListContent.WriteString(taskIndent(row) + lipgloss.NewStyle().
 						Foreground(lipgloss.Color("240")).
-						Render(taskLine))
+						Render(taskLine) + subtaskProgress(row) + taskTagChips(t))
 					taskListContent.WriteString("\n")
 				}
 			}
@@ -245,6 +616,14 @@ func (m *Model) renderTaskReadonlyView() string {
 
 	s.WriteString(detailTitleStyle.Render(task.Title))
 	s.WriteString("\n")
+	if parent := m.findTask(task.ParentTaskID); parent != nil {
+		s.WriteString(subStyle.Render("Subtask of: " + parent.Title))
+		s.WriteString("\n")
+	}
+	if len(task.Tags) > 0 {
+		s.WriteString(renderTagChips(task.Tags))
+		s.WriteString("\n\n")
+	}
 	s.WriteString(detailItemStyle.Render(task.Desc))
 	s.WriteString("\n\n")
 
@@ -254,6 +633,55 @@ func (m *Model) renderTaskReadonlyView() string {
 		s.WriteString(subStyle.Render("Completed at: " + task.CompletedAt.Format("2006-01-02 15:04")))
 	} else {
 		s.WriteString(subStyle.Render("Status: Pending"))
+		if task.State != "" {
+			s.WriteString("\n")
+			s.WriteString(subStyle.Render("State: ") + stateStyle(task.State).Render(task.State))
+		}
+	}
+	if task.Priority != service.PriorityNone {
+		s.WriteString("\n")
+		s.WriteString(subStyle.Render("Priority: ") + priorityLabel(task.Priority))
+	}
+	if task.MilestoneID != nil {
+		if ms := m.findMilestone(*task.MilestoneID); ms != nil {
+			s.WriteString("\n")
+			s.WriteString(subStyle.Render("Milestone: " + milestoneMarker + " " + ms.Name))
+		}
+	}
+	if task.DueAt != nil {
+		s.WriteString("\n")
+		s.WriteString(subStyle.Render("Due: " + task.DueAt.Local().Format(service.DueDateLayout)))
+		s.WriteString(taskDueLabel(*task, time.Now()))
+	}
+	if !task.Recurrence.IsZero() {
+		s.WriteString("\n")
+		s.WriteString(subStyle.Render("Repeats: " + recurringMarker + " " + task.Recurrence.Describe()))
+	}
+	if spent := m.CoreModel.TimeSpent(time.Now())[task.ID]; spent > 0 || m.taskTimerMarker(*task) != "" {
+		s.WriteString("\n")
+		s.WriteString(subStyle.Render("Time spent: "+service.FormatDuration(spent)) + m.taskTimerMarker(*task))
+	}
+
+	blockers, unblocks := m.taskDependencies(*task)
+	if len(blockers) > 0 {
+		s.WriteString("\n\n")
+		s.WriteString(subStyle.Render("Blocked by:"))
+		s.WriteString(renderDependencies(blockers))
+	}
+	if len(unblocks) > 0 {
+		s.WriteString("\n\n")
+		s.WriteString(subStyle.Render("Unblocks:"))
+		s.WriteString(renderDependencies(unblocks))
+	}
+	if links := m.CoreModel.TaskLinks(task.ID); len(links) > 0 {
+		s.WriteString("\n\n")
+		s.WriteString(subStyle.Render("Links:"))
+		s.WriteString(m.renderLinks(links))
+	}
+	if logs := m.taskLogs(*task); len(logs) > 0 {
+		s.WriteString("\n\n")
+		s.WriteString(subStyle.Render("Logs:"))
+		s.WriteString(renderTaskLogs(logs))
 	}
 
 	return s.String()
@@ -283,6 +711,10 @@ func (m *Model) renderTabs() string {
 	tabs = append(tabs, m.tabTitle("Tasks", tasksTab, tabStyle, activeTabStyle))
 	tabs = append(tabs, m.tabTitle("Logs", logsTab, tabStyle, activeTabStyle))
 
+	if tag := m.CoreModel.GetTagFilter(); tag != "" {
+		tabs = append(tabs, lipgloss.NewStyle().Margin(2, 0, 0, 2).Render(subStyle.Render("filter ")+renderTagChips([]string{tag})))
+	}
+
 	return lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
 }
 
@@ -300,6 +732,10 @@ func (m *Model) renderProjectDetails() string {
 	s.WriteString(detailTitleStyle.Render(project.Name))
 	s.WriteString("\n")
 	s.WriteString(getStatusIndicator(project.Status))
+	if len(project.Tags) > 0 {
+		s.WriteString("\n\n")
+		s.WriteString(renderTagChips(project.Tags))
+	}
 	if project.Summary != "" {
 		s.WriteString("\n\n")
 		s.WriteString(projectDetailStyle.Render("Summary: ") + detailItemStyle.Render(project.Summary))
@@ -308,6 +744,29 @@ func (m *Model) renderProjectDetails() string {
 		s.WriteString("\n\n")
 		s.WriteString(projectDetailStyle.Render("Description: ") + detailItemStyle.Render(project.Desc))
 	}
+	if subprojects := m.CoreModel.Subprojects(project.ID); len(subprojects) > 0 {
+		s.WriteString("\n\n")
+		s.WriteString(projectDetailStyle.Render("Subprojects:"))
+		s.WriteString(renderSubprojects(subprojects))
+	}
+	if len(m.CoreModel.GetMilestones()) > 0 {
+		s.WriteString("\n\n")
+		s.WriteString(projectDetailStyle.Render("Milestones:"))
+		s.WriteString(m.renderMilestones())
+	}
+	if links := m.CoreModel.ProjectLinks(); len(links) > 0 {
+		s.WriteString("\n\n")
+		s.WriteString(projectDetailStyle.Render("Links:"))
+		s.WriteString(m.renderLinks(links))
+	}
+	var spent time.Duration
+	for _, d := range m.CoreModel.TimeSpent(time.Now()) {
+		spent += d
+	}
+	if spent > 0 {
+		s.WriteString("\n\n")
+		s.WriteString(projectDetailStyle.Render("Time spent: ") + detailItemStyle.Render(service.FormatDuration(spent)))
+	}
 	s.WriteString("\n")
 	return s.String()
 }
@@ -325,27 +784,29 @@ func (m *Model) renderTasksListOnly() string {
 		return s.String()
 	}
 
-	var pending, completed []service.Task
-	for _, t := range tasks {
-		if t.CompletedAt != nil {
-			completed = append(completed, t)
-		} else {
-			pending = append(pending, t)
-		}
-	}
+	pending, completed := m.taskRows()
 
-	for i, t := range pending {
+	now := time.Now()
+	for i, row := range pending {
+		t := row.task
+		s.WriteString(m.milestoneHeader(pending, i))
+		blocked := service.IsBlocked(t, tasks)
 		taskLine := "[ ] " + t.Title
 		if i == m.selectedTaskIndex {
 			taskLine = lipgloss.NewStyle().
 				Foreground(lipgloss.AdaptiveColor{Light: "#EE6FF8", Dark: "#EE6FF8"}).
 				Render(taskLine)
+		} else if blocked {
+			taskLine = blockedStyle.Render(taskLine)
+		} else if isOverdue(t, now) {
+			taskLine = overdueStyle.Render(taskLine)
 		}
-		s.WriteString(detailItemStyle.Render(taskLine))
+		s.WriteString(detailItemStyle.Render(taskIndent(row) + taskLine + taskBlockedMarker(blocked) +
+			m.taskStateMarker(t) + subtaskProgress(row) + taskPriorityMarker(t) + taskRecurringMarker(t) + m.taskTimerMarker(t) + taskDueLabel(t, now) + taskTagChips(t)))
 		s.WriteString("\n")
 	}
 
-	if len(completed) > 0 {
+	if completedCount := countCompleted(tasks); completedCount > 0 {
 		s.WriteString("\n")
 
 		toggle := "▶"
@@ -353,14 +814,16 @@ func (m *Model) renderTasksListOnly() string {
 			toggle = "▼"
 		}
 
-		separator := fmt.Sprintf("%s Completed (%d)", toggle, len(completed))
+		separator := fmt.Sprintf("%s Completed (%d)", toggle, completedCount)
 		s.WriteString(lipgloss.NewStyle().
 			Foreground(lipgloss.Color("240")).
 			Render(separator))
 		s.WriteString("\n")
 
 		if m.showCompleted {
-			for i, t := range completed {
+			for i, row := range completed {
+				t := row.task
+				s.WriteString(m.milestoneHeader(completed, i))
 				taskLine := "[x] " + t.Title
 				completedIndex := len(pending) + i
 				if completedIndex == m.selectedTaskIndex {
@@ -368,9 +831,9 @@ func (m *Model) renderTasksListOnly() string {
 						Foreground(lipgloss.AdaptiveColor{Light: "#EE6FF8", Dark: "#EE6FF8"}).
 						Render(taskLine)
 				}
-				s.WriteString(lipgloss.NewStyle().
+				s.WriteString(taskIndent(row) + lipgloss.NewStyle().
 					Foreground(lipgloss.Color("240")).
-					Render(taskLine))
+					Render(taskLine) + subtaskProgress(row) + taskTagChips(t))
 				s.WriteString("\n")
 			}
 		}
@@ -456,7 +919,8 @@ func (m *Model) View() string {
 		if m.logEditForm != nil {
 			mainContent = m.logEditForm.View()
 		}
-	case updateView, createView, deleteView, createTaskView, createLogView, deleteTaskView, deleteLogView:
+	case updateView, createView, deleteView, createTaskView, createLogView, deleteTaskView, deleteLogView, tagFilterView,
+		blockersView, createMilestoneView, taskMilestoneView, addLinkView, openLinkView:
 		mainContent = m.renderCenteredForm()
 	}
 
@@ -474,6 +938,16 @@ func (m *Model) View() string {
 		finalView = mainContent
 	}
 
+	if status := m.renderTimerStatus(); status != "" {
+		finalView += "\n" + status
+	}
+	if m.openErr != nil {
+		finalView += "\n" + linkErrorStyle.Render("Could not open link: "+m.openErr.Error())
+	}
+	if m.moveErr != nil {
+		finalView += "\n" + moveErrorStyle.Render("Could not move task: "+m.moveErr.Error())
+	}
+
 	return appStyle.Render(finalView)
 }
 
@@ -499,7 +973,14 @@ func (m *Model) renderTaskDeleteDialog() string {
 		styledName,
 		lipgloss.NewStyle().Bold(true).Render("?"),
 	)
-	return m.renderConfirmationDialog(question, "")
+	var subtext string
+	for _, t := range m.CoreModel.tasks {
+		if t.ParentTaskID != nil && *t.ParentTaskID == task.ID {
+			subtext = "Its subtasks will be deleted too."
+			break
+		}
+	}
+	return m.renderConfirmationDialog(question, subtext)
 }
 
 func (m *Model) renderLogDeleteDialog() string {
@@ -0,0 +1,198 @@
+// Package workspace maps named workspaces to their own SQLite database files
+// and remembers which one is opened by default.
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultName is the workspace backed by the original addae.db file.
+const DefaultName = "default"
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Workspace describes a named database.
+type Workspace struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Default bool   `json:"default"`
+}
+
+// Store manages workspaces under a single configuration directory.
+type Store struct {
+	dir string
+}
+
+// config is the persisted workspace configuration.
+type config struct {
+	Workspace string `json:"workspace"`
+}
+
+// NewStore returns a Store rooted at dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultStore returns a Store in the OS-specific user configuration directory,
+// the same place addae has always kept addae.db.
+func DefaultStore() (*Store, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return NewStore(filepath.Join(configDir, "addae")), nil
+}
+
+// ValidateName checks that name can be used as a workspace name.
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid workspace name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+// Path returns the database file used by the named workspace.
+func (s *Store) Path(name string) string {
+	if name == DefaultName {
+		return filepath.Join(s.dir, "addae.db")
+	}
+	return filepath.Join(s.dir, "workspaces", name+".db")
+}
+
+// Exists reports whether the named workspace has a database file.
+func (s *Store) Exists(name string) bool {
+	_, err := os.Stat(s.Path(name))
+	return err == nil
+}
+
+// List returns every workspace, with the default workspace first.
+func (s *Store) List() ([]Workspace, error) {
+	current, err := s.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{DefaultName}
+	entries, err := os.ReadDir(filepath.Join(s.dir, "workspaces"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read workspaces: %w", err)
+	}
+	var named []string
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".db")
+		if e.IsDir() || !ok || ValidateName(name) != nil {
+			continue
+		}
+		named = append(named, name)
+	}
+	sort.Strings(named)
+	names = append(names, named...)
+
+	workspaces := make([]Workspace, len(names))
+	for i, name := range names {
+		workspaces[i] = Workspace{Name: name, Path: s.Path(name), Default: name == current}
+	}
+	return workspaces, nil
+}
+
+// Create makes an empty database file for the named workspace.
+func (s *Store) Create(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	if s.Exists(name) {
+		return "", fmt.Errorf("workspace %q already exists", name)
+	}
+
+	path := s.Path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return path, f.Close()
+}
+
+// Remove deletes the named workspace's database. The default workspace and the
+// workspace currently set as default cannot be removed.
+func (s *Store) Remove(name string) error {
+	if name == DefaultName {
+		return fmt.Errorf("the %q workspace cannot be removed", DefaultName)
+	}
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if !s.Exists(name) {
+		return fmt.Errorf("workspace %q does not exist", name)
+	}
+	current, err := s.Current()
+	if err != nil {
+		return err
+	}
+	if name == current {
+		return fmt.Errorf("workspace %q is the default, switch to another one first", name)
+	}
+
+	path := s.Path(name)
+	for _, p := range []string{path, path + "-wal", path + "-shm"} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove workspace: %w", err)
+		}
+	}
+	return nil
+}
+
+// Current returns the persisted default workspace.
+func (s *Store) Current() (string, error) {
+	data, err := os.ReadFile(s.configPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultName, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read workspace config: %w", err)
+	}
+
+	var c config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", s.configPath(), err)
+	}
+	if c.Workspace == "" {
+		return DefaultName, nil
+	}
+	return c.Workspace, nil
+}
+
+// Use persists name as the default workspace.
+func (s *Store) Use(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if name != DefaultName && !s.Exists(name) {
+		return fmt.Errorf("workspace %q does not exist", name)
+	}
+
+	data, err := json.MarshalIndent(config{Workspace: name}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(s.configPath(), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to save workspace config: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) configPath() string {
+	return filepath.Join(s.dir, "config.json")
+}
@@ -0,0 +1,97 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	store := NewStore("/config/addae")
+
+	if got := store.Path(DefaultName); got != filepath.Join("/config/addae", "addae.db") {
+		t.Errorf("expected default workspace to use addae.db, got %s", got)
+	}
+	if got := store.Path("work"); got != filepath.Join("/config/addae", "workspaces", "work.db") {
+		t.Errorf("unexpected path for named workspace: %s", got)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"work", "client-a", "side_projects", "v2.0"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("expected %q to be valid: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "../etc", "a/b", ".hidden", "with space"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("expected %q to be invalid", name)
+		}
+	}
+}
+
+func TestCreateListUseRemove(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	current, err := store.Current()
+	if err != nil {
+		t.Fatalf("Current failed: %v", err)
+	}
+	if current != DefaultName {
+		t.Errorf("expected %q to be the default, got %q", DefaultName, current)
+	}
+
+	path, err := store.Create("work")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected database file to exist: %v", err)
+	}
+	if _, err := store.Create("work"); err == nil {
+		t.Errorf("expected error creating a duplicate workspace")
+	}
+
+	if err := store.Use("missing"); err == nil {
+		t.Errorf("expected error using a missing workspace")
+	}
+	// The parent of the workspaces directory holds the default database,
+	// so a path that reaches it exists but is not a workspace name.
+	if err := os.WriteFile(store.Path(DefaultName), nil, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := store.Use("../addae"); err == nil {
+		t.Errorf("expected error using an invalid workspace name")
+	}
+	if err := store.Use("work"); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+
+	workspaces, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(workspaces) != 2 || workspaces[0].Name != DefaultName || workspaces[1].Name != "work" {
+		t.Fatalf("unexpected workspaces: %+v", workspaces)
+	}
+	if workspaces[0].Default || !workspaces[1].Default {
+		t.Errorf("expected work to be the default: %+v", workspaces)
+	}
+
+	if err := store.Remove("work"); err == nil {
+		t.Errorf("expected error removing the default workspace")
+	}
+	if err := store.Remove(DefaultName); err == nil {
+		t.Errorf("expected error removing the %q workspace", DefaultName)
+	}
+
+	if err := store.Use(DefaultName); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+	if err := store.Remove("work"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if store.Exists("work") {
+		t.Errorf("expected workspace to be removed")
+	}
+}
@@ -7,12 +7,7 @@ import (
 	"io/fs"
 	"os"
 
-	"github.com/quamejnr/addae/internal/service"
-	"github.com/quamejnr/addae/internal/ui"
-
-	"github.com/quamejnr/addae/internal/db"
-
-	tea "github.com/charmbracelet/bubbletea"
+	"github.com/quamejnr/addae/internal/cli"
 )
 
 var version = "dev"
@@ -21,51 +16,40 @@ var version = "dev"
 var migrationsFS embed.FS
 
 func main() {
+	os.Exit(run())
+}
+
+func run() int {
 	var showVersion bool
+	var dbPath, workspaceName string
 
 	flag.BoolVar(&showVersion, "version", false, "Print version information")
+	flag.StringVar(&dbPath, "db", "", "Path to the database file (overrides $ADDAE_DB and --workspace)")
+	flag.StringVar(&workspaceName, "workspace", "", "Named workspace to open instead of the default")
 	flag.Parse()
 
 	if showVersion {
 		fmt.Printf("addae version %s\n", version)
-		os.Exit(0)
+		return 0
 	}
 
-	// Initialize database
-	database, err := db.InitDB("")
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	defer database.Close()
-
 	// Get the migrations subdirectory
 	migrations, err := fs.Sub(migrationsFS, "internal/db/migrations")
 	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	// Run migrations with embedded files
-	if err := db.RunMigrationsFromFS(database, migrations); err != nil {
-		fmt.Println(err)
-		return
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
 
-	// Initialize service with database
-	svc := service.NewService(database)
-
-	// Initialize TUI
-	model, err := ui.NewModel(svc)
+	app, err := cli.NewApp(cli.Options{
+		DBPath:     dbPath,
+		Workspace:  workspaceName,
+		Migrations: migrations,
+	})
 	if err != nil {
-		fmt.Println(err)
-		return
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
 
-	// Start TUI program
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Println(err)
-		return
-	}
+	// Start the TUI, or run a subcommand if one was given
+	return app.Run(flag.Args())
 }